@@ -0,0 +1,64 @@
+package global_const
+
+import "fmt"
+
+// ConfirmMode 充值确认方式
+type ConfirmMode uint8
+
+const (
+	ConfirmByDepth     ConfirmMode = iota + 1 // 区块深度达到 Depth
+	ConfirmBySafe                             // 区块高度不超过节点的 safe 区块
+	ConfirmByFinalized                        // 区块高度不超过节点的 finalized 区块
+)
+
+// ConfirmationPolicy 充值入账前需要满足的确认策略
+type ConfirmationPolicy struct {
+	Mode  ConfirmMode
+	Depth uint64 // ConfirmByDepth 时需要的确认数（包含区块本身）
+}
+
+const defaultConfirmations = 12
+
+// confirmationPolicies 各条链默认的确认策略，支持 safe/finalized 标签的链优先使用标签
+var confirmationPolicies = map[uint64]ConfirmationPolicy{
+	EthereumChainId:        {Mode: ConfirmByFinalized},
+	EthereumSepoliaChainId: {Mode: ConfirmBySafe},
+	OpChinId:               {Mode: ConfirmBySafe},
+	OpTestChinId:           {Mode: ConfirmBySafe},
+	BaseChainId:            {Mode: ConfirmBySafe},
+	BaseSepoliaChainId:     {Mode: ConfirmBySafe},
+	MantleChainId:          {Mode: ConfirmBySafe},
+	MantleSepoliaChainId:   {Mode: ConfirmBySafe},
+	MantaChainId:           {Mode: ConfirmBySafe},
+	MantaSepoliaChainId:    {Mode: ConfirmBySafe},
+	ScrollChainId:          {Mode: ConfirmByDepth, Depth: 30},
+	LineaChainId:           {Mode: ConfirmByDepth, Depth: 30},
+	PolygonChainId:         {Mode: ConfirmByDepth, Depth: 64},
+	PolygonSepoliaChainId:  {Mode: ConfirmByDepth, Depth: 64},
+	ZkFairChainId:          {Mode: ConfirmByDepth, Depth: 20},
+	ZkFairSepoliaChainId:   {Mode: ConfirmByDepth, Depth: 20},
+	OkxChainId:             {Mode: ConfirmByDepth, Depth: 3},
+	OkxSepoliaChainId:      {Mode: ConfirmByDepth, Depth: 3},
+}
+
+// ConfirmationPolicyOf 返回链的确认策略，未登记的链按区块深度确认
+func ConfirmationPolicyOf(chainId uint64) ConfirmationPolicy {
+	if policy, ok := confirmationPolicies[chainId]; ok {
+		return policy
+	}
+	return ConfirmationPolicy{Mode: ConfirmByDepth, Depth: defaultConfirmations}
+}
+
+// ParseConfirmMode 解析配置文件中的确认方式
+func ParseConfirmMode(mode string) (ConfirmMode, error) {
+	switch mode {
+	case "depth":
+		return ConfirmByDepth, nil
+	case "safe":
+		return ConfirmBySafe, nil
+	case "finalized":
+		return ConfirmByFinalized, nil
+	default:
+		return 0, fmt.Errorf("unknown confirm mode %q", mode)
+	}
+}
@@ -20,6 +20,55 @@ const (
 	BridgeOperaInitType     = 1
 	BridgeOperaFinalizeType = 2
 
+	AddressTypeUser = 1 // 用户充值地址
+	AddressTypeHot  = 2 // 热钱包地址
+	AddressTypeCold = 3 // 冷钱包地址
+
+	DepositStatusSeen       = 1 // 已扫到
+	DepositStatusConfirming = 2 // 确认中
+	DepositStatusSafe       = 3 // 满足确认策略，等待入账
+	DepositStatusCredited   = 4 // 已入账
+	DepositStatusReverted   = 5 // 所在区块被重组回滚
+
+	WithdrawStatusQueued    = 1 // 排队中
+	WithdrawStatusSigned    = 2 // 已分配 nonce 并签名
+	WithdrawStatusBroadcast = 3 // 已广播
+	WithdrawStatusConfirmed = 4 // 已上链且执行成功
+	WithdrawStatusFailed    = 5 // 已上链但执行失败
+	WithdrawStatusCancelled = 6 // 已取消（排队中直接取消，或取消交易已上链）
+
+	WithdrawTypeUser          = 1 // 用户提现
+	WithdrawTypeSweep         = 2 // 热钱包归集到冷钱包的内部转账
+	WithdrawTypeCollection    = 3 // 用户充值地址归集到热钱包的内部转账
+	WithdrawTypeCollectionGas = 4 // ERC-20 归集前为用户充值地址补充手续费
+
+	WithdrawTxTypeOriginal = 1 // 首次签名的交易
+	WithdrawTxTypeSpeedUp  = 2 // 提高手续费的替换交易
+	WithdrawTxTypeCancel   = 3 // 同 nonce 的 0 金额转给自己，用于取消
+
+	CollectionStatusPending  = 1 // 已发现，等待提交
+	CollectionStatusFunding  = 2 // ERC-20 归集前补手续费中
+	CollectionStatusSweeping = 3 // 归集转账已提交
+	CollectionStatusDone     = 4 // 归集完成
+	CollectionStatusFailed   = 5 // 补手续费或归集失败，需要人工处理
+
+	RetryStatusPending   = 1 // 等待重试
+	RetryStatusDone      = 2 // 重试成功
+	RetryStatusDead      = 3 // 超过最大重试次数，进入死信等待人工处理
+	RetryStatusAbandoned = 4 // 人工放弃
+
+	EventTypeDeposit  = "deposit"
+	EventTypeWithdraw = "withdraw"
+
+	WebhookStatusPending   = 1 // 等待首次投递
+	WebhookStatusDelivered = 2 // 对方返回 2xx
+	WebhookStatusFailed    = 3 // 最近一次投递失败，由重试模块继续投递
+
+	RetryKindWithdrawBroadcast = "withdraw_broadcast" // 提现交易广播失败
+	RetryKindDepositCredit     = "deposit_credit"     // 充值入账失败
+	RetryKindCollection        = "collection"         // 归集步骤失败
+	RetryKindWebhook           = "webhook"            // 回调投递失败
+
 	ScrollChainId          uint64 = 534352
 	PolygonChainId         uint64 = 1101
 	PolygonSepoliaChainId  uint64 = 1442
@@ -0,0 +1,33 @@
+package global_const
+
+// L1FeeModel rollup 链 L1 数据费的计算方式，数据费在执行费之外从发送方余额中额外扣除
+type L1FeeModel uint8
+
+const (
+	L1FeeNone         L1FeeModel = iota // L1 以及数据费已计入 gasPrice 的链
+	L1FeeOpStack                        // OP-stack 链 GasPriceOracle 预部署合约
+	L1FeeScrollOracle                   // Scroll L1GasPriceOracle 预部署合约
+)
+
+const (
+	OpGasPriceOracle       = "0x420000000000000000000000000000000000000F"
+	ScrollL1GasPriceOracle = "0x5300000000000000000000000000000000000002"
+)
+
+// l1FeeModels 单独收取 L1 数据费的 rollup 链
+var l1FeeModels = map[uint64]L1FeeModel{
+	OpChinId:             L1FeeOpStack,
+	OpTestChinId:         L1FeeOpStack,
+	BaseChainId:          L1FeeOpStack,
+	BaseSepoliaChainId:   L1FeeOpStack,
+	MantleChainId:        L1FeeOpStack,
+	MantleSepoliaChainId: L1FeeOpStack,
+	MantaChainId:         L1FeeOpStack,
+	MantaSepoliaChainId:  L1FeeOpStack,
+	ScrollChainId:        L1FeeScrollOracle,
+}
+
+// L1FeeModelOf 返回链的 L1 数据费计算方式
+func L1FeeModelOf(chainId uint64) L1FeeModel {
+	return l1FeeModels[chainId]
+}
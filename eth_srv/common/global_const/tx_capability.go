@@ -0,0 +1,43 @@
+package global_const
+
+import "fmt"
+
+// TxCapability 链支持的交易类型，legacy 交易所有链都支持
+type TxCapability struct {
+	DynamicFee bool // 支持 EIP-1559 动态费用交易
+	AccessList bool // 支持 EIP-2930 访问列表交易
+}
+
+var londonCapability = TxCapability{DynamicFee: true, AccessList: true}
+
+// txCapabilities 不支持 EIP-1559 的链，只能发送 gasPrice 交易
+var txCapabilities = map[uint64]TxCapability{
+	PolygonChainId:        {},
+	PolygonSepoliaChainId: {},
+	ZkFairChainId:         {},
+	ZkFairSepoliaChainId:  {},
+	OkxChainId:            {},
+	OkxSepoliaChainId:     {},
+}
+
+// TxCapabilityOf 返回链支持的交易类型，未登记的链按支持 EIP-1559 处理
+func TxCapabilityOf(chainId uint64) TxCapability {
+	if capability, ok := txCapabilities[chainId]; ok {
+		return capability
+	}
+	return londonCapability
+}
+
+// ParseTxType 解析配置文件中链支持的最高交易类型
+func ParseTxType(txType string) (TxCapability, error) {
+	switch txType {
+	case "legacy":
+		return TxCapability{}, nil
+	case "access_list":
+		return TxCapability{AccessList: true}, nil
+	case "dynamic_fee":
+		return londonCapability, nil
+	default:
+		return TxCapability{}, fmt.Errorf("unknown tx type %q", txType)
+	}
+}
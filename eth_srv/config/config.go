@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type MysqlConfig struct {
 	Host     string `mapstructure:"host" json:"host"`
 	Port     int    `mapstructure:"port" json:"port"`
@@ -8,6 +10,158 @@ type MysqlConfig struct {
 	Password string `mapstructure:"password" json:"password"`
 }
 
+// TokenConfig 支持充值的 ERC-20 代币，未登记的代币不入账
+type TokenConfig struct {
+	Symbol   string `mapstructure:"symbol" json:"symbol"`
+	Address  string `mapstructure:"address" json:"address"`
+	Decimals uint8  `mapstructure:"decimals" json:"decimals"`
+}
+
+// WalletConfig 热钱包地址，私钥保存在签名后端中，按地址或 key id 引用
+type WalletConfig struct {
+	Address string `mapstructure:"address" json:"address"`
+	KeyId   string `mapstructure:"key_id" json:"key_id"` // hsm 后端中的密钥标识，其他后端按地址查找
+}
+
+// SignerConfig 热钱包的签名后端
+type SignerConfig struct {
+	Type          string            `mapstructure:"type" json:"type"` // keystore/remote/hsm
+	KeystoreDir   string            `mapstructure:"keystore_dir" json:"keystore_dir"`
+	PasswordFile  string            `mapstructure:"password_file" json:"password_file"`
+	RemoteTarget  string            `mapstructure:"remote_target" json:"remote_target"` // 远程签名服务的 gRPC 地址
+	RemoteTimeout time.Duration     `mapstructure:"remote_timeout" json:"remote_timeout"`
+	RemoteTLS     SignerTLSConfig   `mapstructure:"remote_tls" json:"remote_tls"` // 连接签名机的双向 TLS 与共享令牌
+	HsmModule     string            `mapstructure:"hsm_module" json:"hsm_module"` // 通过 wallet.RegisterHSMModule 登记的设备名
+	HsmOptions    map[string]string `mapstructure:"hsm_options" json:"-"`
+	HdXprvFile    string            `mapstructure:"hd_xprv_file" json:"hd_xprv_file"` // 与 hd_xpub 对应的账户扩展私钥文件，只在签名机上配置，用于归集派生的充值地址
+	HdMaxIndex    uint32            `mapstructure:"hd_max_index" json:"hd_max_index"` // 查找派生地址时最多派生到的序号
+}
+
+// SignerTLSConfig 远程签名两端的双向 TLS 与共享令牌，各自配置本端证书并用 ca_file 校验对端证书
+type SignerTLSConfig struct {
+	CertFile   string `mapstructure:"cert_file" json:"cert_file"`
+	KeyFile    string `mapstructure:"key_file" json:"key_file"`
+	CaFile     string `mapstructure:"ca_file" json:"ca_file"`
+	ServerName string `mapstructure:"server_name" json:"server_name"` // 业务服务校验签名机证书的名称，为空时使用 remote_target 的主机名
+	TokenFile  string `mapstructure:"token_file" json:"token_file"`   // 共享令牌文件，为空时只使用双向 TLS 认证
+}
+
+// SignerHostConfig 签名机 signer 子命令的配置，签名机只持有密钥，不连接数据库和节点
+type SignerHostConfig struct {
+	Listen  string          `mapstructure:"listen" json:"listen"`
+	Signer  SignerConfig    `mapstructure:"signer" json:"signer"`   // keystore/hsm
+	Wallets []WalletConfig  `mapstructure:"wallets" json:"wallets"` // 允许签名的地址，hsm 后端需要配置 key_id
+	TLS     SignerTLSConfig `mapstructure:"tls" json:"tls"`
+}
+
+// SweepConfig 热钱包单个资产的归集水位，金额均为最小单位。
+// 余额超过 HighWatermark 时，把高于 LowWatermark 的部分转入冷钱包
+type SweepConfig struct {
+	Token         string `mapstructure:"token" json:"token"` // 代币合约地址，为空表示原生币
+	HighWatermark string `mapstructure:"high_watermark" json:"high_watermark"`
+	LowWatermark  string `mapstructure:"low_watermark" json:"low_watermark"`
+}
+
+// CollectionConfig 用户地址单个资产的归集阈值，余额超过阈值时归集到热钱包，金额为最小单位
+type CollectionConfig struct {
+	Token     string `mapstructure:"token" json:"token"` // 代币合约地址，为空表示原生币
+	Threshold string `mapstructure:"threshold" json:"threshold"`
+}
+
+// RetryConfig 失败任务的重试策略，第 n 次重试前等待 base_delay * 2^(n-1)，不超过 max_delay，并加入随机抖动
+type RetryConfig struct {
+	MaxAttempts uint32        `mapstructure:"max_attempts" json:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay" json:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay" json:"max_delay"`
+}
+
+// FeeConfig 手续费档位与上限，金额单位为 wei
+type FeeConfig struct {
+	Tier       string `mapstructure:"tier" json:"tier"`               // slow/normal/fast，出款和归集使用的档位，默认 normal
+	BlockCount uint64 `mapstructure:"block_count" json:"block_count"` // eth_feeHistory 采样的区块数
+	MaxFeeCap  string `mapstructure:"max_fee_cap" json:"max_fee_cap"` // maxFeePerGas（legacy 为 gasPrice）上限，为空时不限制
+	MaxTipCap  string `mapstructure:"max_tip_cap" json:"max_tip_cap"` // maxPriorityFeePerGas 上限，为空时不限制
+	GasMargin  uint64 `mapstructure:"gas_margin" json:"gas_margin"`   // 估算 gas 的安全余量百分比，默认 20
+}
+
+// RpcEndpointConfig 链节点地址，priority 越小越优先。限流按请求权重计算，各方法的默认权重见 node.defaultWeights
+type RpcEndpointConfig struct {
+	Url             string `mapstructure:"url" json:"url"`
+	Priority        int    `mapstructure:"priority" json:"priority"`
+	RpcLimitsConfig `mapstructure:",squash"`
+}
+
+// RpcLimitsConfig 单个节点的限流与批量请求配置
+type RpcLimitsConfig struct {
+	RateLimit    float64        `mapstructure:"rate_limit" json:"rate_limit"`         // 每秒允许的请求权重，0 表示不限流
+	Burst        int            `mapstructure:"burst" json:"burst"`                   // 令牌桶容量，默认为一秒的权重
+	MaxBatchSize int            `mapstructure:"max_batch_size" json:"max_batch_size"` // 单个批量请求的最大条数，默认 100，节点拒绝时自动减小
+	Weights      map[string]int `mapstructure:"weights" json:"weights"`               // 覆盖方法的请求权重，如 eth_getLogs: 20
+}
+
+// RpcConfig 多节点路由配置，按区块高度和延迟检查节点健康，请求失败时切换节点
+type RpcConfig struct {
+	Endpoints      []RpcEndpointConfig `mapstructure:"endpoints" json:"endpoints"`             // 为空时只使用 rpc_url
+	WsUrl          string              `mapstructure:"ws_url" json:"ws_url"`                   // 订阅 newHeads 的 WebSocket 地址，为空时使用 ws:// 开头的 rpc_url 或 endpoints，都没有时按出块时间轮询
+	HealthInterval time.Duration       `mapstructure:"health_interval" json:"health_interval"` // 健康检查间隔，默认 10s
+	MaxLag         uint64              `mapstructure:"max_lag" json:"max_lag"`                 // 落后最高节点超过该区块数的节点不参与路由，默认 5
+	StuckTimeout   time.Duration       `mapstructure:"stuck_timeout" json:"stuck_timeout"`     // 区块高度超过该时间未增长的节点不参与路由，默认 1m
+	Quorum         int                 `mapstructure:"quorum" json:"quorum"`                   // 入账使用的收据和区块头需要至少 quorum 个节点返回一致结果，0 表示不开启
+	Limits         RpcLimitsConfig     `mapstructure:"limits" json:"limits"`                   // 只配置 rpc_url 时该节点的限流与批量请求配置
+}
+
+// WebhookConfig 订阅方的回调地址，按 event_types 订阅充值和提现事件，请求体用 secret 做 HMAC-SHA256 签名。
+// 每个订阅方都会收到全部地址的事件，不能用来隔离不同业务方的数据
+type WebhookConfig struct {
+	Subscriber string   `mapstructure:"subscriber" json:"subscriber"` // 订阅方标识，投递记录按订阅方维护进度，配置后不要修改
+	Url        string   `mapstructure:"url" json:"url"`
+	Secret     string   `mapstructure:"secret" json:"-"`
+	EventTypes []string `mapstructure:"event_types" json:"event_types"` // deposit/withdraw，为空表示全部
+}
+
+// MasterKeyConfig 信封加密的主密钥，文件中为 hex 编码的 32 字节密钥
+type MasterKeyConfig struct {
+	Id   string `mapstructure:"id" json:"id"`
+	File string `mapstructure:"file" json:"file"`
+}
+
+// KeyEncryptionConfig 数据库中地址私钥的信封加密配置，轮换时新增主密钥并修改 current，旧主密钥保留到重新包裹完成
+type KeyEncryptionConfig struct {
+	Current    string            `mapstructure:"current" json:"current"`
+	MasterKeys []MasterKeyConfig `mapstructure:"master_keys" json:"master_keys"`
+}
+
+// EthConfig 链节点以及扫块儿相关配置
+type EthConfig struct {
+	RpcUrl          string              `mapstructure:"rpc_url" json:"rpc_url"`
+	Rpc             RpcConfig           `mapstructure:"rpc" json:"rpc"`
+	ChainId         uint64              `mapstructure:"chain_id" json:"chain_id"`
+	StartingHeight  uint64              `mapstructure:"starting_height" json:"starting_height"` // 首次扫块儿的起始高度，0 表示从最新块开始
+	BlocksStep      uint64              `mapstructure:"blocks_step" json:"blocks_step"`         // 每批次扫描的区块数量，不超过节点批量请求上限的 4 倍
+	ReorgWindow     uint64              `mapstructure:"reorg_window" json:"reorg_window"`       // 持久化保留的区块头数量，决定可回滚的最大深度
+	LoopInterval    time.Duration       `mapstructure:"loop_interval" json:"loop_interval"`
+	Tokens          []TokenConfig       `mapstructure:"tokens" json:"tokens"`
+	ConfirmMode     string              `mapstructure:"confirm_mode" json:"confirm_mode"`   // depth/safe/finalized，为空时使用 global_const 中的链默认策略
+	Confirmations   uint64              `mapstructure:"confirmations" json:"confirmations"` // depth 模式下的确认数
+	TxType          string              `mapstructure:"tx_type" json:"tx_type"`             // legacy/access_list/dynamic_fee，为空时使用 global_const 中的链默认交易类型
+	Fee             FeeConfig           `mapstructure:"fee" json:"fee"`
+	HotWallets      []WalletConfig      `mapstructure:"hot_wallets" json:"hot_wallets"` // 第一个为默认出款地址
+	Signer          SignerConfig        `mapstructure:"signer" json:"signer"`
+	KeyEncryption   KeyEncryptionConfig `mapstructure:"key_encryption" json:"key_encryption"`
+	HdXpub          string              `mapstructure:"hd_xpub" json:"hd_xpub"`                 // m/44'/60'/0' 的扩展公钥，配置后用户充值地址由其派生
+	WithdrawWorker  int                 `mapstructure:"withdraw_worker" json:"withdraw_worker"` // 并发签名广播的协程数
+	StuckTimeout    time.Duration       `mapstructure:"stuck_timeout" json:"stuck_timeout"`     // 交易广播后超过该时间未上链则提高手续费重发
+	ColdWallet      string              `mapstructure:"cold_wallet" json:"cold_wallet"`         // 归集目标冷钱包地址，为空时不归集
+	SweepInterval   time.Duration       `mapstructure:"sweep_interval" json:"sweep_interval"`
+	Sweeps          []SweepConfig       `mapstructure:"sweeps" json:"sweeps"`
+	CollectInterval time.Duration       `mapstructure:"collect_interval" json:"collect_interval"`
+	Retry           RetryConfig         `mapstructure:"retry" json:"retry"`
+	Collections     []CollectionConfig  `mapstructure:"collections" json:"collections"`
+	WebhookInterval time.Duration       `mapstructure:"webhook_interval" json:"webhook_interval"`
+	WebhookTimeout  time.Duration       `mapstructure:"webhook_timeout" json:"webhook_timeout"` // 单次回调请求的超时时间
+	Webhooks        []WebhookConfig     `mapstructure:"webhooks" json:"webhooks"`
+}
+
 //type ConsulConfig struct {
 //	Host string `mapstructure:"host" json:"host"`
 //	Port int    `mapstructure:"port" json:"port"`
@@ -24,6 +178,7 @@ type Config struct {
 	Port  int         `mapstructure:"port" json:"port"`
 	Host  string      `mapstructure:"host" json:"host"`
 	Mysql MysqlConfig `mapstructure:"mysql" json:"mysql"`
+	Eth   EthConfig   `mapstructure:"eth" json:"eth"`
 	//Redis  RedisConfig  `mapstructure:"redis" json:"redis"`
 	//Consul ConsulConfig `mapstructure:"consul" json:"consul"`
 }
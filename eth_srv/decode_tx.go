@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+)
+
+// decodeTx decode-tx 子命令，解析已签名交易并以 JSON 输出，参数为空或 "-" 时从标准输入读取
+func decodeTx(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: eth_srv decode-tx [raw tx hex | -]")
+	}
+	var raw string
+	if len(args) == 0 || args[0] == "-" {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		raw = string(b)
+	} else {
+		raw = args[0]
+	}
+	decoded, err := wallet.DecodeRawTx(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(decoded)
+}
+
+func runDecodeTx(args []string) {
+	if err := decodeTx(args, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Withdrawer 提交提现，补手续费和归集转账与用户提现共用签名和 nonce 分配流程
+type Withdrawer interface {
+	Submit(withdraw *model.Withdraw) error
+}
+
+// Retrier 失败任务交给重试模块按退避策略重新执行
+type Retrier interface {
+	Submit(kind string, refId uint64, cause error) error
+	Active(kind string, refId uint64) (bool, error)
+}
+
+type CollectionConfig struct {
+	ChainId   uint64
+	HotWallet common.Address // 归集目标，同时为 ERC-20 归集补手续费
+	Rules     []config.CollectionConfig
+	Interval  time.Duration
+}
+
+type Collection struct {
+	client         node.EthClient
+	fees           *fee.Oracle
+	store          Store
+	withdrawer     Withdrawer
+	retry          Retrier
+	chainId        uint64
+	hotWallet      common.Address
+	rules          []Rule
+	interval       time.Duration
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+}
+
+func NewCollection(client node.EthClient, fees *fee.Oracle, store Store, withdrawer Withdrawer, retry Retrier, cfg CollectionConfig, shutdown context.CancelCauseFunc) (*Collection, error) {
+	rules, err := parseRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	resCtx, resCancel := context.WithCancel(context.Background())
+
+	return &Collection{
+		client:         client,
+		fees:           fees,
+		store:          store,
+		withdrawer:     withdrawer,
+		retry:          retry,
+		chainId:        cfg.ChainId,
+		hotWallet:      cfg.HotWallet,
+		rules:          rules,
+		interval:       cfg.Interval,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{
+			HandleCrit: func(err error) {
+				shutdown(fmt.Errorf("critical error in collection: %w", err))
+			},
+		},
+	}, nil
+}
+
+func (c *Collection) Close() error {
+	var result error
+	c.resourceCancel()
+	if err := c.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("failed to await collection: %w", err))
+	}
+	return result
+}
+
+func (c *Collection) Start() error {
+	log.Info("start collection......")
+	if len(c.rules) == 0 {
+		log.Warn("collection rules not configured, collection disabled")
+		return nil
+	}
+	tickerCollectionWorker := time.NewTicker(c.interval)
+	c.tasks.Go(func() error {
+		defer tickerCollectionWorker.Stop()
+		for {
+			select {
+			case <-tickerCollectionWorker.C:
+				// 先推进进行中的归集，再发现新的待归集地址
+				if err := c.advance(); err != nil {
+					log.Error("advance collections fail", "err", err)
+				}
+				if err := c.discover(); err != nil {
+					log.Error("discover collections fail", "err", err)
+				}
+			case <-c.resourceCtx.Done():
+				log.Info("stop collection......")
+				return nil
+			}
+		}
+	})
+	return nil
+}
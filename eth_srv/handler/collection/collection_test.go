@@ -0,0 +1,278 @@
+package collection
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+var (
+	userAddress = common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	hotAddress  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	usdc        = common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+)
+
+// tip 1 wei、baseFee 10 wei 时 maxFeePerGas 为 21 wei
+type fakeClient struct {
+	node.EthClient
+	native map[common.Address]*big.Int
+	tokens map[common.Address]*big.Int
+}
+
+func (c *fakeClient) BalanceAtContext(_ context.Context, address common.Address, _ *big.Int) (*big.Int, error) {
+	if balance, ok := c.native[address]; ok {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (c *fakeClient) CallContractContext(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	balance, ok := c.tokens[*msg.To]
+	if !ok {
+		balance = big.NewInt(0)
+	}
+	return common.LeftPadBytes(balance.Bytes(), 32), nil
+}
+
+func (c *fakeClient) FeeHistoryContext(_ context.Context, blockCount uint64, _ *big.Int, percentiles []float64) (*ethereum.FeeHistory, error) {
+	reward := make([]*big.Int, len(percentiles))
+	for i := range reward {
+		reward[i] = big.NewInt(1)
+	}
+	return &ethereum.FeeHistory{Reward: [][]*big.Int{reward}, BaseFee: []*big.Int{big.NewInt(10), big.NewInt(10)}}, nil
+}
+
+type memStore struct {
+	collections []model.Collection
+	withdraws   map[string]*model.Withdraw
+	submits     int
+}
+
+func newMemStore() *memStore {
+	return &memStore{withdraws: make(map[string]*model.Withdraw)}
+}
+
+func (s *memStore) UserAddresses() ([]model.Address, error) {
+	return []model.Address{{Address: userAddress.String(), AddressType: global_const.AddressTypeUser}}, nil
+}
+
+func (s *memStore) HasPendingCollection(chainId uint64, address, token string) (bool, error) {
+	for _, collection := range s.collections {
+		if collection.UserAddress == address && collection.TokenAddress == token && collection.Status < global_const.CollectionStatusDone {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memStore) CreateCollection(collection *model.Collection) error {
+	collection.ID = uint64(len(s.collections) + 1)
+	s.collections = append(s.collections, *collection)
+	return nil
+}
+
+func (s *memStore) CollectionById(id uint64) (*model.Collection, error) {
+	for i := range s.collections {
+		if s.collections[i].ID == id {
+			collection := s.collections[i]
+			return &collection, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memStore) PendingCollections(uint64, int) ([]model.Collection, error) {
+	var pending []model.Collection
+	for _, collection := range s.collections {
+		if collection.Status < global_const.CollectionStatusDone {
+			pending = append(pending, collection)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memStore) WithdrawByRequestId(requestId string) (*model.Withdraw, error) {
+	withdraw, ok := s.withdraws[requestId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return withdraw, nil
+}
+
+func (s *memStore) UpdateCollection(id uint64, from, to uint8, fields map[string]interface{}) error {
+	for i := range s.collections {
+		collection := &s.collections[i]
+		if collection.ID != id || collection.Status != from {
+			continue
+		}
+		collection.Status = to
+		for k, v := range fields {
+			switch k {
+			case "amount":
+				collection.Amount = v.(string)
+			case "gas_amount":
+				collection.GasAmount = v.(string)
+			case "tx_hash":
+				collection.TxHash = v.(string)
+			case "error_message":
+				collection.ErrorMessage = v.(string)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memStore) Submit(withdraw *model.Withdraw) error {
+	if existing, ok := s.withdraws[withdraw.RequestId]; ok {
+		*withdraw = *existing
+		return nil
+	}
+	s.submits++
+	withdraw.ID = uint64(len(s.withdraws) + 100)
+	withdraw.Status = global_const.WithdrawStatusQueued
+	copied := *withdraw
+	s.withdraws[withdraw.RequestId] = &copied
+	return nil
+}
+
+type memRetry struct {
+	active map[uint64]error
+}
+
+func newMemRetry() *memRetry {
+	return &memRetry{active: make(map[uint64]error)}
+}
+
+func (r *memRetry) Submit(_ string, refId uint64, cause error) error {
+	r.active[refId] = cause
+	return nil
+}
+
+func (r *memRetry) Active(_ string, refId uint64) (bool, error) {
+	_, ok := r.active[refId]
+	return ok, nil
+}
+
+func newTestCollection(t *testing.T, client *fakeClient, store *memStore) *Collection {
+	fees := fee.NewOracle(client, fee.OracleConfig{Capability: global_const.TxCapabilityOf(global_const.EthereumSepoliaChainId)})
+	c, err := NewCollection(client, fees, store, store, newMemRetry(), CollectionConfig{
+		ChainId:   global_const.EthereumSepoliaChainId,
+		HotWallet: hotAddress,
+		Rules: []config.CollectionConfig{
+			{Threshold: "1000000"},
+			{Token: usdc.String(), Threshold: "100"},
+		},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCollectNative(t *testing.T) {
+	client := &fakeClient{native: map[common.Address]*big.Int{userAddress: big.NewInt(2000000)}}
+	store := newMemStore()
+	c := newTestCollection(t, client, store)
+
+	if err := c.discover(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.advance(); err != nil {
+		t.Fatal(err)
+	}
+	// 预留 2 * 21000 * 21 wei 手续费
+	collection := store.collections[0]
+	if collection.Status != global_const.CollectionStatusSweeping || collection.Amount != "1118000" {
+		t.Fatalf("unexpected collection %+v", collection)
+	}
+	sweep := store.withdraws[collectionRequestId(collection.ID)]
+	if sweep.FromAddress != userAddress.String() || sweep.ToAddress != hotAddress.String() || sweep.Amount != "1118000" {
+		t.Fatalf("unexpected sweep withdraw %+v", sweep)
+	}
+
+	sweep.Status = global_const.WithdrawStatusConfirmed
+	sweep.TxHash = "0xabc"
+	if err := c.advance(); err != nil {
+		t.Fatal(err)
+	}
+	if store.collections[0].Status != global_const.CollectionStatusDone || store.collections[0].TxHash != "0xabc" {
+		t.Fatalf("collection not done: %+v", store.collections[0])
+	}
+}
+
+func TestCollectTokenWithGasTopUp(t *testing.T) {
+	client := &fakeClient{
+		native: map[common.Address]*big.Int{userAddress: big.NewInt(200000)},
+		tokens: map[common.Address]*big.Int{usdc: big.NewInt(500)},
+	}
+	store := newMemStore()
+	c := newTestCollection(t, client, store)
+
+	if err := c.discover(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.collections) != 1 || store.collections[0].TokenAddress != usdc.String() {
+		t.Fatalf("expected one token collection, got %+v", store.collections)
+	}
+	if err := c.advance(); err != nil {
+		t.Fatal(err)
+	}
+	// ERC-20 转账预留 2 * 100000 * 21 wei，地址上已有 200000 wei
+	collection := store.collections[0]
+	gas := store.withdraws[collectionGasRequestId(collection.ID)]
+	if collection.Status != global_const.CollectionStatusFunding || gas.Amount != "4000000" || gas.FromAddress != hotAddress.String() ||
+		gas.WithdrawType != global_const.WithdrawTypeCollectionGas {
+		t.Fatalf("unexpected funding collection %+v gas %+v", collection, gas)
+	}
+
+	// 补手续费未上链前不提交归集，重复推进也不会再次补手续费
+	if err := c.advance(); err != nil {
+		t.Fatal(err)
+	}
+	if store.submits != 1 {
+		t.Fatalf("gas top up submitted %d times", store.submits)
+	}
+
+	gas.Status = global_const.WithdrawStatusConfirmed
+	if err := c.advance(); err != nil {
+		t.Fatal(err)
+	}
+	sweep := store.withdraws[collectionRequestId(collection.ID)]
+	if store.collections[0].Status != global_const.CollectionStatusSweeping || sweep.TokenAddress != usdc.String() || sweep.Amount != "500" ||
+		sweep.WithdrawType != global_const.WithdrawTypeCollection {
+		t.Fatalf("unexpected sweep %+v", sweep)
+	}
+}
+
+func TestCollectResumeAfterCrash(t *testing.T) {
+	client := &fakeClient{native: map[common.Address]*big.Int{userAddress: big.NewInt(2000000)}}
+	store := newMemStore()
+	c := newTestCollection(t, client, store)
+	store.collections = []model.Collection{{
+		UserAddress:  userAddress.String(),
+		HotAddress:   hotAddress.String(),
+		TokenAddress: global_const.EthAddress,
+		Balance:      "2000000",
+		Status:       global_const.CollectionStatusPending,
+	}}
+	store.collections[0].ID = 9
+	// 上次提交归集后、更新状态前进程退出
+	store.withdraws[collectionRequestId(9)] = &model.Withdraw{RequestId: collectionRequestId(9), Amount: "1000000"}
+
+	client.native[userAddress] = big.NewInt(3000000)
+	if err := c.advance(); err != nil {
+		t.Fatal(err)
+	}
+	if store.submits != 0 || store.collections[0].Status != global_const.CollectionStatusSweeping || store.collections[0].Amount != "1000000" {
+		t.Fatalf("collection should resume with the submitted withdraw, got %+v", store.collections[0])
+	}
+}
@@ -0,0 +1,87 @@
+package collection
+
+import (
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+)
+
+var unfinishedStatuses = []uint8{
+	global_const.CollectionStatusPending,
+	global_const.CollectionStatusFunding,
+	global_const.CollectionStatusSweeping,
+}
+
+// Store 归集模块的持久化接口
+type Store interface {
+	// UserAddresses 返回全部用户充值地址
+	UserAddresses() ([]model.Address, error)
+	// HasPendingCollection 用户地址该资产是否还有未完成的归集
+	HasPendingCollection(chainId uint64, address, token string) (bool, error)
+	// CreateCollection 写入归集记录
+	CreateCollection(collection *model.Collection) error
+	// CollectionById 按 ID 查询归集
+	CollectionById(id uint64) (*model.Collection, error)
+	// PendingCollections 返回未完成的归集
+	PendingCollections(chainId uint64, limit int) ([]model.Collection, error)
+	// WithdrawByRequestId 查询归集提交的提现，不存在时返回 gorm.ErrRecordNotFound
+	WithdrawByRequestId(requestId string) (*model.Withdraw, error)
+	// UpdateCollection 推进归集状态，只有当前状态仍为 from 时才会更新
+	UpdateCollection(id uint64, from, to uint8, fields map[string]interface{}) error
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) UserAddresses() ([]model.Address, error) {
+	var addresses []model.Address
+	err := s.db.Where("address_type = ?", global_const.AddressTypeUser).Order("id ASC").Find(&addresses).Error
+	return addresses, err
+}
+
+func (s *store) HasPendingCollection(chainId uint64, address, token string) (bool, error) {
+	var count int64
+	err := s.db.Model(&model.Collection{}).
+		Where("chain_id = ? AND user_address = ? AND token_address = ? AND status IN ?", chainId, address, token, unfinishedStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *store) CreateCollection(collection *model.Collection) error {
+	return s.db.Create(collection).Error
+}
+
+func (s *store) CollectionById(id uint64) (*model.Collection, error) {
+	var collection model.Collection
+	if err := s.db.Where("id = ?", id).First(&collection).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (s *store) PendingCollections(chainId uint64, limit int) ([]model.Collection, error) {
+	var collections []model.Collection
+	err := s.db.Where("chain_id = ? AND status IN ?", chainId, unfinishedStatuses).Order("id ASC").Limit(limit).Find(&collections).Error
+	return collections, err
+}
+
+func (s *store) WithdrawByRequestId(requestId string) (*model.Withdraw, error) {
+	var withdraw model.Withdraw
+	if err := s.db.Where("request_id = ?", requestId).First(&withdraw).Error; err != nil {
+		return nil, err
+	}
+	return &withdraw, nil
+}
+
+func (s *store) UpdateCollection(id uint64, from, to uint8, fields map[string]interface{}) error {
+	updates := map[string]interface{}{"status": to}
+	for k, v := range fields {
+		updates[k] = v
+	}
+	return s.db.Model(&model.Collection{}).Where("id = ? AND status = ?", id, from).Updates(updates).Error
+}
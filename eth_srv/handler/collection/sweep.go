@@ -0,0 +1,278 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"gorm.io/gorm"
+)
+
+const (
+	collectionBatchSize = 20
+	// 预留手续费取当前估算值的倍数，给 baseFee 上涨和卡单提价留出余量
+	gasReserveMultiplier = 2
+)
+
+// Rule 单个资产的归集阈值
+type Rule struct {
+	Token     common.Address
+	Threshold *big.Int
+}
+
+func parseRules(collections []config.CollectionConfig) ([]Rule, error) {
+	rules := make([]Rule, 0, len(collections))
+	for _, collection := range collections {
+		token := common.HexToAddress(global_const.EthAddress)
+		if collection.Token != "" {
+			if !common.IsHexAddress(collection.Token) {
+				return nil, fmt.Errorf("invalid collection token %q", collection.Token)
+			}
+			token = common.HexToAddress(collection.Token)
+		}
+		threshold, ok := new(big.Int).SetString(collection.Threshold, 10)
+		if !ok || threshold.Sign() < 0 {
+			return nil, fmt.Errorf("invalid collection threshold %q of %s", collection.Threshold, token)
+		}
+		rules = append(rules, Rule{Token: token, Threshold: threshold})
+	}
+	return rules, nil
+}
+
+func isNative(token common.Address) bool {
+	return token == common.HexToAddress(global_const.EthAddress)
+}
+
+func collectionRequestId(id uint64) string {
+	return fmt.Sprintf("collection-%d", id)
+}
+
+func collectionGasRequestId(id uint64) string {
+	return fmt.Sprintf("collection-gas-%d", id)
+}
+
+// balanceOf 查询地址的链上余额，原生币用 eth_getBalance，代币调用 balanceOf
+func (c *Collection) balanceOf(address, token common.Address) (*big.Int, error) {
+	if isNative(token) {
+		return c.client.BalanceAtContext(c.resourceCtx, address, nil)
+	}
+	data, err := wallet.Erc20BalanceOfData(address)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.client.CallContractContext(c.resourceCtx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := wallet.UnpackErc20Balance(result)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected balanceOf result from %s: %w", token, err)
+	}
+	return balance, nil
+}
+
+// gasReserve 为一笔转账预留的手续费
+func (c *Collection) gasReserve(token common.Address) (*big.Int, error) {
+	maxFee, err := c.fees.MaxTransferFee(c.resourceCtx, token)
+	if err != nil {
+		return nil, err
+	}
+	return maxFee.Mul(maxFee, big.NewInt(gasReserveMultiplier)), nil
+}
+
+// discover 查找余额超过阈值且没有进行中归集的用户地址，写入待归集记录
+func (c *Collection) discover() error {
+	addresses, err := c.store.UserAddresses()
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		user := common.HexToAddress(address.Address)
+		for _, rule := range c.rules {
+			pending, err := c.store.HasPendingCollection(c.chainId, user.String(), rule.Token.String())
+			if err != nil {
+				return err
+			}
+			if pending {
+				continue
+			}
+			balance, err := c.balanceOf(user, rule.Token)
+			if err != nil {
+				return fmt.Errorf("unable to query %s balance of %s: %w", rule.Token, user, err)
+			}
+			if balance.Cmp(rule.Threshold) <= 0 {
+				continue
+			}
+			collection := &model.Collection{
+				ChainId:      c.chainId,
+				UserAddress:  user.String(),
+				HotAddress:   c.hotWallet.String(),
+				TokenAddress: rule.Token.String(),
+				Balance:      balance.String(),
+				Status:       global_const.CollectionStatusPending,
+			}
+			if err := c.store.CreateCollection(collection); err != nil {
+				return err
+			}
+			log.Info("collection found", "id", collection.ID, "user", user, "token", rule.Token, "balance", balance)
+		}
+	}
+	return nil
+}
+
+// advance 按记录的状态推进未完成的归集，失败的归集交给重试模块，不影响其他归集
+func (c *Collection) advance() error {
+	collections, err := c.store.PendingCollections(c.chainId, collectionBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range collections {
+		collection := &collections[i]
+		active, err := c.retry.Active(global_const.RetryKindCollection, collection.ID)
+		if err != nil {
+			return err
+		}
+		if active {
+			continue
+		}
+		if err := c.step(collection); err != nil {
+			if err := c.retry.Submit(global_const.RetryKindCollection, collection.ID, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Collection) step(collection *model.Collection) error {
+	switch collection.Status {
+	case global_const.CollectionStatusPending:
+		return c.start(collection)
+	case global_const.CollectionStatusFunding:
+		return c.checkFunding(collection)
+	case global_const.CollectionStatusSweeping:
+		return c.checkSweep(collection)
+	}
+	return nil
+}
+
+// RetryStep 重试模块回调，从归集当前的状态继续推进
+func (c *Collection) RetryStep(id uint64) error {
+	collection, err := c.store.CollectionById(id)
+	if err != nil {
+		return err
+	}
+	return c.step(collection)
+}
+
+// start 原生币扣除预留手续费后直接归集；ERC-20 地址上的 ETH 不够手续费时先从热钱包补足差额
+func (c *Collection) start(collection *model.Collection) error {
+	user := common.HexToAddress(collection.UserAddress)
+	token := common.HexToAddress(collection.TokenAddress)
+	reserve, err := c.gasReserve(token)
+	if err != nil {
+		return err
+	}
+	ethBalance, err := c.client.BalanceAtContext(c.resourceCtx, user, nil)
+	if err != nil {
+		return err
+	}
+
+	if isNative(token) {
+		amount := new(big.Int).Sub(ethBalance, reserve)
+		if amount.Sign() <= 0 {
+			return c.store.UpdateCollection(collection.ID, global_const.CollectionStatusPending, global_const.CollectionStatusFailed, map[string]interface{}{
+				"error_message": fmt.Sprintf("balance %s does not cover gas reserve %s", ethBalance, reserve),
+			})
+		}
+		return c.submitSweep(collection, global_const.CollectionStatusPending, amount.String())
+	}
+
+	if ethBalance.Cmp(reserve) >= 0 {
+		return c.submitSweep(collection, global_const.CollectionStatusPending, collection.Balance)
+	}
+	gas := &model.Withdraw{
+		RequestId:    collectionGasRequestId(collection.ID),
+		FromAddress:  collection.HotAddress,
+		ToAddress:    collection.UserAddress,
+		TokenAddress: global_const.EthAddress,
+		Amount:       new(big.Int).Sub(reserve, ethBalance).String(),
+		WithdrawType: global_const.WithdrawTypeCollectionGas,
+	}
+	// 重复提交时返回第一次提交的提现，进程中途退出也只会补一次手续费
+	if err := c.withdrawer.Submit(gas); err != nil {
+		return err
+	}
+	log.Info("collection gas top up submitted", "id", collection.ID, "user", user, "amount", gas.Amount)
+	return c.store.UpdateCollection(collection.ID, global_const.CollectionStatusPending, global_const.CollectionStatusFunding, map[string]interface{}{
+		"gas_amount":      gas.Amount,
+		"gas_withdraw_id": gas.ID,
+	})
+}
+
+// checkFunding 补手续费上链后提交 ERC-20 归集
+func (c *Collection) checkFunding(collection *model.Collection) error {
+	gas, err := c.store.WithdrawByRequestId(collectionGasRequestId(collection.ID))
+	if err != nil {
+		return err
+	}
+	switch gas.Status {
+	case global_const.WithdrawStatusConfirmed:
+		return c.submitSweep(collection, global_const.CollectionStatusFunding, collection.Balance)
+	case global_const.WithdrawStatusFailed, global_const.WithdrawStatusCancelled:
+		return c.store.UpdateCollection(collection.ID, global_const.CollectionStatusFunding, global_const.CollectionStatusFailed, map[string]interface{}{
+			"error_message": fmt.Sprintf("gas top up %s not confirmed", gas.TxHash),
+		})
+	}
+	return nil
+}
+
+// submitSweep 提交用户地址到热钱包的归集转账，RequestId 由归集 ID 决定，重复提交不会产生第二笔转账
+func (c *Collection) submitSweep(collection *model.Collection, from uint8, amount string) error {
+	sweep := &model.Withdraw{
+		RequestId:    collectionRequestId(collection.ID),
+		FromAddress:  collection.UserAddress,
+		ToAddress:    collection.HotAddress,
+		TokenAddress: collection.TokenAddress,
+		Amount:       amount,
+		WithdrawType: global_const.WithdrawTypeCollection,
+	}
+	if err := c.withdrawer.Submit(sweep); err != nil {
+		return err
+	}
+	log.Info("collection sweep submitted", "id", collection.ID, "user", collection.UserAddress, "token", collection.TokenAddress, "amount", sweep.Amount)
+	return c.store.UpdateCollection(collection.ID, from, global_const.CollectionStatusSweeping, map[string]interface{}{
+		"amount":      sweep.Amount,
+		"withdraw_id": sweep.ID,
+	})
+}
+
+// checkSweep 归集转账结束后标记归集完成或失败
+func (c *Collection) checkSweep(collection *model.Collection) error {
+	sweep, err := c.store.WithdrawByRequestId(collectionRequestId(collection.ID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("collection %d has no sweep withdraw", collection.ID)
+	} else if err != nil {
+		return err
+	}
+	switch sweep.Status {
+	case global_const.WithdrawStatusConfirmed:
+		log.Info("collection done", "id", collection.ID, "hash", sweep.TxHash)
+		return c.store.UpdateCollection(collection.ID, global_const.CollectionStatusSweeping, global_const.CollectionStatusDone, map[string]interface{}{
+			"tx_hash": sweep.TxHash,
+		})
+	case global_const.WithdrawStatusFailed, global_const.WithdrawStatusCancelled:
+		return c.store.UpdateCollection(collection.ID, global_const.CollectionStatusSweeping, global_const.CollectionStatusFailed, map[string]interface{}{
+			"tx_hash":       sweep.TxHash,
+			"error_message": fmt.Sprintf("sweep withdraw ended with status %d", sweep.Status),
+		})
+	}
+	return nil
+}
@@ -4,24 +4,68 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
+
 	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
-	"time"
 )
 
+// Withdrawer 提交提现，归集转账与用户提现共用签名和 nonce 分配流程
+type Withdrawer interface {
+	Submit(withdraw *model.Withdraw) error
+}
+
+type CollectionColdConfig struct {
+	ChainId    uint64
+	HotWallets []common.Address
+	ColdWallet string // 为空时不归集
+	Sweeps     []config.SweepConfig
+	Interval   time.Duration
+}
+
 type CollectionCold struct {
 	client         node.EthClient
+	fees           *fee.Oracle
+	store          Store
+	withdrawer     Withdrawer
+	chainId        uint64
+	hotWallets     []common.Address
+	coldWallet     common.Address
+	rules          []SweepRule
+	interval       time.Duration
 	resourceCtx    context.Context
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 }
 
-func NewCollectionCold(client node.EthClient, shutdown context.CancelCauseFunc) (*CollectionCold, error) {
+func NewCollectionCold(client node.EthClient, fees *fee.Oracle, store Store, withdrawer Withdrawer, cfg CollectionColdConfig, shutdown context.CancelCauseFunc) (*CollectionCold, error) {
+	if cfg.ColdWallet != "" && !common.IsHexAddress(cfg.ColdWallet) {
+		return nil, fmt.Errorf("invalid cold wallet %q", cfg.ColdWallet)
+	}
+	rules, err := parseSweepRules(cfg.Sweeps)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
 	resCtx, resCancel := context.WithCancel(context.Background())
 
 	return &CollectionCold{
 		client:         client,
+		fees:           fees,
+		store:          store,
+		withdrawer:     withdrawer,
+		chainId:        cfg.ChainId,
+		hotWallets:     cfg.HotWallets,
+		coldWallet:     common.HexToAddress(cfg.ColdWallet),
+		rules:          rules,
+		interval:       cfg.Interval,
 		resourceCtx:    resCtx,
 		resourceCancel: resCancel,
 		tasks: tasks.Group{
@@ -36,19 +80,36 @@ func (cc *CollectionCold) Close() error {
 	var result error
 	cc.resourceCancel()
 	if err := cc.tasks.Wait(); err != nil {
-		result = errors.Join(result, fmt.Errorf("failed to await collection cold %w"), err)
+		result = errors.Join(result, fmt.Errorf("failed to await collection cold: %w", err))
 	}
-	return nil
+	return result
 }
 
 func (cc *CollectionCold) Start() error {
 	log.Info("start collection cold......")
-	tickerCollectionColdWorker := time.NewTicker(time.Second * 5)
+	if cc.coldWallet == (common.Address{}) || len(cc.rules) == 0 {
+		log.Warn("cold wallet or sweep rules not configured, collection cold disabled")
+		return nil
+	}
+	tickerCollectionColdWorker := time.NewTicker(cc.interval)
 	cc.tasks.Go(func() error {
-		for range tickerCollectionColdWorker.C {
-			log.Info("collection cold work task go")
+		defer tickerCollectionColdWorker.Stop()
+		for {
+			select {
+			case <-tickerCollectionColdWorker.C:
+				// 先同步进行中的归集，已完成的资产才会在本轮重新检查水位
+				if err := cc.syncSweeps(); err != nil {
+					log.Error("sync sweeps fail", "err", err)
+					continue
+				}
+				if err := cc.sweep(); err != nil {
+					log.Error("sweep hot wallets fail", "err", err)
+				}
+			case <-cc.resourceCtx.Done():
+				log.Info("stop collection cold......")
+				return nil
+			}
 		}
-		return nil
 	})
 	return nil
 }
@@ -0,0 +1,336 @@
+package collection_cold
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+var (
+	hotAddress  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	coldAddress = common.HexToAddress("0x3333333333333333333333333333333333333333")
+	usdc        = common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+)
+
+type fakeClient struct {
+	node.EthClient
+	native  *big.Int
+	tokens  map[common.Address]*big.Int
+	baseFee *big.Int
+}
+
+// fixedL1Fee 每笔交易固定的 L1 数据费
+type fixedL1Fee int64
+
+func (f fixedL1Fee) L1Fee(context.Context, []byte) (*big.Int, error) {
+	return big.NewInt(int64(f)), nil
+}
+
+func testOracle(client node.EthClient, l1Fee fee.L1FeeModel) *fee.Oracle {
+	return fee.NewOracle(client, fee.OracleConfig{
+		ChainId:    global_const.BaseSepoliaChainId,
+		Capability: global_const.TxCapabilityOf(global_const.BaseSepoliaChainId),
+		L1Fee:      l1Fee,
+	})
+}
+
+func (c *fakeClient) BalanceAtContext(context.Context, common.Address, *big.Int) (*big.Int, error) {
+	return c.native, nil
+}
+
+// 默认 baseFee 与小费为 0，手续费不影响水位计算
+func (c *fakeClient) FeeHistoryContext(context.Context, uint64, *big.Int, []float64) (*ethereum.FeeHistory, error) {
+	baseFee := c.baseFee
+	if baseFee == nil {
+		baseFee = big.NewInt(0)
+	}
+	return &ethereum.FeeHistory{Reward: [][]*big.Int{{big.NewInt(0), big.NewInt(0), big.NewInt(0)}}, BaseFee: []*big.Int{baseFee}}, nil
+}
+
+func (c *fakeClient) CallContractContext(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	data, err := wallet.Erc20BalanceOfData(hotAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(msg.Data, data) {
+		return nil, ethereum.NotFound
+	}
+	return common.LeftPadBytes(c.tokens[*msg.To].Bytes(), 32), nil
+}
+
+type memStore struct {
+	sweeps    []model.Sweep
+	withdraws map[string]*model.Withdraw
+}
+
+func (s *memStore) HasPendingSweep(chainId uint64, hot, token string) (bool, error) {
+	for _, sweep := range s.sweeps {
+		if sweep.HotAddress == hot && sweep.TokenAddress == token && !isFinished(sweep.Status) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memStore) CreateSweep(sweep *model.Sweep) error {
+	sweep.ID = uint64(len(s.sweeps) + 1)
+	s.sweeps = append(s.sweeps, *sweep)
+	return nil
+}
+
+func (s *memStore) PendingSweeps(uint64, int) ([]model.Sweep, error) {
+	var pending []model.Sweep
+	for _, sweep := range s.sweeps {
+		if !isFinished(sweep.Status) {
+			pending = append(pending, sweep)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memStore) WithdrawByRequestId(requestId string) (*model.Withdraw, error) {
+	withdraw, ok := s.withdraws[requestId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return withdraw, nil
+}
+
+func (s *memStore) UpdateSweep(id uint64, fields map[string]interface{}) error {
+	for i := range s.sweeps {
+		if s.sweeps[i].ID != id {
+			continue
+		}
+		if v, ok := fields["withdraw_id"]; ok {
+			s.sweeps[i].WithdrawId = v.(uint64)
+		}
+		if v, ok := fields["tx_hash"]; ok {
+			s.sweeps[i].TxHash = v.(string)
+		}
+		if v, ok := fields["status"]; ok {
+			s.sweeps[i].Status = v.(uint8)
+		}
+	}
+	return nil
+}
+
+func (s *memStore) Submit(withdraw *model.Withdraw) error {
+	if existing, ok := s.withdraws[withdraw.RequestId]; ok {
+		*withdraw = *existing
+		return nil
+	}
+	withdraw.ID = uint64(len(s.withdraws) + 100)
+	withdraw.Status = global_const.WithdrawStatusQueued
+	s.withdraws[withdraw.RequestId] = withdraw
+	return nil
+}
+
+func (s *memStore) PendingWithdraws(_ uint64, from string) ([]model.Withdraw, error) {
+	var pending []model.Withdraw
+	for _, withdraw := range s.withdraws {
+		if withdraw.FromAddress == from && withdraw.Status <= global_const.WithdrawStatusBroadcast {
+			pending = append(pending, *withdraw)
+		}
+	}
+	return pending, nil
+}
+
+func isFinished(status uint8) bool {
+	for _, finished := range finishedStatuses {
+		if status == finished {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseSweepRules(t *testing.T) {
+	rules, err := parseSweepRules([]config.SweepConfig{{HighWatermark: "100", LowWatermark: "20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Token != common.HexToAddress(global_const.EthAddress) {
+		t.Fatalf("empty token should be native, got %s", rules[0].Token)
+	}
+	if _, err := parseSweepRules([]config.SweepConfig{{HighWatermark: "100", LowWatermark: "100"}}); err == nil {
+		t.Fatal("expected low watermark not below high watermark to be rejected")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	client := &fakeClient{
+		native: big.NewInt(150),
+		tokens: map[common.Address]*big.Int{usdc: big.NewInt(90)},
+	}
+	store := &memStore{withdraws: make(map[string]*model.Withdraw)}
+	cc, err := NewCollectionCold(client, testOracle(client, nil), store, store, CollectionColdConfig{
+		ChainId:    global_const.EthereumSepoliaChainId,
+		HotWallets: []common.Address{hotAddress},
+		ColdWallet: coldAddress.String(),
+		Sweeps: []config.SweepConfig{
+			{HighWatermark: "100", LowWatermark: "20"},
+			{Token: usdc.String(), HighWatermark: "100", LowWatermark: "20"},
+		},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	// 只有原生币超过高水位，转出到低水位为止
+	if len(store.sweeps) != 1 || len(store.withdraws) != 1 {
+		t.Fatalf("expected one sweep, got %d sweeps %d withdraws", len(store.sweeps), len(store.withdraws))
+	}
+	sweep := store.sweeps[0]
+	withdraw := store.withdraws[sweepRequestId(sweep.ID)]
+	if sweep.Amount != "130" || sweep.Reason == "" || sweep.WithdrawId != withdraw.ID {
+		t.Fatalf("unexpected sweep %+v", sweep)
+	}
+	if withdraw.FromAddress != hotAddress.String() || withdraw.ToAddress != coldAddress.String() || withdraw.Amount != "130" {
+		t.Fatalf("unexpected withdraw %+v", withdraw)
+	}
+
+	// 链上余额在提现上链前不会变化，进行中的归集不能重复提交
+	client.tokens[usdc] = big.NewInt(120)
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sweeps) != 2 || store.sweeps[1].TokenAddress != usdc.String() || store.sweeps[1].Amount != "100" {
+		t.Fatalf("expected only a token sweep, got %+v", store.sweeps)
+	}
+
+	withdraw.Status = global_const.WithdrawStatusConfirmed
+	withdraw.TxHash = "0xabc"
+	if err := cc.syncSweeps(); err != nil {
+		t.Fatal(err)
+	}
+	if store.sweeps[0].Status != global_const.WithdrawStatusConfirmed || store.sweeps[0].TxHash != "0xabc" {
+		t.Fatalf("sweep not synced with withdraw: %+v", store.sweeps[0])
+	}
+}
+
+func TestSyncSweepsResubmits(t *testing.T) {
+	store := &memStore{withdraws: make(map[string]*model.Withdraw)}
+	store.sweeps = []model.Sweep{{
+		HotAddress:   hotAddress.String(),
+		ColdAddress:  coldAddress.String(),
+		TokenAddress: global_const.EthAddress,
+		Amount:       "130",
+		Status:       global_const.WithdrawStatusQueued,
+	}}
+	store.sweeps[0].ID = 7
+	cc, err := NewCollectionCold(&fakeClient{}, testOracle(&fakeClient{}, nil), store, store, CollectionColdConfig{ColdWallet: coldAddress.String()}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cc.syncSweeps(); err != nil {
+		t.Fatal(err)
+	}
+	if withdraw, ok := store.withdraws["sweep-7"]; !ok || store.sweeps[0].WithdrawId != withdraw.ID {
+		t.Fatalf("sweep without withdraw should be resubmitted, got %+v", store.sweeps[0])
+	}
+}
+
+func TestSweepReservesFee(t *testing.T) {
+	// baseFee 1 wei 时 maxFeePerGas 为 2 wei，原生币转账最多消耗 2 * 21000 + 5000 wei
+	client := &fakeClient{
+		native:  big.NewInt(300000),
+		tokens:  map[common.Address]*big.Int{usdc: big.NewInt(500)},
+		baseFee: big.NewInt(1),
+	}
+	store := &memStore{withdraws: make(map[string]*model.Withdraw)}
+	cc, err := NewCollectionCold(client, testOracle(client, fixedL1Fee(5000)), store, store, CollectionColdConfig{
+		ChainId:    global_const.BaseSepoliaChainId,
+		HotWallets: []common.Address{hotAddress},
+		ColdWallet: coldAddress.String(),
+		Sweeps: []config.SweepConfig{
+			{HighWatermark: "100000", LowWatermark: "20"},
+			{Token: usdc.String(), HighWatermark: "100", LowWatermark: "20"},
+		},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	// 低水位不够支付手续费时多留出执行费和 L1 数据费；原生币归集上链前它的金额和手续费都被预留，
+	// 剩余的原生币不够支付代币归集的手续费
+	if len(store.sweeps) != 1 || store.sweeps[0].Amount != "253000" {
+		t.Fatalf("unexpected sweeps %+v", store.sweeps)
+	}
+	store.withdraws[sweepRequestId(store.sweeps[0].ID)].Status = global_const.WithdrawStatusConfirmed
+	store.sweeps[0].Status = global_const.WithdrawStatusConfirmed
+	// 只检查代币归集，热钱包原生币足够支付代币转账手续费
+	cc.rules = cc.rules[1:]
+	client.native = big.NewInt(250000)
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sweeps) != 2 || store.sweeps[1].TokenAddress != usdc.String() || store.sweeps[1].Amount != "480" {
+		t.Fatalf("unexpected token sweep %+v", store.sweeps)
+	}
+
+	// 热钱包原生币不够支付 ERC-20 转账的 2 * 100000 + 5000 wei 手续费时不归集
+	client.native = big.NewInt(150000)
+	store = &memStore{withdraws: make(map[string]*model.Withdraw)}
+	cc.store, cc.withdrawer = store, store
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sweeps) != 0 {
+		t.Fatalf("token sweep should wait for gas, got %+v", store.sweeps)
+	}
+}
+
+func TestSweepKeepsPendingWithdraws(t *testing.T) {
+	client := &fakeClient{
+		native: big.NewInt(150),
+		tokens: map[common.Address]*big.Int{usdc: big.NewInt(150)},
+	}
+	store := &memStore{withdraws: map[string]*model.Withdraw{
+		"user-1": {FromAddress: hotAddress.String(), TokenAddress: global_const.EthAddress, Amount: "100", Status: global_const.WithdrawStatusQueued},
+		"user-2": {FromAddress: hotAddress.String(), TokenAddress: usdc.String(), Amount: "60", Status: global_const.WithdrawStatusBroadcast},
+		"user-3": {FromAddress: hotAddress.String(), TokenAddress: usdc.String(), Amount: "1000", Status: global_const.WithdrawStatusConfirmed},
+	}}
+	cc, err := NewCollectionCold(client, testOracle(client, nil), store, store, CollectionColdConfig{
+		ChainId:    global_const.EthereumSepoliaChainId,
+		HotWallets: []common.Address{hotAddress},
+		ColdWallet: coldAddress.String(),
+		Sweeps: []config.SweepConfig{
+			{HighWatermark: "100", LowWatermark: "20"},
+			{Token: usdc.String(), HighWatermark: "100", LowWatermark: "20"},
+		},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 扣除未上链的提现后原生币剩 50、代币剩 90，都没有超过高水位
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sweeps) != 0 {
+		t.Fatalf("sweep would drain funds of pending withdraws: %+v", store.sweeps)
+	}
+
+	client.tokens[usdc] = big.NewInt(200)
+	if err := cc.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sweeps) != 1 || store.sweeps[0].Amount != "120" {
+		t.Fatalf("expected token sweep keeping the pending withdraw, got %+v", store.sweeps)
+	}
+}
@@ -0,0 +1,81 @@
+package collection_cold
+
+import (
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+)
+
+// 归集对应的提现进入这些状态后不再变化
+var finishedStatuses = []uint8{
+	global_const.WithdrawStatusConfirmed,
+	global_const.WithdrawStatusFailed,
+	global_const.WithdrawStatusCancelled,
+}
+
+// 提现处于这些状态时链上余额还没有扣减
+var pendingWithdrawStatuses = []uint8{
+	global_const.WithdrawStatusQueued,
+	global_const.WithdrawStatusSigned,
+	global_const.WithdrawStatusBroadcast,
+}
+
+// Store 冷热归集模块的持久化接口
+type Store interface {
+	// HasPendingSweep 热钱包该资产是否还有未完成的归集，未完成时链上余额尚未扣减，不能重复归集
+	HasPendingSweep(chainId uint64, hot, token string) (bool, error)
+	// CreateSweep 写入归集记录
+	CreateSweep(sweep *model.Sweep) error
+	// PendingSweeps 返回对应提现尚未结束的归集
+	PendingSweeps(chainId uint64, limit int) ([]model.Sweep, error)
+	// WithdrawByRequestId 查询归集对应的提现，不存在时返回 gorm.ErrRecordNotFound
+	WithdrawByRequestId(requestId string) (*model.Withdraw, error)
+	// UpdateSweep 同步归集对应提现的进度
+	UpdateSweep(id uint64, fields map[string]interface{}) error
+	// PendingWithdraws 返回从热钱包转出、尚未上链的提现
+	PendingWithdraws(chainId uint64, from string) ([]model.Withdraw, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) HasPendingSweep(chainId uint64, hot, token string) (bool, error) {
+	var count int64
+	err := s.db.Model(&model.Sweep{}).
+		Where("chain_id = ? AND hot_address = ? AND token_address = ? AND status NOT IN ?", chainId, hot, token, finishedStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *store) CreateSweep(sweep *model.Sweep) error {
+	return s.db.Create(sweep).Error
+}
+
+func (s *store) PendingSweeps(chainId uint64, limit int) ([]model.Sweep, error) {
+	var sweeps []model.Sweep
+	err := s.db.Where("chain_id = ? AND status NOT IN ?", chainId, finishedStatuses).Order("id ASC").Limit(limit).Find(&sweeps).Error
+	return sweeps, err
+}
+
+func (s *store) WithdrawByRequestId(requestId string) (*model.Withdraw, error) {
+	var withdraw model.Withdraw
+	if err := s.db.Where("request_id = ?", requestId).First(&withdraw).Error; err != nil {
+		return nil, err
+	}
+	return &withdraw, nil
+}
+
+func (s *store) UpdateSweep(id uint64, fields map[string]interface{}) error {
+	return s.db.Model(&model.Sweep{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (s *store) PendingWithdraws(chainId uint64, from string) ([]model.Withdraw, error) {
+	var withdraws []model.Withdraw
+	err := s.db.Where("chain_id = ? AND from_address = ? AND status IN ?", chainId, from, pendingWithdrawStatuses).Find(&withdraws).Error
+	return withdraws, err
+}
@@ -0,0 +1,262 @@
+package collection_cold
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"gorm.io/gorm"
+)
+
+const sweepBatchSize = 20
+
+// SweepRule 单个资产的归集水位
+type SweepRule struct {
+	Token         common.Address
+	HighWatermark *big.Int
+	LowWatermark  *big.Int
+}
+
+// parseSweepRules 解析配置中的归集水位，要求 0 <= low < high
+func parseSweepRules(sweeps []config.SweepConfig) ([]SweepRule, error) {
+	rules := make([]SweepRule, 0, len(sweeps))
+	for _, sweep := range sweeps {
+		token := common.HexToAddress(global_const.EthAddress)
+		if sweep.Token != "" {
+			if !common.IsHexAddress(sweep.Token) {
+				return nil, fmt.Errorf("invalid sweep token %q", sweep.Token)
+			}
+			token = common.HexToAddress(sweep.Token)
+		}
+		high, ok := new(big.Int).SetString(sweep.HighWatermark, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid high watermark %q of %s", sweep.HighWatermark, token)
+		}
+		low, ok := new(big.Int).SetString(sweep.LowWatermark, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid low watermark %q of %s", sweep.LowWatermark, token)
+		}
+		if low.Sign() < 0 || low.Cmp(high) >= 0 {
+			return nil, fmt.Errorf("low watermark of %s must be non-negative and below high watermark", token)
+		}
+		rules = append(rules, SweepRule{Token: token, HighWatermark: high, LowWatermark: low})
+	}
+	return rules, nil
+}
+
+// sweepAmount 余额超过高水位时返回需要转出的金额（保留到低水位），否则返回 nil
+func sweepAmount(balance *big.Int, rule SweepRule) *big.Int {
+	if balance.Cmp(rule.HighWatermark) <= 0 {
+		return nil
+	}
+	return new(big.Int).Sub(balance, rule.LowWatermark)
+}
+
+// balanceOf 查询热钱包的链上余额，原生币用 eth_getBalance，代币调用 balanceOf
+func (cc *CollectionCold) balanceOf(hot, token common.Address) (*big.Int, error) {
+	if token == common.HexToAddress(global_const.EthAddress) {
+		return cc.client.BalanceAtContext(cc.resourceCtx, hot, nil)
+	}
+	data, err := wallet.Erc20BalanceOfData(hot)
+	if err != nil {
+		return nil, err
+	}
+	result, err := cc.client.CallContractContext(cc.resourceCtx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := wallet.UnpackErc20Balance(result)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected balanceOf result from %s: %w", token, err)
+	}
+	return balance, nil
+}
+
+// sweep 检查所有热钱包的各项资产，超过高水位的提交一笔转入冷钱包的提现
+func (cc *CollectionCold) sweep() error {
+	for _, hot := range cc.hotWallets {
+		for _, rule := range cc.rules {
+			if err := cc.sweepAsset(hot, rule); err != nil {
+				return fmt.Errorf("unable to sweep %s of %s: %w", rule.Token, hot, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (cc *CollectionCold) sweepAsset(hot common.Address, rule SweepRule) error {
+	pending, err := cc.store.HasPendingSweep(cc.chainId, hot.String(), rule.Token.String())
+	if err != nil {
+		return err
+	}
+	if pending {
+		return nil
+	}
+	balance, err := cc.balanceOf(hot, rule.Token)
+	if err != nil {
+		return err
+	}
+	// 热钱包要能支付这笔归集的手续费，rollup 链包含 L1 数据费
+	maxFee, err := cc.fees.MaxTransferFee(cc.resourceCtx, rule.Token)
+	if errors.Is(err, fee.ErrFeeAboveCap) {
+		log.Warn("sweep postponed", "hot", hot, "token", rule.Token, "err", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	reserved, err := cc.pendingReserve(hot, rule.Token)
+	if err != nil {
+		return err
+	}
+	available := new(big.Int).Sub(balance, reserved)
+	amount := sweepAmount(available, rule)
+	if amount == nil {
+		return nil
+	}
+	reason := fmt.Sprintf("balance %s minus pending withdraws %s above high watermark %s, keep low watermark %s", balance, reserved, rule.HighWatermark, rule.LowWatermark)
+	if rule.Token == common.HexToAddress(global_const.EthAddress) {
+		if maxFee.Cmp(rule.LowWatermark) > 0 {
+			reason = fmt.Sprintf("balance %s minus pending withdraws %s above high watermark %s, keep max transfer fee %s", balance, reserved, rule.HighWatermark, maxFee)
+			amount.Sub(available, maxFee)
+			if amount.Sign() <= 0 {
+				return nil
+			}
+		}
+	} else {
+		native, err := cc.client.BalanceAtContext(cc.resourceCtx, hot, nil)
+		if err != nil {
+			return err
+		}
+		nativeReserved, err := cc.pendingReserve(hot, common.HexToAddress(global_const.EthAddress))
+		if err != nil {
+			return err
+		}
+		if native.Sub(native, nativeReserved).Cmp(maxFee) < 0 {
+			log.Warn("hot wallet cannot pay sweep fee", "hot", hot, "token", rule.Token, "available", native, "fee", maxFee)
+			return nil
+		}
+	}
+
+	sweep := &model.Sweep{
+		ChainId:       cc.chainId,
+		HotAddress:    hot.String(),
+		ColdAddress:   cc.coldWallet.String(),
+		TokenAddress:  rule.Token.String(),
+		Balance:       balance.String(),
+		HighWatermark: rule.HighWatermark.String(),
+		LowWatermark:  rule.LowWatermark.String(),
+		Amount:        amount.String(),
+		Reason:        reason,
+		Status:        global_const.WithdrawStatusQueued,
+	}
+	if err := cc.store.CreateSweep(sweep); err != nil {
+		return err
+	}
+	log.Info("sweep hot wallet to cold", "hot", hot, "token", rule.Token, "balance", balance, "amount", amount)
+	return cc.submit(sweep)
+}
+
+// pendingReserve 热钱包排队中、已签名和已广播的提现还没有从链上余额扣除，归集前为它们留出该资产的金额；
+// 原生币还要留出这些提现最多消耗的手续费
+func (cc *CollectionCold) pendingReserve(hot, token common.Address) (*big.Int, error) {
+	withdraws, err := cc.store.PendingWithdraws(cc.chainId, hot.String())
+	if err != nil {
+		return nil, err
+	}
+	native := token == common.HexToAddress(global_const.EthAddress)
+	maxFees := make(map[common.Address]*big.Int)
+	reserved := new(big.Int)
+	for _, withdraw := range withdraws {
+		withdrawToken := common.HexToAddress(withdraw.TokenAddress)
+		if withdrawToken == token {
+			amount, ok := new(big.Int).SetString(withdraw.Amount, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid amount %q of withdraw %d", withdraw.Amount, withdraw.ID)
+			}
+			reserved.Add(reserved, amount)
+		}
+		if !native {
+			continue
+		}
+		// 已签名的交易按签名时的 gas 和 feeCap 计算，排队中的提现按当前手续费估算，取两者较大值
+		maxFee, ok := maxFees[withdrawToken]
+		if !ok {
+			maxFee, err = cc.fees.MaxTransferFee(cc.resourceCtx, withdrawToken)
+			if err != nil {
+				return nil, err
+			}
+			maxFees[withdrawToken] = maxFee
+		}
+		if feeCap, ok := new(big.Int).SetString(withdraw.GasFeeCap, 10); ok {
+			if signed := feeCap.Mul(feeCap, new(big.Int).SetUint64(withdraw.GasLimit)); signed.Cmp(maxFee) > 0 {
+				maxFee = signed
+			}
+		}
+		reserved.Add(reserved, maxFee)
+	}
+	return reserved, nil
+}
+
+func sweepRequestId(sweepId uint64) string {
+	return fmt.Sprintf("sweep-%d", sweepId)
+}
+
+// submit 以归集记录生成提现，RequestId 由归集 ID 决定，重复提交不会产生第二笔转账
+func (cc *CollectionCold) submit(sweep *model.Sweep) error {
+	withdraw := &model.Withdraw{
+		RequestId:    sweepRequestId(sweep.ID),
+		FromAddress:  sweep.HotAddress,
+		ToAddress:    sweep.ColdAddress,
+		TokenAddress: sweep.TokenAddress,
+		Amount:       sweep.Amount,
+		WithdrawType: global_const.WithdrawTypeSweep,
+	}
+	if err := cc.withdrawer.Submit(withdraw); err != nil {
+		return err
+	}
+	return cc.store.UpdateSweep(sweep.ID, map[string]interface{}{
+		"withdraw_id": withdraw.ID,
+		"status":      withdraw.Status,
+	})
+}
+
+// syncSweeps 将提现的状态和交易哈希同步到归集记录；创建归集后未来得及提交提现的在这里补交
+func (cc *CollectionCold) syncSweeps() error {
+	sweeps, err := cc.store.PendingSweeps(cc.chainId, sweepBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range sweeps {
+		sweep := &sweeps[i]
+		withdraw, err := cc.store.WithdrawByRequestId(sweepRequestId(sweep.ID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := cc.submit(sweep); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if withdraw.Status == sweep.Status && withdraw.TxHash == sweep.TxHash {
+			continue
+		}
+		err = cc.store.UpdateSweep(sweep.ID, map[string]interface{}{
+			"withdraw_id": withdraw.ID,
+			"tx_hash":     withdraw.TxHash,
+			"status":      withdraw.Status,
+		})
+		if err != nil {
+			return err
+		}
+		log.Info("sweep updated", "id", sweep.ID, "status", withdraw.Status, "hash", withdraw.TxHash)
+	}
+	return nil
+}
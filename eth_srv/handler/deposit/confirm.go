@@ -0,0 +1,146 @@
+package deposit
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// confirmDeposits 按链的确认策略推进充值状态：seen → confirming → safe → credited。
+// 状态都持久化在充值记录上，服务重启后从数据库继续推进。
+func (d *Deposit) confirmDeposits() error {
+	deposits, err := d.store.PendingDeposits(d.chainId)
+	if err != nil {
+		return fmt.Errorf("unable to query pending deposits: %w", err)
+	}
+	if len(deposits) == 0 {
+		return nil
+	}
+
+	latest, err := d.client.BlockHeaderByNumberContext(d.resourceCtx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to query latest header: %w", err)
+	}
+	confirmedHeight, ok, err := d.confirmedHeight(latest)
+	if err != nil {
+		return err
+	}
+	latestNumber := latest.Number.Uint64()
+
+	for i := range deposits {
+		deposit := &deposits[i]
+		var confirmations uint64
+		if latestNumber >= deposit.BlockNumber {
+			confirmations = latestNumber - deposit.BlockNumber + 1
+		}
+
+		status := deposit.Status
+		if status == global_const.DepositStatusSeen && confirmations > 1 {
+			status = global_const.DepositStatusConfirming
+		}
+		if status != global_const.DepositStatusSafe && ok && deposit.BlockNumber <= confirmedHeight {
+			status = global_const.DepositStatusSafe
+		}
+		if status != deposit.Status || confirmations != deposit.Confirmations {
+			if err := d.store.UpdateDepositStatus(deposit.ID, deposit.Status, status, confirmations); err != nil {
+				return fmt.Errorf("unable to update deposit %d: %w", deposit.ID, err)
+			}
+			deposit.Status = status
+			deposit.Confirmations = confirmations
+		}
+
+		if deposit.Status == global_const.DepositStatusSafe {
+			// 入账失败的充值由重试模块接管，不阻塞其他充值
+			active, err := d.retry.Active(global_const.RetryKindDepositCredit, deposit.ID)
+			if err != nil {
+				return err
+			}
+			if active {
+				continue
+			}
+			if err := d.credit(deposit); err != nil {
+				if err := d.retry.Submit(global_const.RetryKindDepositCredit, deposit.ID, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Deposit) credit(deposit *model.Deposit) error {
+	if err := d.verify(deposit); err != nil {
+		return err
+	}
+	if err := d.store.CreditDeposit(deposit); err != nil {
+		return fmt.Errorf("unable to credit deposit %d: %w", deposit.ID, err)
+	}
+	log.Info("deposit credited", "tx", deposit.TxHash, "to", deposit.ToAddress, "token", deposit.TokenAddress, "amount", deposit.Amount)
+	return nil
+}
+
+// verify 入账前按高度重新查询区块头，确认充值所在区块仍在主链上，原生币充值同时重新校验收据。
+// 这两项查询使用 verifier，配置了 quorum 时由多个节点投票，任何确认策略下入账都不只依赖主节点
+func (d *Deposit) verify(deposit *model.Deposit) error {
+	header, err := d.verifier.BlockHeaderByNumberContext(d.resourceCtx, new(big.Int).SetUint64(deposit.BlockNumber))
+	if err != nil {
+		return fmt.Errorf("unable to query header %d of deposit %d: %w", deposit.BlockNumber, deposit.ID, err)
+	}
+	blockHash := common.HexToHash(deposit.BlockHash)
+	if header.Hash() != blockHash {
+		return fmt.Errorf("deposit %d block %d is %s, expected %s", deposit.ID, deposit.BlockNumber, header.Hash(), blockHash)
+	}
+	if deposit.TokenAddress != global_const.EthAddress {
+		return nil
+	}
+	txHash := common.HexToHash(deposit.TxHash)
+	receipt, err := d.verifier.TxReceiptByHashContext(d.resourceCtx, txHash)
+	if err != nil {
+		return fmt.Errorf("unable to query receipt %s of deposit %d: %w", txHash, deposit.ID, err)
+	}
+	if receipt.BlockHash != blockHash || receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("deposit %d receipt %s is in block %s with status %d", deposit.ID, txHash, receipt.BlockHash, receipt.Status)
+	}
+	return nil
+}
+
+// RetryCredit 重试模块回调，充值仍处于 safe 状态时重新入账
+func (d *Deposit) RetryCredit(id uint64) error {
+	deposit, err := d.store.DepositById(id)
+	if err != nil {
+		return err
+	}
+	if deposit.Status != global_const.DepositStatusSafe {
+		return nil
+	}
+	return d.credit(deposit)
+}
+
+// confirmedHeight 返回满足确认策略的最高区块高度，ok 为 false 表示还没有区块满足策略
+func (d *Deposit) confirmedHeight(latest *types.Header) (uint64, bool, error) {
+	switch d.policy.Mode {
+	case global_const.ConfirmBySafe:
+		header, err := d.verifier.LatestSafeBlockHeaderContext(d.resourceCtx)
+		if err != nil {
+			return 0, false, fmt.Errorf("unable to query safe header: %w", err)
+		}
+		return header.Number.Uint64(), true, nil
+	case global_const.ConfirmByFinalized:
+		header, err := d.verifier.LatestFinalizedBlockHeaderContext(d.resourceCtx)
+		if err != nil {
+			return 0, false, fmt.Errorf("unable to query finalized header: %w", err)
+		}
+		return header.Number.Uint64(), true, nil
+	default:
+		latestNumber := latest.Number.Uint64()
+		if latestNumber+1 < d.policy.Depth {
+			return 0, false, nil
+		}
+		return latestNumber + 1 - d.policy.Depth, true, nil
+	}
+}
@@ -4,24 +4,78 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
 	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
-	"time"
 )
 
+// addressBook 钱包地址的内存快照，定时从数据库刷新
+type addressBook struct {
+	users map[common.Address]struct{} // 用户充值地址
+	owned map[common.Address]struct{} // 钱包管理的全部地址（用户、热、冷）
+}
+
+type DepositConfig struct {
+	ChainId uint64
+	Tokens  []config.TokenConfig
+	Policy  global_const.ConfirmationPolicy
+	// Verifier 入账前复核区块头和收据、查询 safe/finalized 高度使用的客户端（如多节点投票），为空时使用扫块儿的客户端
+	Verifier node.EthClient
+}
+
+// Retrier 失败任务交给重试模块按退避策略重新执行
+type Retrier interface {
+	Submit(kind string, refId uint64, cause error) error
+	Active(kind string, refId uint64) (bool, error)
+}
+
 type Deposit struct {
-	client         node.EthClient
+	client         node.EthClient // 处理扫块儿事件和查询最新高度
+	verifier       node.EthClient // 入账复核和确认高度
+	store          Store
+	retry          Retrier
+	chainId        uint64
+	tokens         map[common.Address]config.TokenConfig // 登记的 ERC-20 代币
+	policy         global_const.ConfirmationPolicy
+	addresses      atomic.Pointer[addressBook]
 	resourceCtx    context.Context
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 }
 
-func NewDeposit(client node.EthClient, shutdown context.CancelCauseFunc) (*Deposit, error) {
+func NewDeposit(client node.EthClient, store Store, retry Retrier, cfg DepositConfig, shutdown context.CancelCauseFunc) (*Deposit, error) {
+	if cfg.Policy.Mode == global_const.ConfirmByDepth && cfg.Policy.Depth == 0 {
+		return nil, errors.New("confirmations must be greater than zero")
+	}
+	tokens := make(map[common.Address]config.TokenConfig, len(cfg.Tokens))
+	for _, token := range cfg.Tokens {
+		if !common.IsHexAddress(token.Address) {
+			return nil, fmt.Errorf("invalid token address %q for %s", token.Address, token.Symbol)
+		}
+		tokens[common.HexToAddress(token.Address)] = token
+	}
+	verifier := cfg.Verifier
+	if verifier == nil {
+		verifier = client
+	}
 	resCtx, resCancel := context.WithCancel(context.Background())
 
 	return &Deposit{
 		client:         client,
+		verifier:       verifier,
+		store:          store,
+		retry:          retry,
+		chainId:        cfg.ChainId,
+		tokens:         tokens,
+		policy:         cfg.Policy,
 		resourceCtx:    resCtx,
 		resourceCancel: resCancel,
 		tasks: tasks.Group{
@@ -36,19 +90,145 @@ func (d *Deposit) Close() error {
 	var result error
 	d.resourceCancel()
 	if err := d.tasks.Wait(); err != nil {
-		result = errors.Join(result, fmt.Errorf("failed to await deposit %w"), err)
+		result = errors.Join(result, fmt.Errorf("failed to await deposit: %w", err))
 	}
-	return nil
+	return result
 }
 
 func (d *Deposit) Start() error {
-	log.Info("start withdraw......")
+	log.Info("start deposit......")
+	// 扫块儿开始前先加载一次地址，保证第一个区块就能匹配
+	if err := d.refreshAddresses(); err != nil {
+		return err
+	}
 	tickerDepositWorker := time.NewTicker(time.Second * 5)
 	d.tasks.Go(func() error {
-		for range tickerDepositWorker.C {
-			log.Info("Deposit work task go")
+		defer tickerDepositWorker.Stop()
+		for {
+			select {
+			case <-tickerDepositWorker.C:
+				if err := d.refreshAddresses(); err != nil {
+					log.Error("refresh deposit addresses fail", "err", err)
+				}
+				if err := d.confirmDeposits(); err != nil {
+					log.Error("confirm deposits fail", "err", err)
+				}
+			case <-d.resourceCtx.Done():
+				log.Info("stop deposit......")
+				return nil
+			}
 		}
-		return nil
 	})
 	return nil
 }
+
+func (d *Deposit) refreshAddresses() error {
+	addresses, err := d.store.Addresses()
+	if err != nil {
+		return err
+	}
+	book := &addressBook{
+		users: make(map[common.Address]struct{}),
+		owned: make(map[common.Address]struct{}, len(addresses)),
+	}
+	for _, address := range addresses {
+		addr := common.HexToAddress(address.Address)
+		book.owned[addr] = struct{}{}
+		if address.AddressType == global_const.AddressTypeUser {
+			book.users[addr] = struct{}{}
+		}
+	}
+	d.addresses.Store(book)
+	return nil
+}
+
+// BlockAdded 扫块儿新区块回调，识别转入用户地址的原生币和 ERC-20 充值
+func (d *Deposit) BlockAdded(header *types.Header) error {
+	book := d.addresses.Load()
+	if book == nil || len(book.users) == 0 {
+		return nil
+	}
+
+	deposits, err := d.nativeDeposits(header, book)
+	if err != nil {
+		return err
+	}
+	tokenDeposits, err := d.tokenDeposits(header, book)
+	if err != nil {
+		return err
+	}
+	deposits = append(deposits, tokenDeposits...)
+	if err := d.store.StoreDeposits(deposits); err != nil {
+		return fmt.Errorf("unable to store deposits of block %d: %w", header.Number, err)
+	}
+	if len(deposits) > 0 {
+		log.Info("deposits found", "number", header.Number, "count", len(deposits))
+	}
+	return nil
+}
+
+// nativeDeposits 遍历区块交易，to 为用户地址且执行成功的交易记为充值
+func (d *Deposit) nativeDeposits(header *types.Header, book *addressBook) ([]model.Deposit, error) {
+	blockHash := header.Hash()
+	block, err := d.client.BlockByHashContext(d.resourceCtx, blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query block %s: %w", blockHash, err)
+	}
+
+	var deposits []model.Deposit
+	for _, tx := range block.Transactions {
+		if tx.To == "" || tx.Value.ToInt().Sign() <= 0 {
+			continue
+		}
+		to := common.HexToAddress(tx.To)
+		if _, ok := book.users[to]; !ok {
+			continue
+		}
+		// 钱包内部地址之间的转账（如归集补手续费）不算充值
+		from := common.HexToAddress(tx.From)
+		if _, ok := book.owned[from]; ok {
+			continue
+		}
+
+		txHash := common.HexToHash(tx.Hash)
+		receipt, err := d.client.TxReceiptByHashContext(d.resourceCtx, txHash)
+		if err != nil {
+			return nil, fmt.Errorf("unable to query receipt %s: %w", txHash, err)
+		}
+		if receipt.BlockHash != blockHash {
+			return nil, fmt.Errorf("receipt %s belongs to block %s, expected %s", txHash, receipt.BlockHash, blockHash)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			log.Warn("skip failed deposit tx", "hash", txHash)
+			continue
+		}
+
+		deposits = append(deposits, model.Deposit{
+			ChainId:      d.chainId,
+			BlockHash:    blockHash.String(),
+			BlockNumber:  header.Number.Uint64(),
+			TxHash:       txHash.String(),
+			TxIndex:      uint64(tx.TransactionIndex),
+			TokenAddress: global_const.EthAddress,
+			FromAddress:  from.String(),
+			ToAddress:    to.String(),
+			Amount:       tx.Value.ToInt().String(),
+			Status:       global_const.DepositStatusSeen,
+		})
+	}
+	return deposits, nil
+}
+
+// BlockReverted 链重组区块回滚回调，将该区块内未入账的充值标记为已回滚
+func (d *Deposit) BlockReverted(block node.BlockRef) error {
+	credited, err := d.store.RevertDeposits(block.Hash.String())
+	if err != nil {
+		return fmt.Errorf("unable to revert deposits of block %s: %w", block.Hash, err)
+	}
+	// 已入账的充值被回滚说明确认策略不足，需要人工介入处理余额
+	for _, deposit := range credited {
+		log.Error("credited deposit reverted", "tx", deposit.TxHash, "to", deposit.ToAddress, "token", deposit.TokenAddress, "amount", deposit.Amount)
+	}
+	log.Debug("deposit block reverted", "number", block.Number, "hash", block.Hash)
+	return nil
+}
@@ -0,0 +1,497 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+)
+
+var (
+	userAddress = common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	hotAddress  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	outsider    = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	usdc        = common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+)
+
+type fakeClient struct {
+	node.EthClient
+	block    *node.RpcBlock
+	receipts map[common.Hash]*types.Receipt
+	logs     node.Logs
+}
+
+func (c *fakeClient) FilterLogsContext(context.Context, ethereum.FilterQuery, uint) (node.Logs, error) {
+	return c.logs, nil
+}
+
+func (c *fakeClient) BlockByHashContext(context.Context, common.Hash) (*node.RpcBlock, error) {
+	return c.block, nil
+}
+
+func (c *fakeClient) TxReceiptByHashContext(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	return c.receipts[hash], nil
+}
+
+type memRetry struct {
+	active map[uint64]error
+}
+
+func newMemRetry() *memRetry {
+	return &memRetry{active: make(map[uint64]error)}
+}
+
+func (r *memRetry) Submit(_ string, refId uint64, cause error) error {
+	r.active[refId] = cause
+	return nil
+}
+
+func (r *memRetry) Active(_ string, refId uint64) (bool, error) {
+	_, ok := r.active[refId]
+	return ok, nil
+}
+
+func newMemStore() *memStore {
+	return &memStore{addresses: []model.Address{
+		{Address: userAddress.String(), AddressType: global_const.AddressTypeUser},
+		{Address: hotAddress.String(), AddressType: global_const.AddressTypeHot},
+	}}
+}
+
+type memStore struct {
+	addresses []model.Address
+	deposits  []model.Deposit
+	balances  map[string]*big.Int
+	creditErr error
+}
+
+func (s *memStore) Addresses() ([]model.Address, error) {
+	return s.addresses, nil
+}
+
+func (s *memStore) StoreDeposits(deposits []model.Deposit) error {
+next:
+	for _, deposit := range deposits {
+		for i := range s.deposits {
+			existing := &s.deposits[i]
+			if existing.BlockHash == deposit.BlockHash && existing.TxHash == deposit.TxHash &&
+				existing.LogIndex == deposit.LogIndex && existing.TokenAddress == deposit.TokenAddress {
+				if existing.Status == global_const.DepositStatusReverted {
+					existing.Status, existing.Confirmations = global_const.DepositStatusSeen, 0
+				}
+				continue next
+			}
+		}
+		deposit.ID = uint64(len(s.deposits) + 1)
+		s.deposits = append(s.deposits, deposit)
+	}
+	return nil
+}
+
+func (s *memStore) DepositById(id uint64) (*model.Deposit, error) {
+	for i := range s.deposits {
+		if s.deposits[i].ID == id {
+			deposit := s.deposits[i]
+			return &deposit, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memStore) PendingDeposits(uint64) ([]model.Deposit, error) {
+	var pending []model.Deposit
+	for _, deposit := range s.deposits {
+		if deposit.Status < global_const.DepositStatusCredited {
+			pending = append(pending, deposit)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memStore) UpdateDepositStatus(id uint64, from, to uint8, confirmations uint64) error {
+	for i := range s.deposits {
+		if s.deposits[i].ID == id && s.deposits[i].Status == from {
+			s.deposits[i].Status = to
+			s.deposits[i].Confirmations = confirmations
+		}
+	}
+	return nil
+}
+
+func (s *memStore) CreditDeposit(deposit *model.Deposit) error {
+	if s.creditErr != nil {
+		return s.creditErr
+	}
+	for i := range s.deposits {
+		if s.deposits[i].ID == deposit.ID && s.deposits[i].Status == global_const.DepositStatusSafe {
+			s.deposits[i].Status = global_const.DepositStatusCredited
+			if s.balances == nil {
+				s.balances = make(map[string]*big.Int)
+			}
+			amount, _ := new(big.Int).SetString(deposit.Amount, 10)
+			key := deposit.ToAddress + deposit.TokenAddress
+			if s.balances[key] == nil {
+				s.balances[key] = new(big.Int)
+			}
+			s.balances[key].Add(s.balances[key], amount)
+		}
+	}
+	return nil
+}
+
+func (s *memStore) RevertDeposits(blockHash string) ([]model.Deposit, error) {
+	var credited []model.Deposit
+	for i := range s.deposits {
+		if s.deposits[i].BlockHash != blockHash {
+			continue
+		}
+		if s.deposits[i].Status == global_const.DepositStatusCredited {
+			credited = append(credited, s.deposits[i])
+		} else {
+			s.deposits[i].Status = global_const.DepositStatusReverted
+		}
+	}
+	return credited, nil
+}
+
+func transfer(from, to common.Address, value int64, index uint64) node.TransactionList {
+	return node.TransactionList{
+		From:             from.String(),
+		To:               to.String(),
+		Hash:             common.BigToHash(big.NewInt(int64(index) + 1)).String(),
+		Value:            hexutil.Big(*big.NewInt(value)),
+		TransactionIndex: hexutil.Uint64(index),
+	}
+}
+
+func TestNativeDeposits(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(100)}
+	txs := []node.TransactionList{
+		transfer(outsider, userAddress, 1000, 0),   // 正常充值
+		transfer(outsider, userAddress, 2000, 1),   // 执行失败
+		transfer(hotAddress, userAddress, 3000, 2), // 内部转账
+		transfer(outsider, outsider, 4000, 3),      // 与钱包无关
+		transfer(outsider, userAddress, 0, 4),      // 零金额
+	}
+	receipts := make(map[common.Hash]*types.Receipt)
+	for i, tx := range txs {
+		status := types.ReceiptStatusSuccessful
+		if i == 1 {
+			status = types.ReceiptStatusFailed
+		}
+		receipts[common.HexToHash(tx.Hash)] = &types.Receipt{Status: status, BlockHash: header.Hash()}
+	}
+
+	store := newMemStore()
+	client := &fakeClient{block: &node.RpcBlock{Hash: header.Hash(), Transactions: txs}, receipts: receipts}
+	d, err := NewDeposit(client, store, newMemRetry(), DepositConfig{ChainId: global_const.EthereumChainId, Policy: global_const.ConfirmationPolicyOf(global_const.EthereumChainId)}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.refreshAddresses(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.BlockAdded(header); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deposits) != 1 {
+		t.Fatalf("expected 1 deposit, got %d", len(store.deposits))
+	}
+	deposit := store.deposits[0]
+	if deposit.Amount != "1000" || deposit.ToAddress != userAddress.String() || deposit.FromAddress != outsider.String() {
+		t.Fatalf("unexpected deposit %+v", deposit)
+	}
+	if deposit.BlockNumber != 100 || deposit.BlockHash != header.Hash().String() || deposit.TokenAddress != global_const.EthAddress {
+		t.Fatalf("unexpected deposit block info %+v", deposit)
+	}
+
+	if err := d.BlockReverted(node.BlockRef{Number: header.Number, Hash: header.Hash()}); err != nil {
+		t.Fatal(err)
+	}
+	if store.deposits[0].Status != global_const.DepositStatusReverted {
+		t.Fatalf("expected deposit to be reverted, got status %d", store.deposits[0].Status)
+	}
+
+	// 同一区块重新成为主链，重新扫描后充值恢复为已扫到，不会停在回滚状态
+	if err := d.BlockAdded(header); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deposits) != 1 || store.deposits[0].Status != global_const.DepositStatusSeen {
+		t.Fatalf("expected the reverted deposit to be seen again, got %+v", store.deposits)
+	}
+}
+
+func transferLog(token, from, to common.Address, amount int64, index uint, blockHash common.Hash) types.Log {
+	return types.Log{
+		Address:   token,
+		Topics:    []common.Hash{TransferEventTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:      common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		BlockHash: blockHash,
+		TxHash:    common.BigToHash(big.NewInt(int64(index) + 100)),
+		Index:     index,
+	}
+}
+
+func TestTokenDeposits(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(200)}
+	blockHash := header.Hash()
+	unknownToken := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	nft := transferLog(usdc, outsider, userAddress, 1, 4, blockHash)
+	nft.Topics = append(nft.Topics, common.BigToHash(big.NewInt(1)))
+	nft.Data = nil
+
+	client := &fakeClient{
+		block: &node.RpcBlock{Hash: blockHash},
+		logs: node.Logs{
+			ToBlockHeader: header,
+			Logs: []types.Log{
+				transferLog(usdc, outsider, userAddress, 5000000, 0, blockHash),         // 正常充值
+				transferLog(unknownToken, outsider, userAddress, 5000000, 1, blockHash), // 未登记代币
+				transferLog(usdc, outsider, outsider, 5000000, 2, blockHash),            // 与钱包无关
+				transferLog(usdc, hotAddress, userAddress, 5000000, 3, blockHash),       // 内部转账
+				nft, // ERC-721 Transfer
+			},
+		},
+	}
+	store := newMemStore()
+	tokens := []config.TokenConfig{{Symbol: "USDC", Address: usdc.String(), Decimals: 6}}
+	d, err := NewDeposit(client, store, newMemRetry(), DepositConfig{ChainId: global_const.EthereumSepoliaChainId, Tokens: tokens, Policy: global_const.ConfirmationPolicyOf(global_const.EthereumSepoliaChainId)}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.refreshAddresses(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.BlockAdded(header); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deposits) != 1 {
+		t.Fatalf("expected 1 deposit, got %d", len(store.deposits))
+	}
+	deposit := store.deposits[0]
+	if deposit.TokenAddress != usdc.String() || deposit.Amount != "5000000" || deposit.LogIndex != 0 || deposit.ToAddress != userAddress.String() {
+		t.Fatalf("unexpected deposit %+v", deposit)
+	}
+
+	// 查询期间链头变化时整块重试
+	client.logs.ToBlockHeader = &types.Header{Number: big.NewInt(200), Extra: []byte{1}}
+	if err := d.BlockAdded(header); err == nil {
+		t.Fatal("expected error when logs come from another block")
+	}
+}
+
+// headClient 链头和 finalized 高度可调，按高度查询返回 fork 链上的区块头，0 为充值所在的链
+type headClient struct {
+	node.EthClient
+	latest    uint64
+	finalized uint64
+	fork      byte
+	receipts  map[common.Hash]*types.Receipt
+}
+
+func newHeadClient(latest uint64) *headClient {
+	return &headClient{latest: latest, receipts: make(map[common.Hash]*types.Receipt)}
+}
+
+func blockHeader(number uint64, fork byte) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{fork}}
+}
+
+func (c *headClient) BlockHeaderByNumberContext(_ context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return &types.Header{Number: new(big.Int).SetUint64(c.latest)}, nil
+	}
+	return blockHeader(number.Uint64(), c.fork), nil
+}
+
+func (c *headClient) LatestFinalizedBlockHeaderContext(context.Context) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.finalized)}, nil
+}
+
+func (c *headClient) TxReceiptByHashContext(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, ok := c.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// seenDeposit 高度 number 上执行成功的原生币充值
+func (c *headClient) seenDeposit(number uint64, amount string) model.Deposit {
+	blockHash := blockHeader(number, 0).Hash()
+	txHash := common.BigToHash(new(big.Int).SetUint64(number))
+	c.receipts[txHash] = &types.Receipt{BlockHash: blockHash, Status: types.ReceiptStatusSuccessful}
+	return model.Deposit{
+		BlockNumber:  number,
+		BlockHash:    blockHash.String(),
+		TxHash:       txHash.String(),
+		ToAddress:    userAddress.String(),
+		TokenAddress: global_const.EthAddress,
+		Amount:       amount,
+		Status:       global_const.DepositStatusSeen,
+	}
+}
+
+func TestConfirmDeposits(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy global_const.ConfirmationPolicy
+		// 每一轮的链头和 finalized 高度，以及期望的状态
+		rounds []struct{ latest, finalized uint64 }
+		want   []uint8
+	}{
+		{
+			name:   "depth",
+			policy: global_const.ConfirmationPolicy{Mode: global_const.ConfirmByDepth, Depth: 3},
+			rounds: []struct{ latest, finalized uint64 }{{100, 0}, {101, 0}, {102, 0}, {110, 0}},
+			want: []uint8{
+				global_const.DepositStatusSeen,
+				global_const.DepositStatusConfirming,
+				global_const.DepositStatusCredited,
+				global_const.DepositStatusCredited,
+			},
+		},
+		{
+			name:   "finalized",
+			policy: global_const.ConfirmationPolicy{Mode: global_const.ConfirmByFinalized},
+			rounds: []struct{ latest, finalized uint64 }{{105, 90}, {140, 99}, {141, 100}},
+			want: []uint8{
+				global_const.DepositStatusConfirming,
+				global_const.DepositStatusConfirming,
+				global_const.DepositStatusCredited,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			client := newHeadClient(0)
+			d, err := NewDeposit(client, store, newMemRetry(), DepositConfig{ChainId: global_const.EthereumChainId, Policy: tt.policy}, func(error) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = store.StoreDeposits([]model.Deposit{client.seenDeposit(100, "1000")})
+
+			for i, round := range tt.rounds {
+				client.latest, client.finalized = round.latest, round.finalized
+				if err := d.confirmDeposits(); err != nil {
+					t.Fatal(err)
+				}
+				if got := store.deposits[0].Status; got != tt.want[i] {
+					t.Fatalf("round %d: status = %d, want %d", i, got, tt.want[i])
+				}
+			}
+			// 多轮推进只入账一次
+			if got := store.balances[userAddress.String()+global_const.EthAddress]; got == nil || got.Int64() != 1000 {
+				t.Fatalf("unexpected balance %v", got)
+			}
+		})
+	}
+}
+
+func TestCreditFailureSubmitsRetry(t *testing.T) {
+	store := newMemStore()
+	store.creditErr = errors.New("deadlock found")
+	retry := newMemRetry()
+	client := newHeadClient(110)
+	d, err := NewDeposit(client, store, retry, DepositConfig{ChainId: global_const.EthereumChainId, Policy: global_const.ConfirmationPolicy{Mode: global_const.ConfirmByDepth, Depth: 3}}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.StoreDeposits([]model.Deposit{client.seenDeposit(100, "1000"), client.seenDeposit(101, "2000")})
+
+	if err := d.confirmDeposits(); err != nil {
+		t.Fatalf("credit failure should be handed to retry, got %v", err)
+	}
+	if len(retry.active) != 2 {
+		t.Fatalf("expected both deposits submitted to retry, got %d", len(retry.active))
+	}
+
+	store.creditErr = nil
+	if err := d.RetryCredit(store.deposits[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if store.deposits[0].Status != global_const.DepositStatusCredited {
+		t.Fatalf("retry should credit the deposit, got status %d", store.deposits[0].Status)
+	}
+	// 已入账的充值再次重试不会重复入账
+	if err := d.RetryCredit(store.deposits[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.balances[userAddress.String()+global_const.EthAddress]; got.Int64() != 1000 {
+		t.Fatalf("unexpected balance %s", got)
+	}
+}
+
+// 充值确认后入账前，按高度查询到的区块（配置 quorum 时为多数节点认可的区块）与充值记录不一致，
+// 或原生币充值的收据不再成功时不入账，交给重试模块
+func TestCreditVerifiesBlockAndReceipt(t *testing.T) {
+	policies := []global_const.ConfirmationPolicy{
+		{Mode: global_const.ConfirmByDepth, Depth: 3},
+		{Mode: global_const.ConfirmByFinalized},
+	}
+	for _, policy := range policies {
+		store := newMemStore()
+		retry := newMemRetry()
+		client := newHeadClient(110)
+		client.finalized = 105
+		d, err := NewDeposit(client, store, retry, DepositConfig{ChainId: global_const.EthereumChainId, Policy: policy}, func(error) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		reorged, failed := client.seenDeposit(100, "1000"), client.seenDeposit(101, "2000")
+		reorged.BlockHash = blockHeader(100, 1).Hash().String()
+		client.receipts[common.HexToHash(failed.TxHash)].Status = types.ReceiptStatusFailed
+		_ = store.StoreDeposits([]model.Deposit{reorged, failed})
+
+		if err := d.confirmDeposits(); err != nil {
+			t.Fatal(err)
+		}
+		for _, deposit := range store.deposits {
+			if deposit.Status != global_const.DepositStatusSafe || retry.active[deposit.ID] == nil {
+				t.Fatalf("mode %d: deposit %d should stay safe and be retried, got status %d", policy.Mode, deposit.ID, deposit.Status)
+			}
+		}
+		if len(store.balances) != 0 {
+			t.Fatalf("mode %d: unexpected balances %v", policy.Mode, store.balances)
+		}
+	}
+}
+
+// 配置复核客户端后入账前的区块和收据只向复核客户端查询，主客户端只负责链头高度
+func TestCreditUsesVerifier(t *testing.T) {
+	store := newMemStore()
+	client := newHeadClient(110)
+	client.fork = 1
+	verifier := newHeadClient(110)
+	d, err := NewDeposit(client, store, newMemRetry(), DepositConfig{
+		ChainId:  global_const.EthereumChainId,
+		Policy:   global_const.ConfirmationPolicy{Mode: global_const.ConfirmByDepth, Depth: 3},
+		Verifier: verifier,
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.StoreDeposits([]model.Deposit{verifier.seenDeposit(100, "1000")})
+
+	if err := d.confirmDeposits(); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.deposits[0].Status; got != global_const.DepositStatusCredited {
+		t.Fatalf("expected the verifier to confirm the deposit, got status %d", got)
+	}
+}
@@ -0,0 +1,89 @@
+package deposit
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// TransferEventTopic ERC-20 Transfer(address,address,uint256) 事件的 topic0
+var TransferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// tokenDeposits 通过 Transfer 日志识别转入用户地址的 ERC-20 充值，只处理登记过的代币
+func (d *Deposit) tokenDeposits(header *types.Header, book *addressBook) ([]model.Deposit, error) {
+	if len(d.tokens) == 0 {
+		return nil, nil
+	}
+	blockHash := header.Hash()
+	tokenAddresses := make([]common.Address, 0, len(d.tokens))
+	for address := range d.tokens {
+		tokenAddresses = append(tokenAddresses, address)
+	}
+
+	query := ethereum.FilterQuery{
+		FromBlock: header.Number,
+		ToBlock:   header.Number,
+		Addresses: tokenAddresses,
+		Topics:    [][]common.Hash{{TransferEventTopic}},
+	}
+	logs, err := d.client.FilterLogsContext(d.resourceCtx, query, uint(d.chainId))
+	if err != nil {
+		return nil, fmt.Errorf("unable to query transfer logs of block %d: %w", header.Number, err)
+	}
+	// 按高度查询，链头在查询期间变化时日志可能来自其他分叉，交给扫块儿下一轮重试
+	if logs.ToBlockHeader.Hash() != blockHash {
+		return nil, fmt.Errorf("block %d changed while querying logs, got %s expected %s", header.Number, logs.ToBlockHeader.Hash(), blockHash)
+	}
+
+	var deposits []model.Deposit
+	for _, vLog := range logs.Logs {
+		if vLog.Removed {
+			continue
+		}
+		if vLog.BlockHash != blockHash {
+			return nil, fmt.Errorf("log %s:%d belongs to block %s, expected %s", vLog.TxHash, vLog.Index, vLog.BlockHash, blockHash)
+		}
+		if _, ok := d.tokens[vLog.Address]; !ok {
+			continue
+		}
+		// ERC-721 的 Transfer 事件 tokenId 也是 indexed，topic 数量为 4，这里只处理 ERC-20
+		if len(vLog.Topics) != 3 || vLog.Topics[0] != TransferEventTopic || len(vLog.Data) != 32 {
+			continue
+		}
+		to := common.BytesToAddress(vLog.Topics[2].Bytes())
+		if _, ok := book.users[to]; !ok {
+			continue
+		}
+		from := common.BytesToAddress(vLog.Topics[1].Bytes())
+		if _, ok := book.owned[from]; ok {
+			continue
+		}
+		amount := new(big.Int).SetBytes(vLog.Data)
+		if amount.Sign() <= 0 {
+			continue
+		}
+
+		deposits = append(deposits, model.Deposit{
+			ChainId:      d.chainId,
+			BlockHash:    blockHash.String(),
+			BlockNumber:  header.Number.Uint64(),
+			TxHash:       vLog.TxHash.String(),
+			TxIndex:      uint64(vLog.TxIndex),
+			LogIndex:     uint64(vLog.Index),
+			TokenAddress: vLog.Address.String(),
+			FromAddress:  from.String(),
+			ToAddress:    to.String(),
+			Amount:       amount.String(),
+			Status:       global_const.DepositStatusSeen,
+		})
+		log.Debug("erc20 deposit found", "token", d.tokens[vLog.Address].Symbol, "tx", vLog.TxHash, "amount", amount)
+	}
+	return deposits, nil
+}
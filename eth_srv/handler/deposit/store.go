@@ -0,0 +1,174 @@
+package deposit
+
+import (
+	"fmt"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/event"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store 充值模块的持久化接口
+type Store interface {
+	// Addresses 返回钱包管理的全部地址
+	Addresses() ([]model.Address, error)
+	// StoreDeposits 写入充值记录，重复记录会被忽略，已回滚的记录恢复为已扫到
+	StoreDeposits(deposits []model.Deposit) error
+	// DepositById 按 ID 查询充值
+	DepositById(id uint64) (*model.Deposit, error)
+	// PendingDeposits 返回尚未入账也未回滚的充值
+	PendingDeposits(chainId uint64) ([]model.Deposit, error)
+	// UpdateDepositStatus 推进充值状态，只有当前状态仍为 from 时才会更新，状态变化时写入充值事件
+	UpdateDepositStatus(id uint64, from, to uint8, confirmations uint64) error
+	// CreditDeposit 将 safe 状态的充值标记为已入账并累加余额，与入账事件在同一事务中完成
+	CreditDeposit(deposit *model.Deposit) error
+	// RevertDeposits 将被回滚区块中未入账的充值标记为已回滚并写入回滚事件，返回其中已经入账的记录
+	RevertDeposits(blockHash string) ([]model.Deposit, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) Addresses() ([]model.Address, error) {
+	var addresses []model.Address
+	err := s.db.Find(&addresses).Error
+	return addresses, err
+}
+
+// StoreDeposits 区块回滚后又重新成为主链（重组来回切换或误判回滚）时，重新扫到的充值已有回滚状态的记录，
+// 唯一键冲突会让它永远停在回滚状态，因此先把这些记录恢复为已扫到并写入事件，其余记录重复时忽略
+func (s *store) StoreDeposits(deposits []model.Deposit) error {
+	if len(deposits) == 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range deposits {
+			deposit := &deposits[i]
+			result := tx.Model(&model.Deposit{}).
+				Where("block_hash = ? AND tx_hash = ? AND log_index = ? AND token_address = ? AND status = ?",
+					deposit.BlockHash, deposit.TxHash, deposit.LogIndex, deposit.TokenAddress, global_const.DepositStatusReverted).
+				Updates(map[string]interface{}{"status": global_const.DepositStatusSeen, "confirmations": 0})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			var restored model.Deposit
+			err := tx.Where("block_hash = ? AND tx_hash = ? AND log_index = ? AND token_address = ?",
+				deposit.BlockHash, deposit.TxHash, deposit.LogIndex, deposit.TokenAddress).First(&restored).Error
+			if err != nil {
+				return err
+			}
+			if err := event.Append(tx, event.DepositEvent(&restored)); err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deposits).Error
+	})
+}
+
+func (s *store) DepositById(id uint64) (*model.Deposit, error) {
+	var deposit model.Deposit
+	if err := s.db.Where("id = ?", id).First(&deposit).Error; err != nil {
+		return nil, err
+	}
+	return &deposit, nil
+}
+
+func (s *store) PendingDeposits(chainId uint64) ([]model.Deposit, error) {
+	var deposits []model.Deposit
+	err := s.db.Where("chain_id = ? AND status IN ?", chainId, []uint8{
+		global_const.DepositStatusSeen,
+		global_const.DepositStatusConfirming,
+		global_const.DepositStatusSafe,
+	}).Order("block_number ASC").Find(&deposits).Error
+	return deposits, err
+}
+
+func (s *store) UpdateDepositStatus(id uint64, from, to uint8, confirmations uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Deposit{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(map[string]interface{}{"status": to, "confirmations": confirmations})
+		if result.Error != nil {
+			return result.Error
+		}
+		// 只更新确认数不产生事件
+		if result.RowsAffected == 0 || from == to {
+			return nil
+		}
+		var deposit model.Deposit
+		if err := tx.Where("id = ?", id).First(&deposit).Error; err != nil {
+			return err
+		}
+		return event.Append(tx, event.DepositEvent(&deposit))
+	})
+}
+
+func (s *store) CreditDeposit(deposit *model.Deposit) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Deposit{}).
+			Where("id = ? AND status = ?", deposit.ID, global_const.DepositStatusSafe).
+			Update("status", global_const.DepositStatusCredited)
+		if result.Error != nil {
+			return result.Error
+		}
+		// 状态已被其他流程改变（入账或回滚），不再累加余额
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		balance := model.Balance{
+			Address:      deposit.ToAddress,
+			TokenAddress: deposit.TokenAddress,
+			Balance:      deposit.Amount,
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "address"}, {Name: "token_address"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"balance": gorm.Expr("balance + ?", deposit.Amount)}),
+		}).Create(&balance).Error
+		if err != nil {
+			return fmt.Errorf("unable to credit balance: %w", err)
+		}
+		credited := *deposit
+		credited.Status = global_const.DepositStatusCredited
+		return event.Append(tx, event.DepositEvent(&credited))
+	})
+}
+
+func (s *store) RevertDeposits(blockHash string) ([]model.Deposit, error) {
+	var credited []model.Deposit
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("block_hash = ? AND status = ?", blockHash, global_const.DepositStatusCredited).Find(&credited).Error
+		if err != nil {
+			return err
+		}
+		var reverted []model.Deposit
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("block_hash = ? AND status NOT IN ?", blockHash, []uint8{global_const.DepositStatusCredited, global_const.DepositStatusReverted}).
+			Find(&reverted).Error
+		if err != nil || len(reverted) == 0 {
+			return err
+		}
+		ids := make([]uint64, 0, len(reverted))
+		events := make([]*model.Event, 0, len(reverted))
+		for i := range reverted {
+			reverted[i].Status = global_const.DepositStatusReverted
+			ids = append(ids, reverted[i].ID)
+			events = append(events, event.DepositEvent(&reverted[i]))
+		}
+		err = tx.Model(&model.Deposit{}).Where("id IN ?", ids).Update("status", global_const.DepositStatusReverted).Error
+		if err != nil {
+			return err
+		}
+		return event.Append(tx, events...)
+	})
+	return credited, err
+}
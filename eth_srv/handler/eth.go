@@ -2,50 +2,199 @@ package handler
 
 import (
 	"context"
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/collection"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/collection_cold"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/deposit"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/retry"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/webhook"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/withdraw"
-	"github.com/0xweb-3/CoinNest/proto"
-	"go.uber.org/zap"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
 	"gorm.io/gorm"
+	"io"
 	"sync/atomic"
 )
 
 type EthWallet struct {
 	ethClient      node.EthClient
+	quorumClient   *node.QuorumClient
+	scanner        *node.Scanner
 	collectionCold *collection_cold.CollectionCold
+	collection     *collection.Collection
+	retry          *retry.Retry
+	webhook        *webhook.Webhook
 	deposit        *deposit.Deposit
 	withdraw       *withdraw.Withdraw
+	hotSigner      wallet.Signer
+	keyring        *wallet.Keyring
 
 	shoutDown context.CancelCauseFunc
 	stopped   atomic.Bool
 }
 
-func NewEthWallet(ctx context.Context, shoutDown context.CancelCauseFunc) (*EthWallet, error) {
-	ethClient, err := node.DialEthClient(ctx, "")
+func NewEthWallet(ctx context.Context, db *gorm.DB, cnf config.EthConfig, shoutDown context.CancelCauseFunc) (*EthWallet, error) {
+	ethClient, err := dialEthClient(ctx, cnf)
 	if err != nil {
 		return nil, err
 	}
+	// 充值入账前复核的收据、区块头和 safe/finalized 高度可选多节点投票，防止单个节点返回错误数据；
+	// 扫块儿仍只使用主客户端，不为每个区块和收据付出投票的开销
+	quorumClient, err := dialQuorumClient(ctx, ethClient, cnf.Rpc)
+	if err != nil {
+		return nil, err
+	}
+	var depositVerifier node.EthClient
+	if quorumClient != nil {
+		quorumClient.OnMismatch(alertQuorumMismatch)
+		depositVerifier = quorumClient
+	}
+	scanner, err := node.NewScanner(ethClient, node.NewBlockStore(db), node.ScannerConfig{
+		ChainId:        cnf.ChainId,
+		StartingHeight: cnf.StartingHeight,
+		BlocksStep:     cnf.BlocksStep,
+		ReorgWindow:    cnf.ReorgWindow,
+		LoopInterval:   cnf.LoopInterval,
+		MaxBatchSize:   rpcMaxBatchSize(cnf.Rpc),
+	}, shoutDown)
+	if err != nil {
+		return nil, err
+	}
+	if url := headsUrl(cnf); url != "" {
+		scanner.SubscribeHeads(node.NewHeadSubscription(url))
+	}
 
-	withdraw, err := withdraw.NewWithdraw(ethClient, shoutDown)
+	retry, err := retry.NewRetry(retry.NewStore(db), cnf.Retry, shoutDown)
 	if err != nil {
 		return nil, err
 	}
-	deposit, err := deposit.NewDeposit(ethClient, shoutDown)
+
+	hotSigner, err := newHotSigner(cnf)
 	if err != nil {
 		return nil, err
 	}
-	collectionCold, err := collection_cold.NewCollectionCold(ethClient, shoutDown)
+	keyring, err := newKeyring(cnf.KeyEncryption)
+	if err != nil {
+		return nil, err
+	}
+	hdSigner, err := newHDSigner(cnf, db, hotSigner)
+	if err != nil {
+		return nil, err
+	}
+	// 热钱包由签名后端签名，随机生成的用户地址私钥加密保存在数据库中，派生的用户地址按序号交给签名机签名，
+	// 归集时从用户地址转出
+	signers := []wallet.Signer{hotSigner}
+	if keyring != nil {
+		migrated, err := withdraw.MigratePlaintextKeys(db, keyring)
+		if err != nil {
+			return nil, err
+		}
+		rewrapped, err := withdraw.RewrapKeys(db, keyring)
+		if err != nil {
+			return nil, err
+		}
+		if migrated > 0 || rewrapped > 0 {
+			log.Info("address keys encrypted", "migrated", migrated, "rewrapped", rewrapped, "master_key", keyring.Current())
+		}
+		signers = append(signers, withdraw.NewDBSigner(db, keyring))
+	}
+	if hdSigner != nil {
+		signers = append(signers, hdSigner)
+	}
+	signer := wallet.NewMultiSigner(signers...)
+	hotWallets := make([]common.Address, 0, len(cnf.HotWallets))
+	for _, hotWallet := range cnf.HotWallets {
+		hotWallets = append(hotWallets, common.HexToAddress(hotWallet.Address))
+	}
+	capability := global_const.TxCapabilityOf(cnf.ChainId)
+	if cnf.TxType != "" {
+		capability, err = global_const.ParseTxType(cnf.TxType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fees, err := newFeeOracle(ethClient, cnf.ChainId, capability, cnf.Fee)
 	if err != nil {
 		return nil, err
 	}
+	nonces := withdraw.NewNonceManager(ethClient, cnf.ChainId)
+	withdraw, err := withdraw.NewWithdraw(ethClient, fees, withdraw.NewStore(db, nonces), signer, retry, withdraw.WithdrawConfig{
+		ChainId:      cnf.ChainId,
+		TxCapability: capability,
+		HotWallets:   hotWallets,
+		Workers:      cnf.WithdrawWorker,
+		StuckTimeout: cnf.StuckTimeout,
+	}, shoutDown)
+	if err != nil {
+		return nil, err
+	}
+	policy := global_const.ConfirmationPolicyOf(cnf.ChainId)
+	if cnf.ConfirmMode != "" {
+		mode, err := global_const.ParseConfirmMode(cnf.ConfirmMode)
+		if err != nil {
+			return nil, err
+		}
+		policy = global_const.ConfirmationPolicy{Mode: mode, Depth: cnf.Confirmations}
+	}
+	deposit, err := deposit.NewDeposit(ethClient, deposit.NewStore(db), retry, deposit.DepositConfig{
+		ChainId:  cnf.ChainId,
+		Tokens:   cnf.Tokens,
+		Policy:   policy,
+		Verifier: depositVerifier,
+	}, shoutDown)
+	if err != nil {
+		return nil, err
+	}
+	collectionCold, err := collection_cold.NewCollectionCold(ethClient, fees, collection_cold.NewStore(db), withdraw, collection_cold.CollectionColdConfig{
+		ChainId:    cnf.ChainId,
+		HotWallets: hotWallets,
+		ColdWallet: cnf.ColdWallet,
+		Sweeps:     cnf.Sweeps,
+		Interval:   cnf.SweepInterval,
+	}, shoutDown)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := collection.NewCollection(ethClient, fees, collection.NewStore(db), withdraw, retry, collection.CollectionConfig{
+		ChainId:   cnf.ChainId,
+		HotWallet: hotWallets[0],
+		Rules:     cnf.Collections,
+		Interval:  cnf.CollectInterval,
+	}, shoutDown)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := webhook.NewWebhook(webhook.NewStore(db), retry, webhook.WebhookConfig{
+		Endpoints: cnf.Webhooks,
+		Interval:  cnf.WebhookInterval,
+		Timeout:   cnf.WebhookTimeout,
+	}, shoutDown)
+	if err != nil {
+		return nil, err
+	}
+	retry.Register(global_const.RetryKindWithdrawBroadcast, withdraw.RetryBroadcast)
+	retry.Register(global_const.RetryKindDepositCredit, deposit.RetryCredit)
+	retry.Register(global_const.RetryKindCollection, collection.RetryStep)
+	retry.Register(global_const.RetryKindWebhook, webhook.RetryDeliver)
+	// 扫块儿事件按注册顺序同步分发
+	scanner.AddListener(deposit)
+	scanner.AddListener(withdraw)
 
 	out := &EthWallet{
 		ethClient:      ethClient,
+		quorumClient:   quorumClient,
+		scanner:        scanner,
 		collectionCold: collectionCold,
+		collection:     collection,
+		retry:          retry,
+		webhook:        webhook,
 		deposit:        deposit,
 		withdraw:       withdraw,
+		hotSigner:      hotSigner,
+		keyring:        keyring,
 		shoutDown:      shoutDown,
 	}
 
@@ -57,46 +206,97 @@ func (ew *EthWallet) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	//err = ew.withdraw.Start()
-	//if err != nil {
-	//	return err
-	//}
+	err = ew.withdraw.Start()
+	if err != nil {
+		return err
+	}
 	err = ew.collectionCold.Start()
 	if err != nil {
 		return err
 	}
+	err = ew.collection.Start()
+	if err != nil {
+		return err
+	}
+	err = ew.webhook.Start()
+	if err != nil {
+		return err
+	}
+	err = ew.retry.Start()
+	if err != nil {
+		return err
+	}
+	err = ew.scanner.Start()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
 func (ew *EthWallet) Stop(ctx context.Context) error {
-	err := ew.deposit.Close()
+	defer ew.stopped.Store(true)
+	// 先停止扫块儿，避免回调进入已关闭的模块
+	err := ew.scanner.Close()
+	if err != nil {
+		return err
+	}
+	// 重试任务会回调各模块，先于各模块停止
+	err = ew.retry.Close()
+	if err != nil {
+		return err
+	}
+	err = ew.deposit.Close()
+	if err != nil {
+		return err
+	}
+	err = ew.withdraw.Close()
 	if err != nil {
 		return err
 	}
-	//err = ew.withdraw.Close()
-	//if err != nil {
-	//	return err
-	//}
 
 	err = ew.collectionCold.Close()
 	if err != nil {
 		return err
 	}
+	err = ew.collection.Close()
+	if err != nil {
+		return err
+	}
+	err = ew.webhook.Close()
+	if err != nil {
+		return err
+	}
+	// 远程签名后端持有 gRPC 连接
+	if closer, ok := ew.hotSigner.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	if ew.quorumClient != nil {
+		ew.quorumClient.Close()
+	}
+	ew.ethClient.Close()
 	return nil
 }
 
-func (ew *EthWallet) Stopped() bool {
-	return ew.stopped.Load()
+// Withdraw 出款流程，供接口层提交提现
+func (ew *EthWallet) Withdraw() *withdraw.Withdraw {
+	return ew.withdraw
 }
 
-func NewUserRepo(db *gorm.DB) *EthRepo {
-	return &EthRepo{
-		db:  db,
-		log: zap.S(),
+// Retry 重试队列，供接口层查看和处理死信
+func (ew *EthWallet) Retry() *retry.Retry {
+	return ew.retry
+}
+
+// KeySealer 加密待保存的地址私钥，未配置信封加密时返回 nil
+func (ew *EthWallet) KeySealer() wallet.Sealer {
+	if ew.keyring == nil {
+		return nil
 	}
+	return ew.keyring
 }
 
-// GetUserById 获取账号信息
-func (r *EthRepo) GetUserById(ctx context.Context, userId uint64) (*proto.UserInfo, error) {
-	return nil, nil
+func (ew *EthWallet) Stopped() bool {
+	return ew.stopped.Load()
 }
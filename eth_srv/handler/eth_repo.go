@@ -0,0 +1,666 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/retry"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/sequence"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/withdraw"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/0xweb-3/CoinNest/proto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	TransactionTypeDeposit  = "deposit"
+	TransactionTypeWithdraw = "withdraw"
+
+	hdAddressSequence = "hd_address"
+)
+
+var (
+	// ErrNotFound 查询的记录不存在
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidArgument 请求参数不合法
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrFailedPrecondition 记录当前状态不允许该操作
+	ErrFailedPrecondition = errors.New("failed precondition")
+)
+
+// Withdrawer 提交提现到出款流程
+type Withdrawer interface {
+	Submit(withdraw *model.Withdraw) error
+	Cancel(id uint64) error
+}
+
+// RetryQueue 运营人员处理重试任务，死信任务只能通过 Requeue 重新执行
+type RetryQueue interface {
+	Tasks(status uint8, limit int) ([]model.RetryTask, error)
+	Requeue(id uint64) error
+	Abandon(id uint64) error
+}
+
+type EthRepo struct {
+	db         *gorm.DB
+	withdrawer Withdrawer
+	retries    RetryQueue
+	tokens     map[common.Address]struct{} // 支持提现的代币，原生币始终支持
+	hdAccount  *wallet.ExtendedKey         // 为空时随机生成用户地址
+	sealer     wallet.Sealer               // 加密随机生成的用户地址私钥
+	log        *zap.SugaredLogger
+}
+
+func NewEthRepo(db *gorm.DB, withdrawer Withdrawer, retries RetryQueue, sealer wallet.Sealer, cnf config.EthConfig) (*EthRepo, error) {
+	tokens := map[common.Address]struct{}{common.HexToAddress(global_const.EthAddress): {}}
+	for _, token := range cnf.Tokens {
+		tokens[common.HexToAddress(token.Address)] = struct{}{}
+	}
+	var hdAccount *wallet.ExtendedKey
+	if cnf.HdXpub != "" {
+		key, err := wallet.ParseExtendedKey(cnf.HdXpub)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hd xpub: %w", err)
+		}
+		// 在线服务只允许持有扩展公钥
+		if key.IsPrivate() {
+			return nil, errors.New("hd_xpub must be an extended public key")
+		}
+		hdAccount = key
+	}
+	return &EthRepo{
+		db:         db,
+		withdrawer: withdrawer,
+		retries:    retries,
+		tokens:     tokens,
+		hdAccount:  hdAccount,
+		sealer:     sealer,
+		log:        zap.S(),
+	}, nil
+}
+
+// CreateAddress 为用户生成新的充值地址
+func (r *EthRepo) CreateAddress(ctx context.Context, userId uint64) (*proto.AddressInfo, error) {
+	if userId == 0 {
+		return nil, fmt.Errorf("%w: user id is required", ErrInvalidArgument)
+	}
+	if r.hdAccount != nil {
+		return r.createHDAddress(ctx, userId)
+	}
+	if r.sealer == nil {
+		return nil, errors.New("neither hd xpub nor key encryption is configured")
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	privateKey := crypto.FromECDSA(key)
+	defer clear(privateKey)
+	encrypted, err := withdraw.SealKey(r.sealer, crypto.PubkeyToAddress(key.PublicKey), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	address := model.Address{
+		UserId:      userId,
+		Address:     encrypted.Address,
+		AddressType: global_const.AddressTypeUser,
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&address).Error; err != nil {
+			return err
+		}
+		return tx.Create(encrypted).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log.Infof("address %s created for user %d", address.Address, userId)
+	return addressInfo(&address), nil
+}
+
+// createHDAddress 分配下一个派生序号并由扩展公钥派生地址，序号与地址在同一事务内写入，
+// 事务回滚时序号一并撤销，提交后的序号由唯一索引保证不会再次分配
+func (r *EthRepo) createHDAddress(ctx context.Context, userId uint64) (*proto.AddressInfo, error) {
+	var address model.Address
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for {
+			seq, err := sequence.Allocate(tx, hdAddressSequence, 1)
+			if err != nil {
+				return err
+			}
+			index := seq - 1
+			if index >= uint64(wallet.HardenedOffset) {
+				return errors.New("hd address index exhausted")
+			}
+			derived, err := wallet.DeriveAddress(r.hdAccount, uint32(index))
+			// 无效的派生序号按 BIP-32 跳过
+			if errors.Is(err, wallet.ErrInvalidChild) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			hdIndex := uint32(index)
+			address = model.Address{
+				UserId:      userId,
+				Address:     derived.String(),
+				AddressType: global_const.AddressTypeUser,
+				HdIndex:     &hdIndex,
+			}
+			return tx.Create(&address).Error
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log.Infof("address %s derived at index %d for user %d", address.Address, *address.HdIndex, userId)
+	return addressInfo(&address), nil
+}
+
+// GetAddress 按地址查询，未指定地址时返回用户最早创建的充值地址
+func (r *EthRepo) GetAddress(ctx context.Context, userId uint64, address string) (*proto.AddressInfo, error) {
+	query := r.db.WithContext(ctx)
+	switch {
+	case address != "":
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidArgument, address)
+		}
+		query = query.Where("address = ?", common.HexToAddress(address).String())
+	case userId != 0:
+		query = query.Where("user_id = ? AND address_type = ?", userId, global_const.AddressTypeUser).Order("id ASC")
+	default:
+		return nil, fmt.Errorf("%w: user id or address is required", ErrInvalidArgument)
+	}
+	var row model.Address
+	if err := query.First(&row).Error; err != nil {
+		return nil, notFound(err, "address")
+	}
+	return addressInfo(&row), nil
+}
+
+// GetBalance 查询地址已入账的余额，未指定代币时返回全部代币
+func (r *EthRepo) GetBalance(ctx context.Context, address, tokenAddress string) (*proto.GetBalanceResp, error) {
+	owner, err := parseAddress("address", address)
+	if err != nil {
+		return nil, err
+	}
+	query := r.db.WithContext(ctx).Where("address = ?", owner.String())
+	if tokenAddress != "" {
+		token, err := parseAddress("token address", tokenAddress)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("token_address = ?", token.String())
+	}
+	var balances []model.Balance
+	if err := query.Order("id ASC").Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	resp := &proto.GetBalanceResp{Address: owner.String()}
+	for _, balance := range balances {
+		resp.Balances = append(resp.Balances, &proto.TokenBalance{TokenAddress: balance.TokenAddress, Balance: balance.Balance})
+	}
+	return resp, nil
+}
+
+// SubmitWithdraw 提交提现，从默认热钱包出款，提现金额在创建时从 user_address 的余额中扣减
+func (r *EthRepo) SubmitWithdraw(ctx context.Context, req *proto.SubmitWithdrawReq) (*proto.WithdrawInfo, error) {
+	tokenAddress := req.GetTokenAddress()
+	if tokenAddress == "" {
+		tokenAddress = global_const.EthAddress
+	}
+	token, err := parseAddress("token address", tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.tokens[token]; !ok {
+		return nil, fmt.Errorf("%w: token %s is not supported", ErrInvalidArgument, token)
+	}
+	row := &model.Withdraw{
+		RequestId:    req.GetRequestId(),
+		ToAddress:    req.GetToAddress(),
+		TokenAddress: token.String(),
+		Amount:       req.GetAmount(),
+		UserAddress:  req.GetUserAddress(),
+	}
+	if err := r.withdrawer.Submit(row); err != nil {
+		if errors.Is(err, withdraw.ErrInvalidWithdraw) || errors.Is(err, withdraw.ErrInsufficientBalance) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+		}
+		return nil, err
+	}
+	return withdrawInfo(row), nil
+}
+
+// GetWithdraw 按 id 或 request_id 查询提现
+func (r *EthRepo) GetWithdraw(ctx context.Context, id uint64, requestId string) (*proto.WithdrawInfo, error) {
+	withdraw, err := r.findWithdraw(ctx, id, requestId)
+	if err != nil {
+		return nil, err
+	}
+	return withdrawInfo(withdraw), nil
+}
+
+// CancelWithdraw 取消提现，返回取消后的提现记录。已上链或已取消的提现不能取消
+func (r *EthRepo) CancelWithdraw(ctx context.Context, id uint64, requestId string) (*proto.WithdrawInfo, error) {
+	found, err := r.findWithdraw(ctx, id, requestId)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.withdrawer.Cancel(found.ID); err != nil {
+		if errors.Is(err, withdraw.ErrWithdrawNotPending) {
+			return nil, fmt.Errorf("%w: withdraw %d is not pending", ErrFailedPrecondition, found.ID)
+		}
+		return nil, err
+	}
+	r.log.Infof("withdraw %d cancelled by operator", found.ID)
+	return r.GetWithdraw(ctx, found.ID, "")
+}
+
+func (r *EthRepo) findWithdraw(ctx context.Context, id uint64, requestId string) (*model.Withdraw, error) {
+	query := r.db.WithContext(ctx)
+	switch {
+	case id != 0:
+		query = query.Where("id = ?", id)
+	case requestId != "":
+		query = query.Where("request_id = ?", requestId)
+	default:
+		return nil, fmt.Errorf("%w: id or request id is required", ErrInvalidArgument)
+	}
+	var withdraw model.Withdraw
+	if err := query.First(&withdraw).Error; err != nil {
+		return nil, notFound(err, "withdraw")
+	}
+	return &withdraw, nil
+}
+
+// ListDeposits 按 ID 倒序分页查询地址的充值，ID 随创建时间递增，游标为上一页最后一条的 ID
+func (r *EthRepo) ListDeposits(ctx context.Context, req *proto.ListDepositsReq) (*proto.ListDepositsResp, error) {
+	owner, err := parseAddress("address", req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := parseCursor(req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetLimit())
+
+	query := r.db.WithContext(ctx).Where("to_address = ?", owner.String())
+	if req.GetTokenAddress() != "" {
+		token, err := parseAddress("token address", req.GetTokenAddress())
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("token_address = ?", token.String())
+	}
+	if cursor != 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	var deposits []model.Deposit
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&deposits).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &proto.ListDepositsResp{}
+	if len(deposits) > limit {
+		deposits = deposits[:limit]
+		resp.NextCursor = strconv.FormatUint(deposits[limit-1].ID, 10)
+	}
+	for i := range deposits {
+		resp.Deposits = append(resp.Deposits, depositInfo(&deposits[i]))
+	}
+	return resp, nil
+}
+
+// ListTransactions 按 ID 倒序分页查询地址相关的充值和提现，两张表各取一页后合并
+func (r *EthRepo) ListTransactions(ctx context.Context, req *proto.ListTransactionsReq) (*proto.ListTransactionsResp, error) {
+	owner, err := parseAddress("address", req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := parseCursor(req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetLimit())
+
+	depositQuery := r.db.WithContext(ctx).Where("to_address = ?", owner.String())
+	withdrawQuery := r.db.WithContext(ctx).
+		Where("withdraw_type = ?", global_const.WithdrawTypeUser).
+		Where("from_address = ? OR to_address = ?", owner.String(), owner.String())
+	if cursor != 0 {
+		depositQuery = depositQuery.Where("id < ?", cursor)
+		withdrawQuery = withdrawQuery.Where("id < ?", cursor)
+	}
+	var deposits []model.Deposit
+	if err := depositQuery.Order("id DESC").Limit(limit + 1).Find(&deposits).Error; err != nil {
+		return nil, err
+	}
+	var withdraws []model.Withdraw
+	if err := withdrawQuery.Order("id DESC").Limit(limit + 1).Find(&withdraws).Error; err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*proto.TransactionInfo, 0, len(deposits)+len(withdraws))
+	for i := range deposits {
+		transactions = append(transactions, depositTransaction(&deposits[i]))
+	}
+	for i := range withdraws {
+		transactions = append(transactions, withdrawTransaction(&withdraws[i]))
+	}
+	return pageTransactions(transactions, limit), nil
+}
+
+// EventsAfter 按序号返回 cursor 之后的充值和提现事件，游标为最后一条事件的序号
+func (r *EthRepo) EventsAfter(ctx context.Context, cursor string, eventTypes []string, limit int) ([]*proto.Event, error) {
+	seq, err := parseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	types, err := parseEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	query := r.db.WithContext(ctx).Where("seq > ?", seq)
+	if len(types) > 0 {
+		query = query.Where("event_type IN ?", types)
+	}
+	var events []model.Event
+	if err := query.Order("seq ASC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*proto.Event, 0, len(events))
+	for i := range events {
+		result = append(result, eventInfo(&events[i]))
+	}
+	return result, nil
+}
+
+// ListWebhookDeliveries 按 ID 倒序分页查询订阅方的回调投递记录
+func (r *EthRepo) ListWebhookDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesReq) (*proto.ListWebhookDeliveriesResp, error) {
+	if req.GetSubscriber() == "" {
+		return nil, fmt.Errorf("%w: subscriber is required", ErrInvalidArgument)
+	}
+	cursor, err := parseCursor(req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+	limit := pageSize(req.GetLimit())
+
+	query := r.db.WithContext(ctx).Where("subscriber = ?", req.GetSubscriber())
+	if req.GetStatus() != 0 {
+		query = query.Where("status = ?", req.GetStatus())
+	}
+	if req.GetRefId() != 0 {
+		query = query.Where("ref_id = ?", req.GetRefId())
+	}
+	if cursor != 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	var deliveries []model.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &proto.ListWebhookDeliveriesResp{}
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		resp.NextCursor = strconv.FormatUint(deliveries[limit-1].ID, 10)
+	}
+	for i := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, webhookDeliveryInfo(&deliveries[i]))
+	}
+	return resp, nil
+}
+
+// ListRetryTasks 按状态列出重试任务，未指定状态时列出死信
+func (r *EthRepo) ListRetryTasks(ctx context.Context, req *proto.ListRetryTasksReq) (*proto.ListRetryTasksResp, error) {
+	status := uint8(global_const.RetryStatusDead)
+	if req.GetStatus() != 0 {
+		if req.GetStatus() > global_const.RetryStatusAbandoned {
+			return nil, fmt.Errorf("%w: invalid retry status %d", ErrInvalidArgument, req.GetStatus())
+		}
+		status = uint8(req.GetStatus())
+	}
+	tasks, err := r.retries.Tasks(status, pageSize(req.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.ListRetryTasksResp{}
+	for i := range tasks {
+		resp.Tasks = append(resp.Tasks, retryTaskInfo(&tasks[i]))
+	}
+	return resp, nil
+}
+
+// RequeueRetryTask 把死信或已放弃的任务放回队列立即重试
+func (r *EthRepo) RequeueRetryTask(ctx context.Context, id uint64) (*proto.RetryTask, error) {
+	return r.updateRetryTask(ctx, id, "requeued", r.retries.Requeue)
+}
+
+// AbandonRetryTask 放弃等待重试或死信中的任务
+func (r *EthRepo) AbandonRetryTask(ctx context.Context, id uint64) (*proto.RetryTask, error) {
+	return r.updateRetryTask(ctx, id, "abandoned", r.retries.Abandon)
+}
+
+func (r *EthRepo) updateRetryTask(ctx context.Context, id uint64, action string, update func(id uint64) error) (*proto.RetryTask, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidArgument)
+	}
+	var task model.RetryTask
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		return nil, notFound(err, "retry task")
+	}
+	if err := update(id); err != nil {
+		if errors.Is(err, retry.ErrTaskState) {
+			return nil, fmt.Errorf("%w: retry task %d cannot be %s in status %d", ErrFailedPrecondition, id, action, task.Status)
+		}
+		return nil, err
+	}
+	r.log.Infof("retry task %d (%s %d) %s by operator", id, task.Kind, task.RefId, action)
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		return nil, err
+	}
+	return retryTaskInfo(&task), nil
+}
+
+// parseEventTypes 校验订阅的事件类型并去重，为空表示全部类型
+func parseEventTypes(eventTypes []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(eventTypes))
+	types := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if eventType != global_const.EventTypeDeposit && eventType != global_const.EventTypeWithdraw {
+			return nil, fmt.Errorf("%w: invalid event type %q", ErrInvalidArgument, eventType)
+		}
+		if _, ok := seen[eventType]; ok {
+			continue
+		}
+		seen[eventType] = struct{}{}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
+
+// pageTransactions 合并后按 ID 倒序取一页，还有剩余时以本页最后一条的 ID 作为下一页游标
+func pageTransactions(transactions []*proto.TransactionInfo, limit int) *proto.ListTransactionsResp {
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Id > transactions[j].Id
+	})
+	resp := &proto.ListTransactionsResp{}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		resp.NextCursor = strconv.FormatUint(transactions[limit-1].Id, 10)
+	}
+	resp.Transactions = transactions
+	return resp
+}
+
+func parseAddress(name, address string) (common.Address, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("%w: invalid %s %q", ErrInvalidArgument, name, address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+func parseCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid cursor %q", ErrInvalidArgument, cursor)
+	}
+	return id, nil
+}
+
+func pageSize(limit uint32) int {
+	if limit == 0 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return int(limit)
+}
+
+func notFound(err error, name string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return err
+}
+
+func addressInfo(address *model.Address) *proto.AddressInfo {
+	return &proto.AddressInfo{
+		UserId:      address.UserId,
+		Address:     address.Address,
+		AddressType: uint32(address.AddressType),
+		CreatedAt:   address.CreatedAt.Unix(),
+	}
+}
+
+func withdrawInfo(withdraw *model.Withdraw) *proto.WithdrawInfo {
+	return &proto.WithdrawInfo{
+		Id:           withdraw.ID,
+		RequestId:    withdraw.RequestId,
+		FromAddress:  withdraw.FromAddress,
+		ToAddress:    withdraw.ToAddress,
+		TokenAddress: withdraw.TokenAddress,
+		Amount:       withdraw.Amount,
+		UserAddress:  withdraw.UserAddress,
+		Status:       uint32(withdraw.Status),
+		TxHash:       withdraw.TxHash,
+		BlockNumber:  withdraw.BlockNumber,
+		ErrorMessage: withdraw.ErrorMessage,
+		CreatedAt:    withdraw.CreatedAt.Unix(),
+	}
+}
+
+func depositInfo(deposit *model.Deposit) *proto.DepositInfo {
+	return &proto.DepositInfo{
+		Id:            deposit.ID,
+		TxHash:        deposit.TxHash,
+		BlockNumber:   deposit.BlockNumber,
+		FromAddress:   deposit.FromAddress,
+		ToAddress:     deposit.ToAddress,
+		TokenAddress:  deposit.TokenAddress,
+		Amount:        deposit.Amount,
+		Status:        uint32(deposit.Status),
+		Confirmations: deposit.Confirmations,
+		CreatedAt:     deposit.CreatedAt.Unix(),
+	}
+}
+
+func depositTransaction(deposit *model.Deposit) *proto.TransactionInfo {
+	return &proto.TransactionInfo{
+		Id:           deposit.ID,
+		Type:         TransactionTypeDeposit,
+		TxHash:       deposit.TxHash,
+		BlockNumber:  deposit.BlockNumber,
+		FromAddress:  deposit.FromAddress,
+		ToAddress:    deposit.ToAddress,
+		TokenAddress: deposit.TokenAddress,
+		Amount:       deposit.Amount,
+		Status:       uint32(deposit.Status),
+		CreatedAt:    deposit.CreatedAt.Unix(),
+	}
+}
+
+func withdrawTransaction(withdraw *model.Withdraw) *proto.TransactionInfo {
+	return &proto.TransactionInfo{
+		Id:           withdraw.ID,
+		Type:         TransactionTypeWithdraw,
+		TxHash:       withdraw.TxHash,
+		BlockNumber:  withdraw.BlockNumber,
+		FromAddress:  withdraw.FromAddress,
+		ToAddress:    withdraw.ToAddress,
+		TokenAddress: withdraw.TokenAddress,
+		Amount:       withdraw.Amount,
+		Status:       uint32(withdraw.Status),
+		CreatedAt:    withdraw.CreatedAt.Unix(),
+	}
+}
+
+func eventInfo(event *model.Event) *proto.Event {
+	return &proto.Event{
+		Cursor:       strconv.FormatUint(event.Seq, 10),
+		EventType:    event.EventType,
+		RefId:        event.RefId,
+		RequestId:    event.RequestId,
+		Status:       uint32(event.Status),
+		TxHash:       event.TxHash,
+		BlockNumber:  event.BlockNumber,
+		Address:      event.Address,
+		FromAddress:  event.FromAddress,
+		ToAddress:    event.ToAddress,
+		TokenAddress: event.TokenAddress,
+		Amount:       event.Amount,
+		CreatedAt:    event.CreatedAt.Unix(),
+	}
+}
+
+func retryTaskInfo(task *model.RetryTask) *proto.RetryTask {
+	return &proto.RetryTask{
+		Id:          task.ID,
+		Kind:        task.Kind,
+		RefId:       task.RefId,
+		Status:      uint32(task.Status),
+		Attempts:    task.Attempts,
+		MaxAttempts: task.MaxAttempts,
+		NextRunAt:   task.NextRunAt.Unix(),
+		LastError:   task.LastError,
+		CreatedAt:   task.CreatedAt.Unix(),
+	}
+}
+
+func webhookDeliveryInfo(delivery *model.WebhookDelivery) *proto.WebhookDelivery {
+	info := &proto.WebhookDelivery{
+		Id:           delivery.ID,
+		Subscriber:   delivery.Subscriber,
+		EventCursor:  strconv.FormatUint(delivery.EventSeq, 10),
+		EventType:    delivery.EventType,
+		RefId:        delivery.RefId,
+		Url:          delivery.Url,
+		Status:       uint32(delivery.Status),
+		Attempts:     delivery.Attempts,
+		ResponseCode: int32(delivery.ResponseCode),
+		ErrorMessage: delivery.ErrorMessage,
+		CreatedAt:    delivery.CreatedAt.Unix(),
+	}
+	if delivery.DeliveredAt != nil {
+		info.DeliveredAt = delivery.DeliveredAt.Unix()
+	}
+	return info
+}
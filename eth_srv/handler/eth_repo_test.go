@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/proto"
+)
+
+func TestPageTransactions(t *testing.T) {
+	var transactions []*proto.TransactionInfo
+	for _, id := range []uint64{3, 9, 5, 7, 1} {
+		transactions = append(transactions, &proto.TransactionInfo{Id: id})
+	}
+
+	page := pageTransactions(transactions, 3)
+	if len(page.Transactions) != 3 || page.NextCursor != "5" {
+		t.Fatalf("unexpected page %v next %q", page.Transactions, page.NextCursor)
+	}
+	for i, want := range []uint64{9, 7, 5} {
+		if page.Transactions[i].Id != want {
+			t.Fatalf("transaction %d: id %d, want %d", i, page.Transactions[i].Id, want)
+		}
+	}
+
+	last := pageTransactions([]*proto.TransactionInfo{{Id: 3}, {Id: 1}}, 3)
+	if len(last.Transactions) != 2 || last.NextCursor != "" {
+		t.Fatalf("last page should have no cursor, got %q", last.NextCursor)
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	if id, err := parseCursor(""); err != nil || id != 0 {
+		t.Fatalf("empty cursor should start from the newest, got %d %v", id, err)
+	}
+	if id, err := parseCursor("42"); err != nil || id != 42 {
+		t.Fatalf("unexpected cursor %d %v", id, err)
+	}
+	if _, err := parseCursor("abc"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+	if pageSize(0) != defaultPageSize || pageSize(1000) != maxPageSize || pageSize(5) != 5 {
+		t.Fatal("unexpected page size")
+	}
+}
+
+func TestParseEventTypes(t *testing.T) {
+	types, err := parseEventTypes([]string{"withdraw", "deposit", "withdraw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != 2 || types[0] != "withdraw" || types[1] != "deposit" {
+		t.Fatalf("unexpected event types %v", types)
+	}
+	if types, err := parseEventTypes(nil); err != nil || len(types) != 0 {
+		t.Fatalf("empty event types: %v %v", types, err)
+	}
+	if _, err := parseEventTypes([]string{"sweep"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package event
+
+import (
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/sequence"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+)
+
+const eventSequence = "event"
+
+// Append 在事务 tx 中为事件分配序号并写入，调用方需要在产生状态变更的同一事务内调用，
+// 序号的提交顺序与分配顺序一致
+func Append(tx *gorm.DB, events ...*model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	seq, err := sequence.Allocate(tx, eventSequence, uint64(len(events)))
+	if err != nil {
+		return err
+	}
+	for i, event := range events {
+		event.Seq = seq + uint64(i)
+	}
+	return tx.Create(events).Error
+}
+
+// DepositEvent 充值状态变更事件
+func DepositEvent(deposit *model.Deposit) *model.Event {
+	return &model.Event{
+		EventType:    global_const.EventTypeDeposit,
+		RefId:        deposit.ID,
+		Status:       deposit.Status,
+		TxHash:       deposit.TxHash,
+		Address:      deposit.ToAddress,
+		FromAddress:  deposit.FromAddress,
+		ToAddress:    deposit.ToAddress,
+		TokenAddress: deposit.TokenAddress,
+		Amount:       deposit.Amount,
+		BlockNumber:  deposit.BlockNumber,
+	}
+}
+
+// WithdrawEvent 提现状态变更事件
+func WithdrawEvent(withdraw *model.Withdraw) *model.Event {
+	return &model.Event{
+		EventType:    global_const.EventTypeWithdraw,
+		RefId:        withdraw.ID,
+		RequestId:    withdraw.RequestId,
+		Status:       withdraw.Status,
+		TxHash:       withdraw.TxHash,
+		Address:      withdraw.FromAddress,
+		FromAddress:  withdraw.FromAddress,
+		ToAddress:    withdraw.ToAddress,
+		TokenAddress: withdraw.TokenAddress,
+		Amount:       withdraw.Amount,
+		BlockNumber:  withdraw.BlockNumber,
+	}
+}
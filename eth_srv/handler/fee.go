@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+)
+
+// newFeeOracle 按配置创建手续费估算
+func newFeeOracle(client node.EthClient, chainId uint64, capability global_const.TxCapability, cnf config.FeeConfig) (*fee.Oracle, error) {
+	tier, err := fee.ParseTier(cnf.Tier)
+	if err != nil {
+		return nil, err
+	}
+	maxFeeCap, err := parseOptionalWei("max_fee_cap", cnf.MaxFeeCap)
+	if err != nil {
+		return nil, err
+	}
+	maxTipCap, err := parseOptionalWei("max_tip_cap", cnf.MaxTipCap)
+	if err != nil {
+		return nil, err
+	}
+	return fee.NewOracle(client, fee.OracleConfig{
+		ChainId:    chainId,
+		Capability: capability,
+		L1Fee:      fee.NewL1FeeModel(client, chainId),
+		Tier:       tier,
+		BlockCount: cnf.BlockCount,
+		MaxFeeCap:  maxFeeCap,
+		MaxTipCap:  maxTipCap,
+		GasMargin:  cnf.GasMargin,
+	}), nil
+}
+
+func parseOptionalWei(name, value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	wei, ok := new(big.Int).SetString(value, 10)
+	if !ok || wei.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return wei, nil
+}
@@ -0,0 +1,299 @@
+package fee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	NativeTransferGasLimit = 21000
+	Erc20TransferGasLimit  = 100000
+
+	defaultBlockCount = 20
+	defaultGasMargin  = 20
+)
+
+// maxTransferValue 估算数据费时使用的转账金额，占满 32 字节，数据费不会被低估
+var maxTransferValue = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ErrFeeAboveCap 当前手续费超过链配置的上限，交易应等待手续费回落后再发送
+var ErrFeeAboveCap = errors.New("fee above cap")
+
+// ErrEstimateRejected 节点执行估算的交易时回滚，按默认 gas 发送也会失败
+var ErrEstimateRejected = errors.New("gas estimation rejected")
+
+// Tier 手续费档位
+type Tier uint8
+
+const (
+	TierSlow Tier = iota + 1
+	TierNormal
+	TierFast
+)
+
+// tierPercentiles 各档位对应 eth_feeHistory 的小费分位
+var tierPercentiles = []float64{10, 50, 90}
+
+// legacyTierPercent 不支持 EIP-1559 的链按节点建议 gasPrice 的百分比分档
+var legacyTierPercent = map[Tier]int64{
+	TierSlow:   90,
+	TierNormal: 100,
+	TierFast:   125,
+}
+
+// ParseTier 解析配置文件中的手续费档位
+func ParseTier(tier string) (Tier, error) {
+	switch tier {
+	case "slow":
+		return TierSlow, nil
+	case "", "normal":
+		return TierNormal, nil
+	case "fast":
+		return TierFast, nil
+	default:
+		return 0, fmt.Errorf("unknown fee tier %q", tier)
+	}
+}
+
+// Fees 交易的费用参数，不支持 EIP-1559 的链两者都等于 gasPrice
+type Fees struct {
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+type OracleConfig struct {
+	ChainId    uint64
+	Capability global_const.TxCapability
+	L1Fee      L1FeeModel // rollup 链的 L1 数据费，nil 表示不单独收取
+	Tier       Tier       // 出款和归集默认使用的档位
+	BlockCount uint64     // eth_feeHistory 采样的区块数
+	MaxFeeCap  *big.Int   // maxFeePerGas（legacy 为 gasPrice）上限，nil 表示不限制
+	MaxTipCap  *big.Int   // maxPriorityFeePerGas 上限，nil 表示不限制
+	GasMargin  uint64     // 估算 gas 的安全余量百分比
+}
+
+// Oracle 根据近期区块的手续费分位给出各档位手续费，并按链配置的上限封顶
+type Oracle struct {
+	client     node.EthClient
+	chainId    *big.Int
+	capability global_const.TxCapability
+	l1Fee      L1FeeModel
+	tier       Tier
+	blockCount uint64
+	maxFeeCap  *big.Int
+	maxTipCap  *big.Int
+	gasMargin  uint64
+}
+
+func NewOracle(client node.EthClient, cfg OracleConfig) *Oracle {
+	if cfg.Tier == 0 {
+		cfg.Tier = TierNormal
+	}
+	if cfg.BlockCount == 0 {
+		cfg.BlockCount = defaultBlockCount
+	}
+	if cfg.GasMargin == 0 {
+		cfg.GasMargin = defaultGasMargin
+	}
+	return &Oracle{
+		client:     client,
+		chainId:    new(big.Int).SetUint64(cfg.ChainId),
+		capability: cfg.Capability,
+		l1Fee:      cfg.L1Fee,
+		tier:       cfg.Tier,
+		blockCount: cfg.BlockCount,
+		maxFeeCap:  cfg.MaxFeeCap,
+		maxTipCap:  cfg.MaxTipCap,
+		gasMargin:  cfg.GasMargin,
+	}
+}
+
+// Fees 默认档位的手续费
+func (o *Oracle) Fees(ctx context.Context) (*Fees, error) {
+	return o.SuggestFees(ctx, o.tier)
+}
+
+// SuggestFees 返回指定档位的手续费。EIP-1559 链的小费取近期区块对应分位小费的中位数，
+// maxFeePerGas 取两倍下一个区块的 baseFee 加小费，可以承受连续几个区块的 baseFee 上涨
+func (o *Oracle) SuggestFees(ctx context.Context, tier Tier) (*Fees, error) {
+	if tier < TierSlow || tier > TierFast {
+		return nil, fmt.Errorf("unknown fee tier %d", tier)
+	}
+	if !o.capability.DynamicFee {
+		price, err := o.client.SuggestGasPriceContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to suggest gas price: %w", err)
+		}
+		price = new(big.Int).Div(new(big.Int).Mul(price, big.NewInt(legacyTierPercent[tier])), big.NewInt(100))
+		if o.maxFeeCap != nil && price.Cmp(o.maxFeeCap) > 0 {
+			return nil, fmt.Errorf("%w: gas price %s exceeds %s", ErrFeeAboveCap, price, o.maxFeeCap)
+		}
+		return &Fees{GasTipCap: price, GasFeeCap: new(big.Int).Set(price)}, nil
+	}
+
+	history, err := o.client.FeeHistoryContext(ctx, o.blockCount, nil, tierPercentiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 || history.BaseFee[len(history.BaseFee)-1] == nil {
+		return nil, errors.New("chain does not support EIP-1559")
+	}
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	tip := medianReward(history.Reward, int(tier-TierSlow))
+	if tip == nil {
+		// 近期区块没有交易时退回节点建议的小费
+		if tip, err = o.client.SuggestGasTipCapContext(ctx); err != nil {
+			return nil, fmt.Errorf("unable to suggest gas tip cap: %w", err)
+		}
+	}
+	return o.capFees(baseFee, tip)
+}
+
+// capFees 按上限调整手续费：小费超过上限时取上限；下一个区块的 baseFee 加小费超过 maxFeePerGas 上限时
+// 返回 ErrFeeAboveCap，否则 maxFeePerGas 不超过上限
+func (o *Oracle) capFees(baseFee, tip *big.Int) (*Fees, error) {
+	if o.maxTipCap != nil && tip.Cmp(o.maxTipCap) > 0 {
+		tip = new(big.Int).Set(o.maxTipCap)
+	}
+	feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	if o.maxFeeCap != nil {
+		if required := new(big.Int).Add(baseFee, tip); required.Cmp(o.maxFeeCap) > 0 {
+			return nil, fmt.Errorf("%w: base fee %s plus tip %s exceeds %s", ErrFeeAboveCap, baseFee, tip, o.maxFeeCap)
+		}
+		if feeCap.Cmp(o.maxFeeCap) > 0 {
+			feeCap = new(big.Int).Set(o.maxFeeCap)
+		}
+	}
+	return &Fees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// CheckCap 校验替换交易提价后的手续费没有超过上限
+func (o *Oracle) CheckCap(fees *Fees) error {
+	if o.maxFeeCap != nil && fees.GasFeeCap.Cmp(o.maxFeeCap) > 0 {
+		return fmt.Errorf("%w: fee cap %s exceeds %s", ErrFeeAboveCap, fees.GasFeeCap, o.maxFeeCap)
+	}
+	if o.capability.DynamicFee && o.maxTipCap != nil && fees.GasTipCap.Cmp(o.maxTipCap) > 0 {
+		return fmt.Errorf("%w: tip cap %s exceeds %s", ErrFeeAboveCap, fees.GasTipCap, o.maxTipCap)
+	}
+	return nil
+}
+
+// medianReward 各区块第 index 个分位小费的中位数，没有数据时返回 nil
+func medianReward(rewards [][]*big.Int, index int) *big.Int {
+	samples := make([]*big.Int, 0, len(rewards))
+	for _, reward := range rewards {
+		if index < len(reward) && reward[index] != nil {
+			samples = append(samples, reward[index])
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Cmp(samples[j]) < 0 })
+	return new(big.Int).Set(samples[len(samples)/2])
+}
+
+// GasLimit 估算交易的 gas 并加上安全余量。节点返回执行回滚说明交易本身无法执行，
+// 返回 ErrEstimateRejected；网络错误、超时、限流和节点内部错误都使用 fallback。
+// 恰好为 21000 的估算值是普通转账，执行消耗固定，不加余量
+func (o *Oracle) GasLimit(ctx context.Context, msg ethereum.CallMsg, fallback uint64) (uint64, error) {
+	gas, err := o.client.EstimateGasContext(ctx, msg)
+	if err != nil {
+		if isExecutionError(err) {
+			return 0, fmt.Errorf("%w: %w", ErrEstimateRejected, err)
+		}
+		log.Warn("estimate gas failed, use default gas limit", "from", msg.From, "to", msg.To, "gas", fallback, "err", err)
+		return fallback, nil
+	}
+	if gas == NativeTransferGasLimit {
+		return gas, nil
+	}
+	return gas + gas*o.gasMargin/100, nil
+}
+
+// executionRevertedCode 节点执行交易回滚时返回的 JSON-RPC 错误码
+const executionRevertedCode = 3
+
+// isExecutionError 交易执行回滚，错误码为 3 或消息为 execution reverted。
+// 其他 JSON-RPC 错误（如 -32005 限流、-32603 节点内部错误）是暂时性的，不代表交易无法执行
+func isExecutionError(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == executionRevertedCode {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// TransferGasLimit 估算原生币或 ERC-20 转账的 gas
+func (o *Oracle) TransferGasLimit(ctx context.Context, from, token, to common.Address, amount *big.Int) (uint64, error) {
+	if token == common.HexToAddress(global_const.EthAddress) {
+		return o.GasLimit(ctx, ethereum.CallMsg{From: from, To: &to, Value: amount}, NativeTransferGasLimit)
+	}
+	data, err := wallet.Erc20TransferData(to, amount)
+	if err != nil {
+		return 0, err
+	}
+	return o.GasLimit(ctx, ethereum.CallMsg{From: from, To: &token, Data: data}, Erc20TransferGasLimit)
+}
+
+// L1Fee 交易在 rollup 链上需要额外支付的 L1 数据费，不单独收取数据费的链返回 0
+func (o *Oracle) L1Fee(ctx context.Context, tx *types.Transaction) (*big.Int, error) {
+	if o.l1Fee == nil {
+		return new(big.Int), nil
+	}
+	unsigned, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return o.l1Fee.L1Fee(ctx, unsigned)
+}
+
+// MaxTransferFee 按默认档位手续费估算一笔原生币或 ERC-20 转账最多消耗的手续费：
+// gasLimit * maxFeePerGas，rollup 链再加上同样大小交易的 L1 数据费
+func (o *Oracle) MaxTransferFee(ctx context.Context, token common.Address) (*big.Int, error) {
+	fees, err := o.Fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := &wallet.TxRequest{
+		ChainId:   o.chainId,
+		Nonce:     math.MaxUint32,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Gas:       NativeTransferGasLimit,
+		To:        &token,
+		Value:     maxTransferValue,
+	}
+	if token != common.HexToAddress(global_const.EthAddress) {
+		// 数据费只与交易长度和内容有关，用最大金额的转账估算
+		data, err := wallet.Erc20TransferData(token, maxTransferValue)
+		if err != nil {
+			return nil, err
+		}
+		req.Gas, req.Value, req.Data = Erc20TransferGasLimit, new(big.Int), data
+	}
+	total := new(big.Int).Mul(fees.GasFeeCap, new(big.Int).SetUint64(req.Gas))
+	tx, err := wallet.NewTx(req, o.capability)
+	if err != nil {
+		return nil, err
+	}
+	l1Fee, err := o.L1Fee(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return total.Add(total, l1Fee), nil
+}
@@ -0,0 +1,250 @@
+package fee
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type fakeClient struct {
+	node.EthClient
+	history  *ethereum.FeeHistory
+	gasPrice *big.Int
+	tipCap   *big.Int
+	estimate uint64
+	estErr   error
+	calls    []ethereum.CallMsg
+	l1Fee    *big.Int
+}
+
+func (c *fakeClient) CallContractContext(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	c.calls = append(c.calls, msg)
+	return common.LeftPadBytes(c.l1Fee.Bytes(), 32), nil
+}
+
+func (c *fakeClient) FeeHistoryContext(context.Context, uint64, *big.Int, []float64) (*ethereum.FeeHistory, error) {
+	return c.history, nil
+}
+
+func (c *fakeClient) SuggestGasPriceContext(context.Context) (*big.Int, error) {
+	return c.gasPrice, nil
+}
+
+func (c *fakeClient) SuggestGasTipCapContext(context.Context) (*big.Int, error) {
+	return c.tipCap, nil
+}
+
+func (c *fakeClient) EstimateGasContext(context.Context, ethereum.CallMsg) (uint64, error) {
+	return c.estimate, c.estErr
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func rewards(values ...[3]int64) [][]*big.Int {
+	out := make([][]*big.Int, 0, len(values))
+	for _, v := range values {
+		out = append(out, []*big.Int{gwei(v[0]), gwei(v[1]), gwei(v[2])})
+	}
+	return out
+}
+
+func TestSuggestFees(t *testing.T) {
+	client := &fakeClient{history: &ethereum.FeeHistory{
+		Reward:  rewards([3]int64{1, 2, 9}, [3]int64{1, 3, 5}, [3]int64{2, 2, 50}),
+		BaseFee: []*big.Int{gwei(8), gwei(9), gwei(10), gwei(11)},
+	}}
+	oracle := NewOracle(client, OracleConfig{Capability: global_const.TxCapabilityOf(global_const.EthereumChainId)})
+
+	tests := []struct {
+		tier    Tier
+		wantTip int64
+	}{
+		{tier: TierSlow, wantTip: 1},
+		{tier: TierNormal, wantTip: 2},
+		{tier: TierFast, wantTip: 9},
+	}
+	for _, tt := range tests {
+		fees, err := oracle.SuggestFees(context.Background(), tt.tier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// maxFeePerGas 为两倍下一个区块的 baseFee（11 gwei）加小费
+		if fees.GasTipCap.Cmp(gwei(tt.wantTip)) != 0 || fees.GasFeeCap.Cmp(gwei(22+tt.wantTip)) != 0 {
+			t.Fatalf("tier %d: unexpected fees %s %s", tt.tier, fees.GasTipCap, fees.GasFeeCap)
+		}
+	}
+
+	// 近期区块没有小费数据时使用节点建议值
+	client.history.Reward = nil
+	client.tipCap = gwei(3)
+	fees, err := oracle.Fees(context.Background())
+	if err != nil || fees.GasTipCap.Cmp(gwei(3)) != 0 {
+		t.Fatalf("unexpected fallback fees %+v %v", fees, err)
+	}
+}
+
+func TestSuggestFeesCap(t *testing.T) {
+	client := &fakeClient{history: &ethereum.FeeHistory{
+		Reward:  rewards([3]int64{1, 2, 9}),
+		BaseFee: []*big.Int{gwei(10), gwei(10)},
+	}}
+	capability := global_const.TxCapabilityOf(global_const.EthereumChainId)
+
+	// 上限高于 baseFee + 小费时只压低 maxFeePerGas
+	oracle := NewOracle(client, OracleConfig{Capability: capability, MaxFeeCap: gwei(15), MaxTipCap: gwei(4)})
+	fees, err := oracle.SuggestFees(context.Background(), TierFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasTipCap.Cmp(gwei(4)) != 0 || fees.GasFeeCap.Cmp(gwei(15)) != 0 {
+		t.Fatalf("unexpected capped fees %s %s", fees.GasTipCap, fees.GasFeeCap)
+	}
+
+	// baseFee 飙升到上限以上时不发送交易
+	client.history.BaseFee = []*big.Int{gwei(10), gwei(14)}
+	if _, err := oracle.SuggestFees(context.Background(), TierNormal); !errors.Is(err, ErrFeeAboveCap) {
+		t.Fatalf("expected fee above cap, got %v", err)
+	}
+
+	if err := oracle.CheckCap(&Fees{GasTipCap: gwei(4), GasFeeCap: gwei(16)}); !errors.Is(err, ErrFeeAboveCap) {
+		t.Fatalf("expected fee above cap, got %v", err)
+	}
+	if err := oracle.CheckCap(&Fees{GasTipCap: gwei(4), GasFeeCap: gwei(15)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSuggestFeesLegacy(t *testing.T) {
+	client := &fakeClient{gasPrice: gwei(100)}
+	oracle := NewOracle(client, OracleConfig{Capability: global_const.TxCapabilityOf(global_const.OkxChainId), MaxFeeCap: gwei(110)})
+
+	slow, err := oracle.SuggestFees(context.Background(), TierSlow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slow.GasFeeCap.Cmp(gwei(90)) != 0 || slow.GasTipCap.Cmp(slow.GasFeeCap) != 0 {
+		t.Fatalf("unexpected legacy fees %s %s", slow.GasTipCap, slow.GasFeeCap)
+	}
+	if _, err := oracle.SuggestFees(context.Background(), TierFast); !errors.Is(err, ErrFeeAboveCap) {
+		t.Fatalf("expected fee above cap, got %v", err)
+	}
+}
+
+func TestTransferGasLimit(t *testing.T) {
+	client := &fakeClient{estimate: 21000}
+	oracle := NewOracle(client, OracleConfig{GasMargin: 25})
+	from := common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	token := common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+
+	gas, err := oracle.TransferGasLimit(context.Background(), from, common.HexToAddress(global_const.EthAddress), token, big.NewInt(1))
+	if err != nil || gas != NativeTransferGasLimit {
+		t.Fatalf("plain transfer should not get a margin, got %d %v", gas, err)
+	}
+
+	client.estimate = 40000
+	if gas, _ := oracle.TransferGasLimit(context.Background(), from, token, from, big.NewInt(1)); gas != 50000 {
+		t.Fatalf("expected 25%% margin, got %d", gas)
+	}
+
+	// 网络错误使用默认值
+	client.estErr = errors.New("connection refused")
+	if gas, err := oracle.TransferGasLimit(context.Background(), from, token, from, big.NewInt(1)); err != nil || gas != Erc20TransferGasLimit {
+		t.Fatalf("expected default erc20 gas limit, got %d %v", gas, err)
+	}
+
+	// 限流和节点内部错误是暂时性的，同样使用默认值
+	for _, estErr := range []error{
+		rpcError{code: -32005, message: "limit exceeded"},
+		rpcError{code: -32603, message: "internal error"},
+	} {
+		client.estErr = estErr
+		if gas, err := oracle.TransferGasLimit(context.Background(), from, token, from, big.NewInt(1)); err != nil || gas != Erc20TransferGasLimit {
+			t.Fatalf("%v: expected default erc20 gas limit, got %d %v", estErr, gas, err)
+		}
+	}
+
+	// 节点返回执行回滚时交易无法上链，不能用默认值签名
+	for _, estErr := range []error{
+		revertError{},
+		rpcError{code: -32000, message: "execution reverted"},
+	} {
+		client.estErr = estErr
+		if _, err := oracle.TransferGasLimit(context.Background(), from, token, from, big.NewInt(1)); !errors.Is(err, ErrEstimateRejected) {
+			t.Fatalf("%v: expected rejected estimate, got %v", estErr, err)
+		}
+	}
+}
+
+// rpcError 模拟节点返回的 JSON-RPC 错误
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string  { return e.message }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// revertError 模拟节点返回的执行回滚错误
+type revertError struct{}
+
+func (revertError) Error() string {
+	return "execution reverted: ERC20: transfer amount exceeds balance"
+}
+func (revertError) ErrorCode() int         { return 3 }
+func (revertError) ErrorData() interface{} { return "0x08c379a0" }
+
+func TestMaxTransferFeeWithL1Fee(t *testing.T) {
+	client := &fakeClient{
+		history: &ethereum.FeeHistory{Reward: rewards([3]int64{1, 1, 1}), BaseFee: []*big.Int{gwei(1), gwei(1)}},
+		l1Fee:   big.NewInt(7e12),
+	}
+	oracle := NewOracle(client, OracleConfig{
+		ChainId:    global_const.BaseChainId,
+		Capability: global_const.TxCapabilityOf(global_const.BaseChainId),
+		L1Fee:      NewL1FeeModel(client, global_const.BaseChainId),
+	})
+	token := common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+
+	maxFee, err := oracle.MaxTransferFee(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 执行费 3 gwei * 100000 加 L1 数据费
+	if want := new(big.Int).Add(new(big.Int).Mul(gwei(3), big.NewInt(Erc20TransferGasLimit)), big.NewInt(7e12)); maxFee.Cmp(want) != 0 {
+		t.Fatalf("max transfer fee %s, want %s", maxFee, want)
+	}
+	if len(client.calls) != 1 || *client.calls[0].To != common.HexToAddress(global_const.OpGasPriceOracle) {
+		t.Fatalf("expected one GasPriceOracle call, got %+v", client.calls)
+	}
+	method, err := wallet.GasPriceOracleABI.MethodById(client.calls[0].Data[:4])
+	if err != nil || method.Name != "getL1Fee" {
+		t.Fatalf("unexpected oracle call %x", client.calls[0].Data)
+	}
+
+	if model := NewL1FeeModel(client, global_const.ScrollChainId).(*gasPriceOracle); model.address != common.HexToAddress(global_const.ScrollL1GasPriceOracle) {
+		t.Fatalf("unexpected scroll oracle %s", model.address)
+	}
+	if model := NewL1FeeModel(client, global_const.EthereumChainId); model != nil {
+		t.Fatal("l1 should not have a data fee model")
+	}
+}
+
+func TestParseTier(t *testing.T) {
+	for input, want := range map[string]Tier{"": TierNormal, "slow": TierSlow, "normal": TierNormal, "fast": TierFast} {
+		if got, err := ParseTier(input); err != nil || got != want {
+			t.Errorf("ParseTier(%q) = %d, %v", input, got, err)
+		}
+	}
+	if _, err := ParseTier("urgent"); err == nil {
+		t.Error("expected unknown tier to fail")
+	}
+}
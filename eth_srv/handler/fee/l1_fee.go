@@ -0,0 +1,53 @@
+package fee
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// L1FeeModel rollup 链按交易数据计算 L1 数据费，其他收费方式的 rollup 实现该接口即可接入
+type L1FeeModel interface {
+	L1Fee(ctx context.Context, unsignedTx []byte) (*big.Int, error)
+}
+
+// NewL1FeeModel 按链返回 L1 数据费模型，不单独收取数据费的链返回 nil
+func NewL1FeeModel(client node.EthClient, chainId uint64) L1FeeModel {
+	switch global_const.L1FeeModelOf(chainId) {
+	case global_const.L1FeeOpStack:
+		return NewGasPriceOracle(client, common.HexToAddress(global_const.OpGasPriceOracle))
+	case global_const.L1FeeScrollOracle:
+		return NewGasPriceOracle(client, common.HexToAddress(global_const.ScrollL1GasPriceOracle))
+	default:
+		return nil
+	}
+}
+
+// gasPriceOracle 调用预部署合约的 getL1Fee(bytes)，OP-stack 的 GasPriceOracle 与 Scroll 的 L1GasPriceOracle 接口相同，
+// 两者都按未签名交易计算并自行加上签名的长度
+type gasPriceOracle struct {
+	client  node.EthClient
+	address common.Address
+}
+
+func NewGasPriceOracle(client node.EthClient, address common.Address) L1FeeModel {
+	return &gasPriceOracle{client: client, address: address}
+}
+
+func (o *gasPriceOracle) L1Fee(ctx context.Context, unsignedTx []byte) (*big.Int, error) {
+	data, err := wallet.GetL1FeeData(unsignedTx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := o.client.CallContractContext(ctx, ethereum.CallMsg{To: &o.address, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query l1 fee from %s: %w", o.address, err)
+	}
+	return wallet.UnpackL1Fee(result)
+}
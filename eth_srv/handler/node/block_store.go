@@ -0,0 +1,51 @@
+package node
+
+import (
+	"errors"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+)
+
+// BlockStore 持久化扫块儿窗口内的区块头
+type BlockStore interface {
+	// LatestBlock 返回窗口内最新的区块，窗口为空时返回 nil
+	LatestBlock(chainId uint64) (*model.Block, error)
+	// StoreBlock 写入一个新区块
+	StoreBlock(block *model.Block) error
+	// DeleteBlock 删除被回滚的区块
+	DeleteBlock(chainId, number uint64) error
+	// PruneBlocks 删除高度小于 number 的区块，只保留最近的窗口
+	PruneBlocks(chainId, number uint64) error
+}
+
+type blockStore struct {
+	db *gorm.DB
+}
+
+func NewBlockStore(db *gorm.DB) BlockStore {
+	return &blockStore{db: db}
+}
+
+func (s *blockStore) LatestBlock(chainId uint64) (*model.Block, error) {
+	var block model.Block
+	err := s.db.Where("chain_id = ?", chainId).Order("number DESC").First(&block).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func (s *blockStore) StoreBlock(block *model.Block) error {
+	return s.db.Create(block).Error
+}
+
+func (s *blockStore) DeleteBlock(chainId, number uint64) error {
+	return s.db.Where("chain_id = ? AND number = ?", chainId, number).Delete(&model.Block{}).Error
+}
+
+func (s *blockStore) PruneBlocks(chainId, number uint64) error {
+	return s.db.Where("chain_id = ? AND number < ?", chainId, number).Delete(&model.Block{}).Error
+}
@@ -31,38 +31,68 @@ type Logs struct {
 }
 
 type TransactionList struct {
-	To   string `json:"to"`
-	Hash string `json:"hash"`
+	From             string         `json:"from"`
+	To               string         `json:"to"`
+	Hash             string         `json:"hash"`
+	Value            hexutil.Big    `json:"value"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
 }
 
 type RpcBlock struct {
 	Hash         common.Hash       `json:"hash"`
+	Number       hexutil.Big       `json:"number"`
 	Transactions []TransactionList `json:"transactions"`
 	BaseFee      string            `json:"baseFeePerGas"`
 }
 
+// EthClient 链节点客户端，XxxContext 方法使用调用方的 ctx，调用方取消或到达截止时间时请求随之结束；
+// 不带 ctx 的方法使用默认的请求超时
 type EthClient interface {
 	BlockHeaderByNumber(*big.Int) (*types.Header, error)
+	BlockHeaderByNumberContext(context.Context, *big.Int) (*types.Header, error)
 
 	BlockByNumber(*big.Int) (*RpcBlock, error)
+	BlockByNumberContext(context.Context, *big.Int) (*RpcBlock, error)
+	BlockByHash(common.Hash) (*RpcBlock, error)
+	BlockByHashContext(context.Context, common.Hash) (*RpcBlock, error)
 
 	LatestSafeBlockHeader() (*types.Header, error)
+	LatestSafeBlockHeaderContext(context.Context) (*types.Header, error)
 	LatestFinalizedBlockHeader() (*types.Header, error)
+	LatestFinalizedBlockHeaderContext(context.Context) (*types.Header, error)
 	BlockHeaderByHash(common.Hash) (*types.Header, error)
+	BlockHeaderByHashContext(context.Context, common.Hash) (*types.Header, error)
 	BlockHeadersByRange(*big.Int, *big.Int, uint) ([]types.Header, error)
+	BlockHeadersByRangeContext(context.Context, *big.Int, *big.Int, uint) ([]types.Header, error)
 
 	TxByHash(common.Hash) (*types.Transaction, error)
+	TxByHashContext(context.Context, common.Hash) (*types.Transaction, error)
 	TxReceiptByHash(common.Hash) (*types.Receipt, error)
+	TxReceiptByHashContext(context.Context, common.Hash) (*types.Receipt, error)
 
 	StorageHash(common.Address, *big.Int) (common.Hash, error)
+	StorageHashContext(context.Context, common.Address, *big.Int) (common.Hash, error)
 	FilterLogs(filterQuery ethereum.FilterQuery, chainId uint) (Logs, error)
+	FilterLogsContext(ctx context.Context, filterQuery ethereum.FilterQuery, chainId uint) (Logs, error)
 
 	TxCountByAddress(common.Address) (hexutil.Uint64, error)
+	TxCountByAddressContext(context.Context, common.Address) (hexutil.Uint64, error)
+	BalanceAt(address common.Address, blockNumber *big.Int) (*big.Int, error)
+	BalanceAtContext(ctx context.Context, address common.Address, blockNumber *big.Int) (*big.Int, error)
+	CallContract(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	CallContractContext(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 
 	SendRawTransaction(rawTx string) error
+	SendRawTransactionContext(ctx context.Context, rawTx string) error
 
 	SuggestGasPrice() (*big.Int, error)
+	SuggestGasPriceContext(context.Context) (*big.Int, error)
 	SuggestGasTipCap() (*big.Int, error)
+	SuggestGasTipCapContext(context.Context) (*big.Int, error)
+	FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	FeeHistoryContext(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	EstimateGas(msg ethereum.CallMsg) (uint64, error)
+	EstimateGasContext(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
 
 	Close()
 }
@@ -71,7 +101,18 @@ type client struct {
 	rpc RPC
 }
 
-func DialEthClient(ctx context.Context, rpcUrl string) (EthClient, error) {
+func DialEthClient(ctx context.Context, rpcUrl string, limits RPCLimits) (EthClient, error) {
+	rpc, err := DialRPC(ctx, rpcUrl, limits)
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		rpc: rpc,
+	}, nil
+}
+
+// DialRPC 连接节点，请求按 limits 限流，批量请求按节点的承受能力拆分
+func DialRPC(ctx context.Context, rpcUrl string, limits RPCLimits) (RPC, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
 	defer cancel()
 
@@ -79,10 +120,22 @@ func DialEthClient(ctx context.Context, rpcUrl string) (EthClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	rpc := NewRPC(clt)
+	return NewLimitedRPC(NewRPC(clt), limits), nil
+}
+
+// NewEthClient 基于已有的 RPC 创建客户端，如多节点路由的 MultiRPC
+func NewEthClient(rpc RPC) EthClient {
 	return &client{
 		rpc: rpc,
-	}, nil
+	}
+}
+
+// withRequestTimeout 调用方没有设置截止时间时使用默认的请求超时，调用方取消时请求随之取消
+func withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultRequestTimeout)
 }
 
 func toBlockNumArg(number *big.Int) string {
@@ -96,7 +149,11 @@ func toBlockNumArg(number *big.Int) string {
 }
 
 func (c *client) BlockHeaderByNumber(number *big.Int) (*types.Header, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.BlockHeaderByNumberContext(context.Background(), number)
+}
+
+func (c *client) BlockHeaderByNumberContext(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var header *types.Header
@@ -112,10 +169,14 @@ func (c *client) BlockHeaderByNumber(number *big.Int) (*types.Header, error) {
 }
 
 func (c *client) BlockByNumber(number *big.Int) (*RpcBlock, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.BlockByNumberContext(context.Background(), number)
+}
+
+func (c *client) BlockByNumberContext(ctx context.Context, number *big.Int) (*RpcBlock, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 	var block *RpcBlock
-	err := c.rpc.CallContext(ctx, &block, "eth_getBlockByNumber", toBlockNumArg(number), false)
+	err := c.rpc.CallContext(ctx, &block, "eth_getBlockByNumber", toBlockNumArg(number), true)
 	if err != nil {
 		log.Error("Call eth_getBlockByNumber method fail", "err", err)
 		return nil, err
@@ -127,8 +188,33 @@ func (c *client) BlockByNumber(number *big.Int) (*RpcBlock, error) {
 	return block, nil
 }
 
+// BlockByHash 按哈希获取包含完整交易的区块，扫块儿时按哈希查询可以避免重组带来的不一致
+func (c *client) BlockByHash(hash common.Hash) (*RpcBlock, error) {
+	return c.BlockByHashContext(context.Background(), hash)
+}
+
+func (c *client) BlockByHashContext(ctx context.Context, hash common.Hash) (*RpcBlock, error) {
+	ctx, cancel := withRequestTimeout(ctx)
+	defer cancel()
+	var block *RpcBlock
+	err := c.rpc.CallContext(ctx, &block, "eth_getBlockByHash", hash, true)
+	if err != nil {
+		log.Error("Call eth_getBlockByHash method fail", "err", err)
+		return nil, err
+	} else if block == nil {
+		log.Warn("block not found", "hash", hash)
+		return nil, ethereum.NotFound
+	}
+
+	return block, nil
+}
+
 func (c *client) LatestSafeBlockHeader() (*types.Header, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.LatestSafeBlockHeaderContext(context.Background())
+}
+
+func (c *client) LatestSafeBlockHeaderContext(ctx context.Context) (*types.Header, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var header *types.Header
@@ -143,7 +229,11 @@ func (c *client) LatestSafeBlockHeader() (*types.Header, error) {
 }
 
 func (c *client) LatestFinalizedBlockHeader() (*types.Header, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.LatestFinalizedBlockHeaderContext(context.Background())
+}
+
+func (c *client) LatestFinalizedBlockHeaderContext(ctx context.Context) (*types.Header, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var header *types.Header
@@ -158,7 +248,11 @@ func (c *client) LatestFinalizedBlockHeader() (*types.Header, error) {
 }
 
 func (c *client) BlockHeaderByHash(hash common.Hash) (*types.Header, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.BlockHeaderByHashContext(context.Background(), hash)
+}
+
+func (c *client) BlockHeaderByHashContext(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var header *types.Header
@@ -180,9 +274,13 @@ func (c *client) BlockHeaderByHash(hash common.Hash) (*types.Header, error) {
 // 如果 startHeight == endHeight，则直接查询单个区块头。
 // 对于 ZkFairSepolia 和 ZkFair 链，采用并发分批查询（每批最多 100 个区块）。
 func (c *client) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId uint) ([]types.Header, error) {
+	return c.BlockHeadersByRangeContext(context.Background(), startHeight, endHeight, chainId)
+}
+
+func (c *client) BlockHeadersByRangeContext(ctx context.Context, startHeight, endHeight *big.Int, chainId uint) ([]types.Header, error) {
 	// 比较起始块儿和总止块儿是否一样
 	if startHeight.Cmp(endHeight) == 0 {
-		header, err := c.BlockHeaderByNumber(startHeight)
+		header, err := c.BlockHeaderByNumberContext(ctx, startHeight)
 		if err != nil {
 			return nil, err
 		}
@@ -196,8 +294,7 @@ func (c *client) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId ui
 	// 预分配 RPC 批量查询的请求切片
 	batchElems := make([]rpc.BatchElem, count)
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
-	defer cancel()
+	// 不对整个区间设置超时：批量请求由限流层拆分并按单个请求计算超时，逐个查询时每个请求单独计算超时
 
 	// 如果目标链是 ZkFairSepolia 或 ZkFair，则采用并发分批查询的方式
 	if chainId == uint(global_const.ZkFairSepoliaChainId) ||
@@ -211,7 +308,7 @@ func (c *client) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId ui
 		for i := 0; i < int(count); i += groupSize {
 			start := i
 			end := i + groupSize - 1
-			if end > int(count) {
+			if end >= int(count) {
 				end = int(count) - 1 // 防止越界
 			}
 
@@ -229,7 +326,9 @@ func (c *client) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId ui
 					}
 					// 直接执行 RPC 调用（同步方式）
 					header := new(types.Header)
-					batchElems[j].Error = c.rpc.CallContext(ctx, header, batchElems[j].Method, toBlockNumArg(height), false)
+					callCtx, cancel := withRequestTimeout(ctx)
+					batchElems[j].Error = c.rpc.CallContext(callCtx, header, batchElems[j].Method, toBlockNumArg(height), false)
+					cancel()
 					batchElems[j].Result = header
 				}
 			}(start, end)
@@ -258,6 +357,10 @@ func (c *client) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId ui
 	// 解析批量查询结果
 	size := 0
 	for i, batchElem := range batchElems {
+		// 单个区块查询失败时整批作废，避免返回空区块头
+		if batchElem.Error != nil {
+			return nil, fmt.Errorf("unable to query header %d: %w", i, batchElem.Error)
+		}
 		// 确保 RPC 响应结果能够正确转换为 `types.Header`
 		header, ok := batchElem.Result.(*types.Header)
 		if !ok {
@@ -275,7 +378,11 @@ func (c *client) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId ui
 }
 
 func (c *client) TxByHash(hash common.Hash) (*types.Transaction, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.TxByHashContext(context.Background(), hash)
+}
+
+func (c *client) TxByHashContext(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var tx *types.Transaction
@@ -290,7 +397,11 @@ func (c *client) TxByHash(hash common.Hash) (*types.Transaction, error) {
 }
 
 func (c *client) TxReceiptByHash(hash common.Hash) (*types.Receipt, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.TxReceiptByHashContext(context.Background(), hash)
+}
+
+func (c *client) TxReceiptByHashContext(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var txReceipt *types.Receipt
@@ -306,7 +417,11 @@ func (c *client) TxReceiptByHash(hash common.Hash) (*types.Receipt, error) {
 }
 
 func (c *client) StorageHash(address common.Address, blockNumber *big.Int) (common.Hash, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.StorageHashContext(context.Background(), address, blockNumber)
+}
+
+func (c *client) StorageHashContext(ctx context.Context, address common.Address, blockNumber *big.Int) (common.Hash, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	proof := struct{ StorageHash common.Hash }{}
@@ -349,6 +464,10 @@ func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
 
 // FilterLogs 根据指定的过滤条件查询区块链日志
 func (c *client) FilterLogs(query ethereum.FilterQuery, chainId uint) (Logs, error) {
+	return c.FilterLogsContext(context.Background(), query, chainId)
+}
+
+func (c *client) FilterLogsContext(ctx context.Context, query ethereum.FilterQuery, chainId uint) (Logs, error) {
 	// 将查询条件转换为 RPC 需要的参数
 	arg, err := toFilterArg(query)
 	if err != nil {
@@ -365,7 +484,7 @@ func (c *client) FilterLogs(query ethereum.FilterQuery, chainId uint) (Logs, err
 	batchElems[0] = rpc.BatchElem{Method: "eth_getBlockByNumber", Args: []interface{}{toBlockNumArg(query.ToBlock), false}, Result: &header}
 	batchElems[1] = rpc.BatchElem{Method: "eth_getLogs", Args: []interface{}{arg}, Result: &logs}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	// 对于特定链（ZkFairSepolia 和 ZkFair），使用单独的 RPC 请求，而不是批量调用
@@ -397,7 +516,11 @@ func (c *client) FilterLogs(query ethereum.FilterQuery, chainId uint) (Logs, err
 }
 
 func (c *client) TxCountByAddress(address common.Address) (hexutil.Uint64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.TxCountByAddressContext(context.Background(), address)
+}
+
+func (c *client) TxCountByAddressContext(ctx context.Context, address common.Address) (hexutil.Uint64, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 	var nonce hexutil.Uint64
 	err := c.rpc.CallContext(ctx, &nonce, "eth_getTransactionCount", address, "latest")
@@ -409,8 +532,72 @@ func (c *client) TxCountByAddress(address common.Address) (hexutil.Uint64, error
 	return nonce, err
 }
 
+// BalanceAt 查询地址的原生币余额，blockNumber 为 nil 时查询最新区块
+func (c *client) BalanceAt(address common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return c.BalanceAtContext(context.Background(), address, blockNumber)
+}
+
+func (c *client) BalanceAtContext(ctx context.Context, address common.Address, blockNumber *big.Int) (*big.Int, error) {
+	ctx, cancel := withRequestTimeout(ctx)
+	defer cancel()
+	var balance hexutil.Big
+	err := c.rpc.CallContext(ctx, &balance, "eth_getBalance", address, toBlockNumArg(blockNumber))
+	if err != nil {
+		log.Error("Call eth_getBalance method fail", "err", err)
+		return nil, err
+	}
+	return (*big.Int)(&balance), nil
+}
+
+// CallContract 执行只读合约调用，blockNumber 为 nil 时基于最新区块
+func (c *client) CallContract(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return c.CallContractContext(context.Background(), msg, blockNumber)
+}
+
+func (c *client) CallContractContext(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	ctx, cancel := withRequestTimeout(ctx)
+	defer cancel()
+	var result hexutil.Bytes
+	err := c.rpc.CallContext(ctx, &result, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
+	if err != nil {
+		log.Error("Call eth_call method fail", "err", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	return arg
+}
+
 func (c *client) SendRawTransaction(rawTx string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.SendRawTransactionContext(context.Background(), rawTx)
+}
+
+func (c *client) SendRawTransactionContext(ctx context.Context, rawTx string) error {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 	if err := c.rpc.CallContext(ctx, nil, "eth_sendRawTransaction", rawTx); err != nil {
 		return err
@@ -420,7 +607,11 @@ func (c *client) SendRawTransaction(rawTx string) error {
 }
 
 func (c *client) SuggestGasPrice() (*big.Int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.SuggestGasPriceContext(context.Background())
+}
+
+func (c *client) SuggestGasPriceContext(ctx context.Context) (*big.Int, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 
 	var hex hexutil.Big
@@ -431,7 +622,11 @@ func (c *client) SuggestGasPrice() (*big.Int, error) {
 }
 
 func (c *client) SuggestGasTipCap() (*big.Int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	return c.SuggestGasTipCapContext(context.Background())
+}
+
+func (c *client) SuggestGasTipCapContext(ctx context.Context) (*big.Int, error) {
+	ctx, cancel := withRequestTimeout(ctx)
 	defer cancel()
 	var hex hexutil.Big
 	if err := c.rpc.CallContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
@@ -440,6 +635,61 @@ func (c *client) SuggestGasTipCap() (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory 查询截至 lastBlock 的 blockCount 个区块的 baseFee 以及各分位的小费，lastBlock 为 nil 时截至最新区块。
+// BaseFee 比区块数多一个，最后一个为下一个区块的 baseFee
+func (c *client) FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return c.FeeHistoryContext(context.Background(), blockCount, lastBlock, rewardPercentiles)
+}
+
+func (c *client) FeeHistoryContext(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	ctx, cancel := withRequestTimeout(ctx)
+	defer cancel()
+	var res feeHistoryResult
+	if err := c.rpc.CallContext(ctx, &res, "eth_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles); err != nil {
+		log.Error("Call eth_feeHistory method fail", "err", err)
+		return nil, err
+	}
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	baseFee := make([]*big.Int, len(res.BaseFee))
+	for i, b := range res.BaseFee {
+		baseFee[i] = (*big.Int)(b)
+	}
+	return &ethereum.FeeHistory{
+		OldestBlock:  (*big.Int)(res.OldestBlock),
+		Reward:       reward,
+		BaseFee:      baseFee,
+		GasUsedRatio: res.GasUsedRatio,
+	}, nil
+}
+
+// EstimateGas 基于 pending 状态估算交易需要的 gas
+func (c *client) EstimateGas(msg ethereum.CallMsg) (uint64, error) {
+	return c.EstimateGasContext(context.Background(), msg)
+}
+
+func (c *client) EstimateGasContext(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	ctx, cancel := withRequestTimeout(ctx)
+	defer cancel()
+	var gas hexutil.Uint64
+	if err := c.rpc.CallContext(ctx, &gas, "eth_estimateGas", toCallArg(msg)); err != nil {
+		return 0, err
+	}
+	return uint64(gas), nil
+}
+
 func (c *client) Close() {
 	c.rpc.Close()
 }
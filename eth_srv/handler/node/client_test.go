@@ -0,0 +1,57 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ctxRPC 记录请求收到的 ctx，并在 ctx 结束时返回其错误
+type ctxRPC struct {
+	ctx context.Context
+}
+
+func (r *ctxRPC) Close() {}
+
+func (r *ctxRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	r.ctx = ctx
+	return ctx.Err()
+}
+
+func (r *ctxRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	r.ctx = ctx
+	return ctx.Err()
+}
+
+func TestClientContext(t *testing.T) {
+	r := &ctxRPC{}
+	client := NewEthClient(r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.BalanceAtContext(ctx, common.Address{}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancellation should reach the rpc client, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel = context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if _, err := client.TxCountByAddressContext(ctx, common.Address{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := r.ctx.Deadline(); !ok || !got.Equal(deadline) {
+		t.Fatalf("caller deadline should be kept, got %s", got)
+	}
+
+	// 不带截止时间的请求使用默认超时
+	if _, err := client.SuggestGasPrice(); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := r.ctx.Deadline(); !ok || time.Until(got) > defaultRequestTimeout {
+		t.Fatalf("expected default request timeout, got %s", got)
+	}
+}
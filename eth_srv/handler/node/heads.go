@@ -0,0 +1,121 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = time.Minute
+)
+
+// HeadSource 新区块通知，扫块儿收到通知后立即追赶到链头
+type HeadSource interface {
+	// Run 维持订阅直到 ctx 结束
+	Run(ctx context.Context)
+	// Heads 有新区块或重新订阅成功时收到通知，多个通知会合并
+	Heads() <-chan struct{}
+	// Active 订阅是否正常，订阅中断期间扫块儿退回轮询
+	Active() bool
+}
+
+// IsWebsocketUrl 节点地址是否为 WebSocket，只有 WebSocket 节点支持 eth_subscribe
+func IsWebsocketUrl(url string) bool {
+	return strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://")
+}
+
+// HeadSubscription 通过 WebSocket 订阅 newHeads，连接断开后按退避间隔重新连接并订阅
+type HeadSubscription struct {
+	url        string
+	heads      chan struct{}
+	active     atomic.Bool
+	retryDelay time.Duration
+}
+
+func NewHeadSubscription(url string) *HeadSubscription {
+	return &HeadSubscription{url: url, heads: make(chan struct{}, 1), retryDelay: minResubscribeDelay}
+}
+
+func (h *HeadSubscription) Heads() <-chan struct{} {
+	return h.heads
+}
+
+func (h *HeadSubscription) Active() bool {
+	return h.active.Load()
+}
+
+func (h *HeadSubscription) Run(ctx context.Context) {
+	delay := h.retryDelay
+	for {
+		established, err := h.subscribe(ctx)
+		h.active.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			log.Warn("endpoint does not support subscriptions, poll for new heads", "url", h.url)
+			return
+		}
+		if established {
+			delay = h.retryDelay
+		}
+		log.Warn("head subscription dropped, poll until resubscribed", "url", h.url, "retry", delay, "err", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxResubscribeDelay)
+	}
+}
+
+// subscribe 建立连接并订阅，返回订阅中断的原因，established 表示订阅曾经成功
+func (h *HeadSubscription) subscribe(ctx context.Context) (bool, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+	client, err := rpc.DialContext(dialCtx, h.url)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	ch := make(chan *types.Header, 16)
+	subCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	sub, err := client.EthSubscribe(subCtx, ch, "newHeads")
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer sub.Unsubscribe()
+
+	h.active.Store(true)
+	log.Info("subscribed to new heads", "url", h.url)
+	// 订阅成功后立即扫描一次，补齐断开期间错过的区块
+	h.notify()
+	for {
+		select {
+		case header := <-ch:
+			log.Debug("new head", "number", header.Number, "hash", header.Hash())
+			h.notify()
+		case err := <-sub.Err():
+			return true, err
+		case <-ctx.Done():
+			return true, nil
+		}
+	}
+}
+
+func (h *HeadSubscription) notify() {
+	select {
+	case h.heads <- struct{}{}:
+	default:
+	}
+}
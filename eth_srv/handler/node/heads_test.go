@@ -0,0 +1,96 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// headsService 模拟节点的 eth_subscribe("newHeads")
+type headsService struct {
+	headers chan *types.Header
+}
+
+func (s *headsService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			select {
+			case header := <-s.headers:
+				notifier.Notify(sub.ID, header)
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func waitHead(t *testing.T, h *HeadSubscription) {
+	t.Helper()
+	select {
+	case <-h.Heads():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no head notification")
+	}
+}
+
+func TestHeadSubscription(t *testing.T) {
+	service := &headsService{headers: make(chan *types.Header)}
+	// 节点重启时换成新的 rpc.Server，旧连接全部断开
+	var current atomic.Pointer[rpc.Server]
+	restart := func() {
+		server := rpc.NewServer()
+		if err := server.RegisterName("eth", service); err != nil {
+			t.Fatal(err)
+		}
+		if old := current.Swap(server); old != nil {
+			old.Stop()
+		}
+	}
+	restart()
+	defer func() { current.Load().Stop() }()
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().WebsocketHandler([]string{"*"}).ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	h := NewHeadSubscription("ws" + strings.TrimPrefix(httpServer.URL, "http"))
+	h.retryDelay = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+
+	// 订阅成功后立即通知一次，用于补齐区块
+	waitHead(t, h)
+	if !h.Active() {
+		t.Fatal("subscription should be active")
+	}
+	service.headers <- &types.Header{Number: big.NewInt(1), Difficulty: new(big.Int)}
+	waitHead(t, h)
+
+	// 连接断开后重新订阅
+	restart()
+	waitHead(t, h)
+	if !h.Active() {
+		t.Fatal("subscription should be active after resubscribe")
+	}
+
+	cancel()
+	<-done
+	if h.Active() {
+		t.Fatal("subscription should be inactive after stop")
+	}
+}
@@ -0,0 +1,229 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultMaxBatchSize  = 100
+	defaultRequestWeight = 1
+	// limitExceededCode 节点返回的请求超限错误码
+	limitExceededCode = -32005
+	// 批量请求被限流后按指数退避等待再重试
+	limitBackoffBase = 100 * time.Millisecond
+	limitBackoffMax  = 5 * time.Second
+)
+
+// defaultWeights 各方法的默认请求权重，与服务商按计算量计费的方式大致对应
+var defaultWeights = map[string]int{
+	"eth_getLogs":            10,
+	"eth_getProof":           5,
+	"eth_estimateGas":        5,
+	"eth_sendRawTransaction": 5,
+	"eth_call":               2,
+	"eth_feeHistory":         2,
+}
+
+// RPCLimits 单个节点的限流与批量请求配置
+type RPCLimits struct {
+	RateLimit    float64        // 每秒允许的请求权重，0 表示不限流
+	Burst        int            // 令牌桶容量，默认为一秒的权重
+	MaxBatchSize int            // 单个批量请求的最大条数
+	Weights      map[string]int // 方法的请求权重，覆盖默认权重，方法名不区分大小写
+}
+
+// limitedRPC 按令牌桶限制请求权重，并把批量请求拆分成不超过当前批量大小的多个请求。
+// 节点返回批量过大或请求过多时批量大小减半并退避等待，请求成功后逐步恢复。
+// 拆分后的每个请求单独计算超时，排队和退避等待的时间不占用请求的超时
+type limitedRPC struct {
+	RPC
+	limiter  *rate.Limiter
+	weights  map[string]int
+	maxBatch int
+	batch    atomic.Int64
+	timeout  time.Duration
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewLimitedRPC(rpc RPC, limits RPCLimits) RPC {
+	if limits.MaxBatchSize <= 0 {
+		limits.MaxBatchSize = defaultMaxBatchSize
+	}
+	weights := make(map[string]int, len(defaultWeights)+len(limits.Weights))
+	for method, weight := range defaultWeights {
+		weights[strings.ToLower(method)] = weight
+	}
+	for method, weight := range limits.Weights {
+		weights[strings.ToLower(method)] = weight
+	}
+	r := &limitedRPC{RPC: rpc, weights: weights, maxBatch: limits.MaxBatchSize, timeout: defaultRequestTimeout, sleep: sleepContext}
+	if limits.RateLimit > 0 {
+		burst := limits.Burst
+		if burst <= 0 {
+			burst = max(int(limits.RateLimit), 1)
+		}
+		r.limiter = rate.NewLimiter(rate.Limit(limits.RateLimit), burst)
+	}
+	r.batch.Store(int64(limits.MaxBatchSize))
+	return r
+}
+
+// MinBatchSize 多个节点中最小的批量请求上限，未配置的节点按默认值计算
+func MinBatchSize(limits ...RPCLimits) int {
+	size := 0
+	for _, l := range limits {
+		batch := l.MaxBatchSize
+		if batch <= 0 {
+			batch = defaultMaxBatchSize
+		}
+		if size == 0 || batch < size {
+			size = batch
+		}
+	}
+	return size
+}
+
+func (r *limitedRPC) weight(method string) int {
+	if weight, ok := r.weights[strings.ToLower(method)]; ok {
+		return weight
+	}
+	return defaultRequestWeight
+}
+
+// wait 等待令牌桶中有足够的权重，权重超过桶容量的请求按桶容量计算
+func (r *limitedRPC) wait(ctx context.Context, weight int) error {
+	if r.limiter == nil {
+		return nil
+	}
+	return r.limiter.WaitN(ctx, min(weight, r.limiter.Burst()))
+}
+
+func (r *limitedRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if err := r.wait(ctx, r.weight(method)); err != nil {
+		return err
+	}
+	return r.RPC.CallContext(ctx, result, method, args...)
+}
+
+func (r *limitedRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	// limits 连续被限流的次数，决定重试前的等待时间
+	limits := 0
+	for start := 0; start < len(b); {
+		size := int(r.batch.Load())
+		chunk := b[start:min(start+size, len(b))]
+		weight := 0
+		for _, elem := range chunk {
+			weight += r.weight(elem.Method)
+		}
+		if err := r.wait(ctx, weight); err != nil {
+			return err
+		}
+
+		err := r.batchCall(ctx, chunk)
+		limited := err != nil && isLimitError(err)
+		if err == nil {
+			limited = chunkLimited(chunk)
+		}
+		if !limited {
+			if err != nil {
+				return err
+			}
+			// 不满批量的请求成功不能说明节点能承受更大的批量
+			if len(chunk) == size {
+				r.grow(size)
+			}
+			start += len(chunk)
+			limits = 0
+			continue
+		}
+		if len(chunk) == 1 {
+			// 单条请求仍被拒绝时交给调用方处理，单条请求的错误保留在 Error 中
+			return err
+		}
+		r.shrink(size)
+		for i := range chunk {
+			chunk[i].Error = nil
+		}
+		if err := r.sleep(ctx, limitBackoff(limits)); err != nil {
+			return err
+		}
+		limits++
+	}
+	return nil
+}
+
+// batchCall 发送拆分后的一个批量请求，超时只覆盖这一个请求
+func (r *limitedRPC) batchCall(ctx context.Context, chunk []rpc.BatchElem) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.RPC.BatchCallContext(ctx, chunk)
+}
+
+// limitBackoff 第 n 次连续限流后的等待时间，从 limitBackoffBase 开始翻倍，不超过 limitBackoffMax
+func limitBackoff(n int) time.Duration {
+	if n >= 16 {
+		return limitBackoffMax
+	}
+	return min(limitBackoffBase<<n, limitBackoffMax)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shrink 批量大小减半，多个请求并发失败时只减一次
+func (r *limitedRPC) shrink(size int) {
+	next := max(size/2, 1)
+	if r.batch.CompareAndSwap(int64(size), int64(next)) {
+		log.Warn("rpc batch limited, shrink batch size", "size", next)
+	}
+}
+
+// grow 请求成功后按最大批量的十分之一恢复
+func (r *limitedRPC) grow(size int) {
+	if size >= r.maxBatch {
+		return
+	}
+	r.batch.CompareAndSwap(int64(size), int64(min(size+max(r.maxBatch/10, 1), r.maxBatch)))
+}
+
+// chunkLimited 部分节点以批量中单条请求的错误返回批量过大
+func chunkLimited(chunk []rpc.BatchElem) bool {
+	for _, elem := range chunk {
+		if elem.Error != nil && isLimitError(elem.Error) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLimitError 节点因批量过大或请求过多拒绝请求
+func isLimitError(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusRequestEntityTooLarge
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == limitExceededCode {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "batch too large") || strings.Contains(msg, "batch size") ||
+		strings.Contains(msg, "too many requests") || strings.Contains(msg, "rate limit")
+}
@@ -0,0 +1,218 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// batchRPC 拒绝超过 limit 条的批量请求，记录每次请求的条数
+type batchRPC struct {
+	limit    int
+	reject   func(b []rpc.BatchElem) error
+	sizes    []int
+	requests int
+}
+
+func (r *batchRPC) Close() {}
+
+func (r *batchRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	r.requests++
+	return nil
+}
+
+func (r *batchRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	r.sizes = append(r.sizes, len(b))
+	if len(b) > r.limit {
+		return r.reject(b)
+	}
+	for i := range b {
+		*b[i].Result.(*string) = b[i].Method
+	}
+	return nil
+}
+
+func testBatch(n int) []rpc.BatchElem {
+	b := make([]rpc.BatchElem, n)
+	for i := range b {
+		b[i] = rpc.BatchElem{Method: "eth_getBlockByNumber", Result: new(string)}
+	}
+	return b
+}
+
+func checkBatch(t *testing.T, b []rpc.BatchElem) {
+	t.Helper()
+	for i, elem := range b {
+		if elem.Error != nil || *elem.Result.(*string) != elem.Method {
+			t.Fatalf("element %d not filled: %v", i, elem.Error)
+		}
+	}
+}
+
+func TestLimitedRPCSplitsBatch(t *testing.T) {
+	inner := &batchRPC{limit: 100}
+	r := NewLimitedRPC(inner, RPCLimits{MaxBatchSize: 4})
+	b := testBatch(10)
+	if err := r.BatchCallContext(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+	checkBatch(t, b)
+	if len(inner.sizes) != 3 || inner.sizes[0] != 4 || inner.sizes[2] != 2 {
+		t.Fatalf("unexpected batch sizes %v", inner.sizes)
+	}
+}
+
+// slowRPC 每个批量请求耗时 delay，超过截止时间时返回 ctx 的错误
+type slowRPC struct {
+	batchRPC
+	delay time.Duration
+}
+
+func (r *slowRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return r.batchRPC.BatchCallContext(ctx, b)
+}
+
+// 超时按拆分后的单个请求计算，整个区间的耗时可以超过单个请求的超时
+func TestLimitedRPCBatchTimeout(t *testing.T) {
+	inner := &slowRPC{batchRPC: batchRPC{limit: 100}, delay: 30 * time.Millisecond}
+	r := NewLimitedRPC(inner, RPCLimits{MaxBatchSize: 2}).(*limitedRPC)
+	r.timeout = 50 * time.Millisecond
+	b := testBatch(8)
+	if err := r.BatchCallContext(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+	checkBatch(t, b)
+
+	inner.delay = time.Second
+	if err := r.BatchCallContext(context.Background(), testBatch(2)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a slow batch to time out, got %v", err)
+	}
+}
+
+func TestLimitedRPCShrinksBatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		reject func(b []rpc.BatchElem) error
+	}{
+		{
+			// geth 以第一条请求的错误返回批量过大
+			name: "batch too large",
+			reject: func(b []rpc.BatchElem) error {
+				b[0].Error = errors.New("batch too large")
+				for i := 1; i < len(b); i++ {
+					b[i].Error = errors.New("missing batch response")
+				}
+				return nil
+			},
+		},
+		{
+			name: "too many requests",
+			reject: func([]rpc.BatchElem) error {
+				return rpc.HTTPError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &batchRPC{limit: 3, reject: tt.reject}
+			r := NewLimitedRPC(inner, RPCLimits{MaxBatchSize: 20}).(*limitedRPC)
+			r.sleep = func(context.Context, time.Duration) error { return nil }
+			b := testBatch(30)
+			if err := r.BatchCallContext(context.Background(), b); err != nil {
+				t.Fatal(err)
+			}
+			checkBatch(t, b)
+			// 20 → 10 → 5 → 2，之后成功一次增加 2
+			if got := inner.sizes[:4]; got[0] != 20 || got[1] != 10 || got[2] != 5 || got[3] != 2 {
+				t.Fatalf("unexpected batch sizes %v", inner.sizes)
+			}
+			if size := r.batch.Load(); size > 4 {
+				t.Fatalf("batch size should stay near the provider limit, got %d", size)
+			}
+		})
+	}
+}
+
+func TestLimitedRPCBackoff(t *testing.T) {
+	inner := &batchRPC{limit: 3, reject: func([]rpc.BatchElem) error {
+		return rpc.HTTPError{StatusCode: http.StatusTooManyRequests}
+	}}
+	r := NewLimitedRPC(inner, RPCLimits{MaxBatchSize: 20}).(*limitedRPC)
+	var delays []time.Duration
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	b := testBatch(30)
+	if err := r.BatchCallContext(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+	checkBatch(t, b)
+	// 20、10、5 条的批量连续被限流，退避 100ms、200ms、400ms；
+	// 之后批量恢复到 4 条再被限流时，前面已有请求成功，退避从 100ms 重新开始
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	if len(delays) <= len(want) {
+		t.Fatalf("unexpected delays %v", delays)
+	}
+	for i, d := range delays {
+		if i < len(want) && d != want[i] || i >= len(want) && d != limitBackoffBase {
+			t.Fatalf("unexpected delays %v", delays)
+		}
+	}
+	if got := limitBackoff(10); got != limitBackoffMax {
+		t.Fatalf("backoff should be capped, got %s", got)
+	}
+
+	// 退避期间调用方取消时不再重试
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.sleep = sleepContext
+	r.batch.Store(20)
+	if err := r.BatchCallContext(ctx, testBatch(30)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled backoff, got %v", err)
+	}
+}
+
+func TestLimitedRPCSingleRejected(t *testing.T) {
+	inner := &batchRPC{limit: 0, reject: func([]rpc.BatchElem) error {
+		return rpc.HTTPError{StatusCode: http.StatusTooManyRequests}
+	}}
+	r := NewLimitedRPC(inner, RPCLimits{MaxBatchSize: 4})
+	var httpErr rpc.HTTPError
+	if err := r.BatchCallContext(context.Background(), testBatch(4)); !errors.As(err, &httpErr) {
+		t.Fatalf("expected http error once batch cannot shrink, got %v", err)
+	}
+}
+
+func TestLimitedRPCWeights(t *testing.T) {
+	inner := &batchRPC{}
+	r := NewLimitedRPC(inner, RPCLimits{RateLimit: 10, Burst: 20, Weights: map[string]int{"eth_getlogs": 15}}).(*limitedRPC)
+	if r.weight("eth_getLogs") != 15 || r.weight("eth_estimateGas") != 5 || r.weight("eth_chainId") != 1 {
+		t.Fatalf("unexpected weights %d %d %d", r.weight("eth_getLogs"), r.weight("eth_estimateGas"), r.weight("eth_chainId"))
+	}
+
+	if err := r.CallContext(context.Background(), nil, "eth_getLogs"); err != nil {
+		t.Fatal(err)
+	}
+	// 桶里只剩 5，再请求 15 需要等待 1 秒
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := r.CallContext(ctx, nil, "eth_getLogs"); err == nil {
+		t.Fatal("request above the remaining budget should wait past the deadline")
+	}
+	if err := r.CallContext(ctx, nil, "eth_chainId"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.requests != 2 {
+		t.Fatalf("expected 2 requests to reach the provider, got %d", inner.requests)
+	}
+}
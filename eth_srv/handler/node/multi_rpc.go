@@ -0,0 +1,283 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultHealthInterval = 10 * time.Second
+	defaultMaxLag         = 5
+	defaultStuckTimeout   = time.Minute
+)
+
+// RPCEndpoint 一个节点地址，Priority 越小越优先，同优先级的健康节点中固定使用一个主节点，主节点失败后才切换
+type RPCEndpoint struct {
+	Url      string
+	Priority int
+	Limits   RPCLimits
+}
+
+type MultiRPCConfig struct {
+	HealthInterval time.Duration // 健康检查间隔
+	MaxLag         uint64        // 区块高度落后最高节点超过该值的节点不参与路由
+	StuckTimeout   time.Duration // 区块高度超过该时间没有增长的节点不参与路由
+}
+
+// endpoint 节点的健康状态，由健康检查和请求结果共同维护
+type endpoint struct {
+	url      string
+	priority int
+	rpc      RPC
+
+	mu       sync.Mutex
+	checked  bool          // 至少完成过一次健康检查
+	head     uint64        // 最近一次检查到的区块高度
+	headAt   time.Time     // 区块高度最近一次增长的时间
+	latency  time.Duration // 最近一次健康检查的耗时
+	failures int           // 连续失败次数，健康检查成功后清零
+}
+
+type endpointState struct {
+	*endpoint
+	head     uint64
+	headAt   time.Time
+	latency  time.Duration
+	failures int
+	checked  bool
+}
+
+func (e *endpoint) state() endpointState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return endpointState{endpoint: e, head: e.head, headAt: e.headAt, latency: e.latency, failures: e.failures, checked: e.checked}
+}
+
+func (e *endpoint) fail() {
+	e.mu.Lock()
+	e.failures++
+	e.mu.Unlock()
+}
+
+// MultiRPC 按优先级在多个节点间路由请求：定时检查各节点的区块高度和延迟，落后、高度停滞或请求失败的节点不参与路由，
+// 请求因网络或节点故障失败时依次切换到下一个节点。实现 RPC 接口，上层的 EthClient 不需要感知多节点。
+// 连续的请求固定发往主节点，避免相邻请求落到高度不同的节点上读到不一致的数据
+type MultiRPC struct {
+	endpoints []*endpoint
+	cfg       MultiRPCConfig
+	primary   atomic.Pointer[endpoint] // 当前主节点，失败或不再健康时才更换
+	now       func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// DialMultiRPC 连接所有节点并完成首次健康检查，单个节点连接失败时跳过，全部失败时返回错误
+func DialMultiRPC(ctx context.Context, endpoints []RPCEndpoint, cfg MultiRPCConfig) (*MultiRPC, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one rpc endpoint is required")
+	}
+	dialed := make([]*endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		conn, err := DialRPC(ctx, ep.Url, ep.Limits)
+		if err != nil {
+			log.Warn("unable to dial rpc endpoint", "url", ep.Url, "err", err)
+			continue
+		}
+		dialed = append(dialed, &endpoint{url: ep.Url, priority: ep.Priority, rpc: conn})
+	}
+	if len(dialed) == 0 {
+		return nil, errors.New("unable to dial any rpc endpoint")
+	}
+	m := newMultiRPC(dialed, cfg)
+	m.checkHealth(ctx)
+	m.start()
+	return m, nil
+}
+
+func newMultiRPC(endpoints []*endpoint, cfg MultiRPCConfig) *MultiRPC {
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = defaultHealthInterval
+	}
+	if cfg.MaxLag == 0 {
+		cfg.MaxLag = defaultMaxLag
+	}
+	if cfg.StuckTimeout <= 0 {
+		cfg.StuckTimeout = defaultStuckTimeout
+	}
+	return &MultiRPC{endpoints: endpoints, cfg: cfg, now: time.Now}
+}
+
+func (m *MultiRPC) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.cfg.HealthInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.checkHealth(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// checkHealth 并发查询各节点的最新区块高度并记录耗时
+func (m *MultiRPC) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, ep := range m.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			callCtx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+			defer cancel()
+			started := m.now()
+			var head hexutil.Uint64
+			err := ep.rpc.CallContext(callCtx, &head, "eth_blockNumber")
+			latency := m.now().Sub(started)
+
+			ep.mu.Lock()
+			defer ep.mu.Unlock()
+			if err != nil {
+				ep.failures++
+				log.Warn("rpc endpoint health check failed", "url", ep.url, "err", err)
+				return
+			}
+			if !ep.checked || uint64(head) > ep.head {
+				ep.headAt = m.now()
+			}
+			ep.checked = true
+			ep.head = uint64(head)
+			ep.latency = latency
+			ep.failures = 0
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// candidates 返回本次请求依次尝试的节点。健康节点按优先级排列，最高优先级内当前主节点在前，其余按延迟排列；
+// 没有健康节点时按优先级尝试全部节点，好过直接失败
+func (m *MultiRPC) candidates() []*endpoint {
+	states := make([]endpointState, 0, len(m.endpoints))
+	var best uint64
+	for _, ep := range m.endpoints {
+		state := ep.state()
+		if state.checked && state.failures == 0 && state.head > best {
+			best = state.head
+		}
+		states = append(states, state)
+	}
+
+	now := m.now()
+	healthy := make([]endpointState, 0, len(states))
+	for _, state := range states {
+		if !state.checked || state.failures > 0 {
+			continue
+		}
+		if state.head+m.cfg.MaxLag < best || now.Sub(state.headAt) > m.cfg.StuckTimeout {
+			continue
+		}
+		healthy = append(healthy, state)
+	}
+	if len(healthy) == 0 {
+		healthy = states
+	}
+	sort.SliceStable(healthy, func(i, j int) bool {
+		if healthy[i].priority != healthy[j].priority {
+			return healthy[i].priority < healthy[j].priority
+		}
+		return healthy[i].latency < healthy[j].latency
+	})
+
+	// 主节点仍在最高优先级的健康节点中时继续使用，否则由延迟最低的节点接替
+	top := 1
+	for top < len(healthy) && healthy[top].priority == healthy[0].priority {
+		top++
+	}
+	offset := 0
+	primary := m.primary.Load()
+	for i := 0; i < top; i++ {
+		if healthy[i].endpoint == primary {
+			offset = i
+			break
+		}
+	}
+	m.primary.Store(healthy[offset].endpoint)
+	out := make([]*endpoint, 0, len(healthy))
+	for i := 0; i < top; i++ {
+		out = append(out, healthy[(offset+i)%top].endpoint)
+	}
+	for _, state := range healthy[top:] {
+		out = append(out, state.endpoint)
+	}
+	return out
+}
+
+// shouldFailover 网络错误、HTTP 错误和节点自身的超时切换节点重试。
+// 调用方 ctx 已取消或超时时，无论节点返回什么错误都不切换，也不记为节点故障；
+// 节点返回的 JSON-RPC 错误（如执行回滚）换节点也不会成功，同样直接返回
+func shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+func (m *MultiRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	var errs error
+	for _, ep := range m.candidates() {
+		err := ep.rpc.CallContext(ctx, result, method, args...)
+		if err == nil || !shouldFailover(ctx, err) {
+			// 切换后第一个成功响应的节点成为新的主节点
+			m.primary.CompareAndSwap(nil, ep)
+			return err
+		}
+		ep.fail()
+		m.primary.CompareAndSwap(ep, nil)
+		log.Warn("rpc endpoint failed, fail over", "url", ep.url, "method", method, "err", err)
+		errs = errors.Join(errs, fmt.Errorf("%s: %w", ep.url, err))
+	}
+	return errs
+}
+
+func (m *MultiRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	var errs error
+	for _, ep := range m.candidates() {
+		err := ep.rpc.BatchCallContext(ctx, b)
+		if err == nil || !shouldFailover(ctx, err) {
+			// 切换后第一个成功响应的节点成为新的主节点
+			m.primary.CompareAndSwap(nil, ep)
+			return err
+		}
+		ep.fail()
+		m.primary.CompareAndSwap(ep, nil)
+		log.Warn("rpc endpoint failed, fail over", "url", ep.url, "method", "batch", "err", err)
+		errs = errors.Join(errs, fmt.Errorf("%s: %w", ep.url, err))
+	}
+	return errs
+}
+
+func (m *MultiRPC) Close() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+	for _, ep := range m.endpoints {
+		ep.rpc.Close()
+	}
+}
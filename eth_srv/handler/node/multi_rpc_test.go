@@ -0,0 +1,201 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeRPC 返回固定的区块高度，其他请求返回节点名称
+type fakeRPC struct {
+	name string
+	head uint64
+	err  error
+}
+
+func (f *fakeRPC) Close() {}
+
+func (f *fakeRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	if f.err != nil {
+		return f.err
+	}
+	switch method {
+	case "eth_blockNumber":
+		*result.(*hexutil.Uint64) = hexutil.Uint64(f.head)
+	default:
+		*result.(*string) = f.name
+	}
+	return nil
+}
+
+func (f *fakeRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if f.err != nil {
+		return f.err
+	}
+	for i := range b {
+		*b[i].Result.(*string) = f.name
+	}
+	return nil
+}
+
+type jsonError struct{}
+
+func (jsonError) Error() string  { return "execution reverted" }
+func (jsonError) ErrorCode() int { return 3 }
+
+func testMultiRPC(rpcs ...*fakeRPC) (*MultiRPC, *time.Time) {
+	endpoints := make([]*endpoint, 0, len(rpcs))
+	for i, r := range rpcs {
+		endpoints = append(endpoints, &endpoint{url: r.name, priority: i / 2, rpc: r})
+	}
+	m := newMultiRPC(endpoints, MultiRPCConfig{MaxLag: 2, StuckTimeout: time.Minute})
+	now := time.Unix(1700000000, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func call(t *testing.T, m *MultiRPC) string {
+	var name string
+	if err := m.CallContext(context.Background(), &name, "eth_chainId"); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestMultiRPCStickyPrimary(t *testing.T) {
+	// a、b 优先级 0，c 优先级 1
+	rpcs := map[string]*fakeRPC{"a": {name: "a", head: 100}, "b": {name: "b", head: 100}}
+	m, _ := testMultiRPC(rpcs["a"], rpcs["b"], &fakeRPC{name: "c", head: 100})
+	m.checkHealth(context.Background())
+
+	first := call(t, m)
+	if first == "c" {
+		t.Fatal("lower priority endpoint should not be the primary")
+	}
+	for i := 0; i < 4; i++ {
+		if got := call(t, m); got != first {
+			t.Fatalf("requests should stick to the primary %s, got %s", first, got)
+		}
+	}
+
+	// 主节点失败后切换到同优先级的另一个节点，恢复后也不再切回
+	rpcs[first].err = errors.New("connection refused")
+	second := call(t, m)
+	if second == first || second == "c" {
+		t.Fatalf("expected failover within the top priority, got %s", second)
+	}
+	rpcs[first].err = nil
+	m.checkHealth(context.Background())
+	for i := 0; i < 4; i++ {
+		if got := call(t, m); got != second {
+			t.Fatalf("recovered endpoint should not take over the primary %s, got %s", second, got)
+		}
+	}
+}
+
+func TestMultiRPCCallerContext(t *testing.T) {
+	a := &fakeRPC{name: "a", head: 100}
+	b := &fakeRPC{name: "b", head: 100}
+	m, _ := testMultiRPC(a, b)
+	m.endpoints[1].priority = 1
+	m.checkHealth(context.Background())
+
+	// 调用方取消导致的失败不切换节点，也不把节点记为故障
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.err = context.Canceled
+	var name string
+	if err := m.CallContext(ctx, &name, "eth_chainId"); !errors.Is(err, context.Canceled) || name != "" {
+		t.Fatalf("expected caller cancellation without failover, got %q %v", name, err)
+	}
+	if m.endpoints[0].state().failures != 0 {
+		t.Fatal("caller cancellation should not mark the endpoint failed")
+	}
+
+	// 调用方 ctx 仍有效时，节点自身超时切换到下一个节点
+	a.err = context.DeadlineExceeded
+	if got := call(t, m); got != "b" {
+		t.Fatalf("expected failover after endpoint timeout, got %s", got)
+	}
+}
+
+func TestMultiRPCFailover(t *testing.T) {
+	a := &fakeRPC{name: "a", head: 100}
+	m, _ := testMultiRPC(a, &fakeRPC{name: "b", head: 100})
+	m.endpoints[1].priority = 1
+	m.checkHealth(context.Background())
+
+	a.err = errors.New("connection refused")
+	if got := call(t, m); got != "b" {
+		t.Fatalf("expected failover to b, got %s", got)
+	}
+	// 失败的节点在下次健康检查成功前不再参与路由
+	a.err = nil
+	if got := call(t, m); got != "b" {
+		t.Fatalf("failed endpoint should be skipped until next health check, got %s", got)
+	}
+	m.checkHealth(context.Background())
+	if got := call(t, m); got != "a" {
+		t.Fatalf("recovered endpoint should be preferred again, got %s", got)
+	}
+
+	// 节点返回的 JSON-RPC 错误直接返回，不切换节点
+	a.err = jsonError{}
+	var name string
+	if err := m.CallContext(context.Background(), &name, "eth_call"); !errors.Is(err, a.err) {
+		t.Fatalf("expected json-rpc error to be returned, got %v", err)
+	}
+}
+
+func TestMultiRPCAllFailed(t *testing.T) {
+	m, _ := testMultiRPC(&fakeRPC{name: "a", err: errors.New("a down")}, &fakeRPC{name: "b", err: errors.New("b down")})
+	m.checkHealth(context.Background())
+	var name string
+	err := m.CallContext(context.Background(), &name, "eth_chainId")
+	if err == nil || !strings.Contains(err.Error(), "a: a down") || !strings.Contains(err.Error(), "b: b down") {
+		t.Fatalf("expected errors from all endpoints, got %v", err)
+	}
+}
+
+func TestMultiRPCLagAndStuck(t *testing.T) {
+	a := &fakeRPC{name: "a", head: 100}
+	b := &fakeRPC{name: "b", head: 100}
+	c := &fakeRPC{name: "c", head: 100}
+	m, now := testMultiRPC(a, b, c)
+	m.checkHealth(context.Background())
+
+	// a 落后超过 2 个区块
+	b.head, c.head = 103, 103
+	m.checkHealth(context.Background())
+	for i := 0; i < 4; i++ {
+		if got := call(t, m); got == "a" {
+			t.Fatal("lagging endpoint should be excluded")
+		}
+	}
+
+	// b 高度长时间不变，a 追上并持续出块
+	*now = now.Add(40 * time.Second)
+	a.head, c.head = 104, 104
+	m.checkHealth(context.Background())
+	*now = now.Add(40 * time.Second)
+	a.head, c.head = 105, 105
+	m.checkHealth(context.Background())
+	for i := 0; i < 4; i++ {
+		if got := call(t, m); got == "b" {
+			t.Fatal("stuck endpoint should be excluded")
+		}
+	}
+
+	batch := []rpc.BatchElem{{Method: "eth_chainId", Result: new(string)}}
+	if err := m.BatchCallContext(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if got := *batch[0].Result.(*string); got == "b" {
+		t.Fatal("stuck endpoint should be excluded from batch calls")
+	}
+}
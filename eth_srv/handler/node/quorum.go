@@ -0,0 +1,232 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrQuorumMismatch 参与投票的节点返回了不一致的结果，可能有节点作恶或处于分叉链上，需要人工介入
+	ErrQuorumMismatch = errors.New("quorum endpoints disagree")
+	// ErrQuorumNotReached 成功返回结果的节点数不足，稍后重试
+	ErrQuorumNotReached = errors.New("quorum not reached")
+)
+
+// QuorumMismatch 投票节点对同一查询返回了不同结果
+type QuorumMismatch struct {
+	What    string            // 查询对象，如 receipt 0x... 或 header 100
+	Results map[string]string // 节点地址到各自返回的结果
+}
+
+type quorumMember struct {
+	url    string
+	client EthClient
+}
+
+// QuorumClient 入账依赖的收据、指定高度和 safe/finalized 的区块头同时向全部节点查询，至少 quorum 个节点返回且结果完全一致才接受，
+// 防止单个节点作恶或落后导致错误入账。其他方法由内嵌的客户端处理
+type QuorumClient struct {
+	EthClient
+	members    []quorumMember
+	quorum     int
+	mismatches atomic.Uint64
+	onMismatch func(QuorumMismatch)
+}
+
+// DialQuorumClient 分别连接每个节点用于投票，quorum 不能超过节点数量
+func DialQuorumClient(ctx context.Context, primary EthClient, endpoints []RPCEndpoint, quorum int) (*QuorumClient, error) {
+	if quorum <= 0 || quorum > len(endpoints) {
+		return nil, fmt.Errorf("quorum %d must be between 1 and the number of endpoints %d", quorum, len(endpoints))
+	}
+	members := make([]quorumMember, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		conn, err := DialRPC(ctx, endpoint.Url, endpoint.Limits)
+		if err != nil {
+			for _, member := range members {
+				member.client.Close()
+			}
+			return nil, fmt.Errorf("unable to dial quorum endpoint %s: %w", endpoint.Url, err)
+		}
+		members = append(members, quorumMember{url: endpoint.Url, client: NewEthClient(conn)})
+	}
+	return newQuorumClient(primary, members, quorum), nil
+}
+
+func newQuorumClient(primary EthClient, members []quorumMember, quorum int) *QuorumClient {
+	return &QuorumClient{EthClient: primary, members: members, quorum: quorum}
+}
+
+// OnMismatch 设置投票结果不一致时的告警回调，在查询所在协程同步调用，需要在开始查询前设置
+func (q *QuorumClient) OnMismatch(handler func(QuorumMismatch)) {
+	q.onMismatch = handler
+}
+
+// Mismatches 累计的投票不一致次数，供监控采集
+func (q *QuorumClient) Mismatches() uint64 {
+	return q.mismatches.Load()
+}
+
+// mismatch 记录一次投票不一致并触发告警
+func (q *QuorumClient) mismatch(what string, results map[string]string) {
+	q.mismatches.Add(1)
+	log.Error("quorum endpoints disagree", "what", what, "results", results)
+	if q.onMismatch != nil {
+		q.onMismatch(QuorumMismatch{What: what, Results: results})
+	}
+}
+
+type vote[T any] struct {
+	member quorumMember
+	value  T
+	err    error
+}
+
+// ask 并发向全部节点查询
+func ask[T any](members []quorumMember, query func(EthClient) (T, error)) []vote[T] {
+	votes := make([]vote[T], len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member quorumMember) {
+			defer wg.Done()
+			value, err := query(member.client)
+			votes[i] = vote[T]{member: member, value: value, err: err}
+		}(i, member)
+	}
+	wg.Wait()
+	return votes
+}
+
+func (q *QuorumClient) TxReceiptByHash(hash common.Hash) (*types.Receipt, error) {
+	return q.TxReceiptByHashContext(context.Background(), hash)
+}
+
+func (q *QuorumClient) TxReceiptByHashContext(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	votes := ask(q.members, func(client EthClient) (*types.Receipt, error) {
+		return client.TxReceiptByHashContext(ctx, hash)
+	})
+	var (
+		agreed *vote[*types.Receipt]
+		count  int
+		errs   error
+	)
+	for i := range votes {
+		v := &votes[i]
+		if v.err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", v.member.url, v.err))
+			continue
+		}
+		if agreed == nil {
+			agreed = v
+		} else if v.value.BlockHash != agreed.value.BlockHash || v.value.Status != agreed.value.Status {
+			q.mismatch(fmt.Sprintf("receipt %s", hash), map[string]string{
+				agreed.member.url: fmt.Sprintf("block=%s status=%d", agreed.value.BlockHash, agreed.value.Status),
+				v.member.url:      fmt.Sprintf("block=%s status=%d", v.value.BlockHash, v.value.Status),
+			})
+			return nil, fmt.Errorf("%w: receipt %s", ErrQuorumMismatch, hash)
+		}
+		count++
+	}
+	if count < q.quorum {
+		return nil, fmt.Errorf("%w: receipt %s got %d of %d responses: %w", ErrQuorumNotReached, hash, count, q.quorum, errs)
+	}
+	return agreed.value, nil
+}
+
+func (q *QuorumClient) LatestSafeBlockHeader() (*types.Header, error) {
+	return q.LatestSafeBlockHeaderContext(context.Background())
+}
+
+func (q *QuorumClient) LatestSafeBlockHeaderContext(ctx context.Context) (*types.Header, error) {
+	return q.agreedHeader(ctx, "safe", func(client EthClient) (*types.Header, error) {
+		return client.LatestSafeBlockHeaderContext(ctx)
+	})
+}
+
+func (q *QuorumClient) LatestFinalizedBlockHeader() (*types.Header, error) {
+	return q.LatestFinalizedBlockHeaderContext(context.Background())
+}
+
+func (q *QuorumClient) LatestFinalizedBlockHeaderContext(ctx context.Context) (*types.Header, error) {
+	return q.agreedHeader(ctx, "finalized", func(client EthClient) (*types.Header, error) {
+		return client.LatestFinalizedBlockHeaderContext(ctx)
+	})
+}
+
+// BlockHeaderByNumber 指定高度时要求各节点在该高度上的区块哈希一致；查询最新区块时各节点的链头本就不同，由内嵌的客户端处理
+func (q *QuorumClient) BlockHeaderByNumber(number *big.Int) (*types.Header, error) {
+	return q.BlockHeaderByNumberContext(context.Background(), number)
+}
+
+func (q *QuorumClient) BlockHeaderByNumberContext(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return q.EthClient.BlockHeaderByNumberContext(ctx, nil)
+	}
+	votes := ask(q.members, func(client EthClient) (*types.Header, error) {
+		return client.BlockHeaderByNumberContext(ctx, number)
+	})
+	return q.agree(fmt.Sprintf("header %d", number), votes)
+}
+
+// agreedHeader 各节点的 safe/finalized 高度可能略有差异，取返回结果中的最低高度，要求各节点在该高度上的区块哈希一致
+func (q *QuorumClient) agreedHeader(ctx context.Context, tag string, latest func(EthClient) (*types.Header, error)) (*types.Header, error) {
+	votes := ask(q.members, latest)
+	var lowest *big.Int
+	for _, v := range votes {
+		if v.err == nil && (lowest == nil || v.value.Number.Cmp(lowest) < 0) {
+			lowest = v.value.Number
+		}
+	}
+	for i := range votes {
+		v := &votes[i]
+		if v.err == nil && v.value.Number.Cmp(lowest) != 0 {
+			v.value, v.err = v.member.client.BlockHeaderByNumberContext(ctx, lowest)
+		}
+	}
+	return q.agree(fmt.Sprintf("%s header %d", tag, lowest), votes)
+}
+
+// agree 至少 quorum 个节点返回且区块哈希完全一致时接受区块头
+func (q *QuorumClient) agree(what string, votes []vote[*types.Header]) (*types.Header, error) {
+	var (
+		agreed *vote[*types.Header]
+		count  int
+		errs   error
+	)
+	for i := range votes {
+		v := &votes[i]
+		if v.err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", v.member.url, v.err))
+			continue
+		}
+		if agreed == nil {
+			agreed = v
+		} else if v.value.Hash() != agreed.value.Hash() {
+			q.mismatch(what, map[string]string{
+				agreed.member.url: agreed.value.Hash().String(),
+				v.member.url:      v.value.Hash().String(),
+			})
+			return nil, fmt.Errorf("%w: %s", ErrQuorumMismatch, what)
+		}
+		count++
+	}
+	if count < q.quorum {
+		return nil, fmt.Errorf("%w: %s got %d of %d responses: %w", ErrQuorumNotReached, what, count, q.quorum, errs)
+	}
+	return agreed.value, nil
+}
+
+// Close 只关闭投票节点的连接，内嵌的客户端由创建方关闭
+func (q *QuorumClient) Close() {
+	for _, member := range q.members {
+		member.client.Close()
+	}
+}
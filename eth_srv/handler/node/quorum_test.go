@@ -0,0 +1,138 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeProvider 一个节点的视图，headers 按高度保存
+type fakeProvider struct {
+	EthClient
+	receipt   *types.Receipt
+	headers   map[uint64]*types.Header
+	finalized uint64
+	err       error
+}
+
+func (p *fakeProvider) TxReceiptByHashContext(context.Context, common.Hash) (*types.Receipt, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return p.receipt, nil
+}
+
+func (p *fakeProvider) LatestFinalizedBlockHeaderContext(context.Context) (*types.Header, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.headers[p.finalized], nil
+}
+
+func (p *fakeProvider) BlockHeaderByNumberContext(_ context.Context, number *big.Int) (*types.Header, error) {
+	header, ok := p.headers[number.Uint64()]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+func testQuorum(quorum int, providers ...*fakeProvider) *QuorumClient {
+	members := make([]quorumMember, 0, len(providers))
+	for i, p := range providers {
+		members = append(members, quorumMember{url: string(rune('a' + i)), client: p})
+	}
+	return newQuorumClient(nil, members, quorum)
+}
+
+func testHeaders(fork byte, numbers ...uint64) map[uint64]*types.Header {
+	headers := make(map[uint64]*types.Header, len(numbers))
+	for _, number := range numbers {
+		headers[number] = &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{fork}}
+	}
+	return headers
+}
+
+func TestQuorumReceipt(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	ok := &types.Receipt{BlockHash: common.HexToHash("0xaa"), Status: types.ReceiptStatusSuccessful}
+	failed := &types.Receipt{BlockHash: common.HexToHash("0xaa"), Status: types.ReceiptStatusFailed}
+	down := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		providers []*fakeProvider
+		wantErr   error
+	}{
+		{name: "agreed", providers: []*fakeProvider{{receipt: ok}, {receipt: ok}, {err: down}}},
+		{name: "status mismatch", providers: []*fakeProvider{{receipt: ok}, {receipt: failed}, {receipt: ok}}, wantErr: ErrQuorumMismatch},
+		{name: "not enough responses", providers: []*fakeProvider{{receipt: ok}, {}, {err: down}}, wantErr: ErrQuorumNotReached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, err := testQuorum(2, tt.providers...).TxReceiptByHash(hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && receipt != ok {
+				t.Fatalf("unexpected receipt %+v", receipt)
+			}
+		})
+	}
+}
+
+func TestQuorumFinalizedHeader(t *testing.T) {
+	// b 的 finalized 高度更高，按最低高度 10 比较
+	a := &fakeProvider{headers: testHeaders(0, 10), finalized: 10}
+	b := &fakeProvider{headers: testHeaders(0, 10, 11), finalized: 11}
+	header, err := testQuorum(2, a, b).LatestFinalizedBlockHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Number.Uint64() != 10 || header.Hash() != a.headers[10].Hash() {
+		t.Fatalf("expected agreed header 10, got %d", header.Number)
+	}
+
+	// c 在高度 10 上是另一条链
+	c := &fakeProvider{headers: testHeaders(1, 10, 11), finalized: 11}
+	q := testQuorum(2, a, b, c)
+	var alerts []QuorumMismatch
+	q.OnMismatch(func(m QuorumMismatch) { alerts = append(alerts, m) })
+	if _, err := q.LatestFinalizedBlockHeader(); !errors.Is(err, ErrQuorumMismatch) {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+	if q.Mismatches() != 1 || len(alerts) != 1 || len(alerts[0].Results) != 2 {
+		t.Fatalf("expected one alert with both results, got %d %+v", q.Mismatches(), alerts)
+	}
+}
+
+func TestQuorumHeaderByNumber(t *testing.T) {
+	// 主节点在高度 10 上处于另一条链，按高度查询时以投票节点一致的结果为准
+	primary := &fakeProvider{headers: testHeaders(1, 10)}
+	a := &fakeProvider{headers: testHeaders(0, 10)}
+	b := &fakeProvider{headers: testHeaders(0, 10)}
+	q := testQuorum(2, a, b)
+	q.EthClient = primary
+	header, err := q.BlockHeaderByNumber(big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Hash() != a.headers[10].Hash() {
+		t.Fatalf("expected the quorum header, got %s", header.Hash())
+	}
+
+	if _, err := testQuorum(2, a, primary).BlockHeaderByNumber(big.NewInt(10)); !errors.Is(err, ErrQuorumMismatch) {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+	if _, err := testQuorum(2, a, &fakeProvider{}).BlockHeaderByNumber(big.NewInt(10)); !errors.Is(err, ErrQuorumNotReached) {
+		t.Fatalf("expected quorum not reached, got %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrReorgTooDeep 重组深度超过了持久化窗口，无法自动找到公共祖先
+	ErrReorgTooDeep = errors.New("reorg deeper than persisted header window")
+	// ErrBrokenBatch 同一批次内的区块头不连续，通常是拉取过程中链发生了变化
+	ErrBrokenBatch = errors.New("headers in batch are not contiguous")
+)
+
+const (
+	minPollInterval = time.Second
+	maxPollInterval = 30 * time.Second
+	// subscribedPollInterval 订阅正常时的兜底轮询间隔，防止订阅无声中断
+	subscribedPollInterval = time.Minute
+)
+
+// BlockRef 区块的最小标识，用于通知被回滚的区块
+type BlockRef struct {
+	Number     *big.Int
+	Hash       common.Hash
+	ParentHash common.Hash
+	Timestamp  uint64
+}
+
+// BlockListener 扫块儿事件的消费者，deposit、withdraw 等模块实现该接口。
+// 回调在扫块儿协程中同步执行，返回错误时本批次会在下一轮重试，因此实现需要保证幂等。
+type BlockListener interface {
+	// BlockAdded 新区块进入规范链
+	BlockAdded(header *types.Header) error
+	// BlockReverted 区块因链重组被移出规范链，按从新到旧的顺序通知
+	BlockReverted(block BlockRef) error
+}
+
+// maxStepBatches 每轮扫块儿的区块数最多为批量请求上限的倍数，一轮请求拆分出的批量请求数保持在较小范围内
+const maxStepBatches = 4
+
+type ScannerConfig struct {
+	ChainId        uint64
+	StartingHeight uint64
+	BlocksStep     uint64 // 每轮扫描的区块数，不超过 MaxBatchSize 的 maxStepBatches 倍
+	ReorgWindow    uint64
+	LoopInterval   time.Duration // 出块时间未知时的轮询间隔
+	MaxBatchSize   int           // 节点单个批量请求的最大条数，默认同限流层的默认值
+}
+
+// Scanner 跟随链头扫块儿，维护持久化的区块头窗口并处理链重组
+type Scanner struct {
+	client    EthClient
+	store     BlockStore
+	cfg       ScannerConfig
+	listeners []BlockListener
+	heads     HeadSource
+	// pollInterval 没有订阅时的轮询间隔，按近期出块时间调整
+	pollInterval time.Duration
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+}
+
+func NewScanner(client EthClient, store BlockStore, cfg ScannerConfig, shutdown context.CancelCauseFunc) (*Scanner, error) {
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaultMaxBatchSize
+	}
+	if maxStep := uint64(cfg.MaxBatchSize * maxStepBatches); cfg.BlocksStep == 0 || cfg.BlocksStep > maxStep {
+		cfg.BlocksStep = maxStep
+	}
+	if cfg.ReorgWindow == 0 {
+		return nil, errors.New("reorg window must be greater than zero")
+	}
+	if cfg.LoopInterval == 0 {
+		cfg.LoopInterval = time.Second * 5
+	}
+	resCtx, resCancel := context.WithCancel(context.Background())
+
+	return &Scanner{
+		client:         client,
+		store:          store,
+		cfg:            cfg,
+		pollInterval:   cfg.LoopInterval,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{
+			HandleCrit: func(err error) {
+				shutdown(fmt.Errorf("critical error in scanner: %w", err))
+			},
+		},
+	}, nil
+}
+
+// AddListener 注册扫块儿事件的消费者，需要在 Start 之前调用
+func (s *Scanner) AddListener(listener BlockListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// SubscribeHeads 由新区块通知驱动扫块儿，订阅中断期间按出块时间轮询，需要在 Start 之前调用
+func (s *Scanner) SubscribeHeads(source HeadSource) {
+	s.heads = source
+}
+
+func (s *Scanner) Close() error {
+	var result error
+	s.resourceCancel()
+	if err := s.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("failed to await scanner: %w", err))
+	}
+	return result
+}
+
+func (s *Scanner) Start() error {
+	log.Info("start scanner......")
+	var heads <-chan struct{}
+	if s.heads != nil {
+		heads = s.heads.Heads()
+		s.tasks.Go(func() error {
+			s.heads.Run(s.resourceCtx)
+			return nil
+		})
+	}
+	s.tasks.Go(func() error {
+		timer := time.NewTimer(s.nextPoll())
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+			case <-heads:
+			case <-s.resourceCtx.Done():
+				log.Info("stop scanner......")
+				return nil
+			}
+			err := s.catchUp()
+			if errors.Is(err, ErrReorgTooDeep) {
+				s.tasks.HandleCrit(err)
+				return err
+			} else if err != nil {
+				log.Error("scan blocks fail", "err", err)
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(s.nextPoll())
+		}
+	})
+	return nil
+}
+
+// nextPoll 订阅正常时只做兜底轮询，否则按出块时间轮询
+func (s *Scanner) nextPoll() time.Duration {
+	if s.heads != nil && s.heads.Active() {
+		return subscribedPollInterval
+	}
+	return s.pollInterval
+}
+
+// catchUp 连续扫描直到追上链头，订阅断开重连后据此补齐中间的区块
+func (s *Scanner) catchUp() error {
+	for s.resourceCtx.Err() == nil {
+		caughtUp, err := s.scan()
+		if err != nil || caughtUp {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan 扫描一个批次：拉取窗口之后的区块头，检测到父哈希不一致时回滚到公共祖先
+func (s *Scanner) Scan() error {
+	_, err := s.scan()
+	return err
+}
+
+// scan 扫描一个批次，caughtUp 表示已经处理到链头
+func (s *Scanner) scan() (caughtUp bool, err error) {
+	latest, err := s.client.BlockHeaderByNumberContext(s.resourceCtx, nil)
+	if err != nil {
+		return false, fmt.Errorf("unable to query latest header: %w", err)
+	}
+	last, err := s.store.LatestBlock(s.cfg.ChainId)
+	if err != nil {
+		return false, fmt.Errorf("unable to query latest stored block: %w", err)
+	}
+
+	var from *big.Int
+	if last != nil {
+		from = new(big.Int).SetUint64(last.Number + 1)
+	} else if s.cfg.StartingHeight > 0 {
+		from = new(big.Int).SetUint64(s.cfg.StartingHeight)
+	} else {
+		from = new(big.Int).Set(latest.Number)
+	}
+	if from.Cmp(latest.Number) > 0 {
+		// 已追上链头
+		return true, nil
+	}
+	to := new(big.Int).Add(from, new(big.Int).SetUint64(s.cfg.BlocksStep-1))
+	if to.Cmp(latest.Number) > 0 {
+		to = latest.Number
+	}
+
+	headers, err := s.client.BlockHeadersByRangeContext(s.resourceCtx, from, to, uint(s.cfg.ChainId))
+	if err != nil {
+		return false, fmt.Errorf("unable to query headers [%d, %d]: %w", from, to, err)
+	}
+	if err := verifyHeaders(from, headers); err != nil {
+		return false, err
+	}
+
+	if last != nil && headers[0].ParentHash != common.HexToHash(last.Hash) {
+		log.Warn("reorg detected", "number", headers[0].Number, "parentHash", headers[0].ParentHash, "storedHash", last.Hash)
+		return false, s.rollback()
+	}
+
+	for i := range headers {
+		header := &headers[i]
+		for _, listener := range s.listeners {
+			if err := listener.BlockAdded(header); err != nil {
+				return false, fmt.Errorf("listener failed on block %d: %w", header.Number, err)
+			}
+		}
+		err := s.store.StoreBlock(&model.Block{
+			ChainId:    s.cfg.ChainId,
+			Number:     header.Number.Uint64(),
+			Hash:       header.Hash().String(),
+			ParentHash: header.ParentHash.String(),
+			Timestamp:  header.Time,
+		})
+		if err != nil {
+			return false, fmt.Errorf("unable to store block %d: %w", header.Number, err)
+		}
+	}
+	s.updatePollInterval(last, headers)
+
+	if tip := to.Uint64(); tip >= s.cfg.ReorgWindow {
+		if err := s.store.PruneBlocks(s.cfg.ChainId, tip-s.cfg.ReorgWindow+1); err != nil {
+			log.Warn("prune blocks fail", "err", err)
+		}
+	}
+	return to.Cmp(latest.Number) == 0, nil
+}
+
+// updatePollInterval 按本批次的平均出块时间调整轮询间隔，每个区块轮询两次
+func (s *Scanner) updatePollInterval(last *model.Block, headers []types.Header) {
+	first, count := headers[0].Time, uint64(len(headers)-1)
+	if last != nil {
+		first, count = last.Timestamp, uint64(len(headers))
+	}
+	newest := headers[len(headers)-1].Time
+	if count == 0 || newest < first {
+		return
+	}
+	blockTime := time.Duration(newest-first) * time.Second / time.Duration(count)
+	s.pollInterval = min(max(blockTime/2, minPollInterval), maxPollInterval)
+}
+
+// rollback 从窗口最新的区块向前回退，直到找到与链上哈希一致的公共祖先
+func (s *Scanner) rollback() error {
+	for {
+		last, err := s.store.LatestBlock(s.cfg.ChainId)
+		if err != nil {
+			return fmt.Errorf("unable to query latest stored block: %w", err)
+		}
+		if last == nil {
+			return ErrReorgTooDeep
+		}
+
+		number := new(big.Int).SetUint64(last.Number)
+		canonical, err := s.client.BlockHeaderByNumberContext(s.resourceCtx, number)
+		if err != nil {
+			return fmt.Errorf("unable to query header %d: %w", number, err)
+		}
+		if canonical.Hash() == common.HexToHash(last.Hash) {
+			log.Info("reorg rollback finished", "commonAncestor", last.Number, "hash", last.Hash)
+			return nil
+		}
+
+		ref := BlockRef{
+			Number:     number,
+			Hash:       common.HexToHash(last.Hash),
+			ParentHash: common.HexToHash(last.ParentHash),
+			Timestamp:  last.Timestamp,
+		}
+		for _, listener := range s.listeners {
+			if err := listener.BlockReverted(ref); err != nil {
+				return fmt.Errorf("listener failed on reverted block %d: %w", last.Number, err)
+			}
+		}
+		if err := s.store.DeleteBlock(s.cfg.ChainId, last.Number); err != nil {
+			return fmt.Errorf("unable to delete block %d: %w", last.Number, err)
+		}
+		log.Warn("block reverted", "number", last.Number, "hash", last.Hash)
+	}
+}
+
+// verifyHeaders 校验批次内区块头高度连续且父哈希相连
+func verifyHeaders(from *big.Int, headers []types.Header) error {
+	if len(headers) == 0 {
+		return fmt.Errorf("%w: empty batch", ErrBrokenBatch)
+	}
+	for i := range headers {
+		expected := new(big.Int).Add(from, big.NewInt(int64(i)))
+		if headers[i].Number == nil || headers[i].Number.Cmp(expected) != 0 {
+			return fmt.Errorf("%w: expected block %d at index %d", ErrBrokenBatch, expected, i)
+		}
+		if i > 0 && headers[i].ParentHash != headers[i-1].Hash() {
+			return fmt.Errorf("%w: block %d does not link to its parent", ErrBrokenBatch, expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeChain 内存中的链，只实现扫块儿用到的方法
+type fakeChain struct {
+	EthClient
+	headers []types.Header
+}
+
+// extend 在 parent 之后追加 n 个区块，fork 用于区分分叉链上同高度的区块
+func (c *fakeChain) extend(n int, fork byte) {
+	for i := 0; i < n; i++ {
+		header := types.Header{Number: big.NewInt(int64(len(c.headers))), Time: uint64(len(c.headers)) * 12, Extra: []byte{fork}}
+		if len(c.headers) > 0 {
+			header.ParentHash = c.headers[len(c.headers)-1].Hash()
+		}
+		c.headers = append(c.headers, header)
+	}
+}
+
+func (c *fakeChain) reorg(depth, n int, fork byte) {
+	c.headers = c.headers[:len(c.headers)-depth]
+	c.extend(n, fork)
+}
+
+func (c *fakeChain) BlockHeaderByNumberContext(_ context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return &c.headers[len(c.headers)-1], nil
+	}
+	if number.Uint64() >= uint64(len(c.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return &c.headers[number.Uint64()], nil
+}
+
+func (c *fakeChain) BlockHeadersByRangeContext(_ context.Context, start, end *big.Int, _ uint) ([]types.Header, error) {
+	return append([]types.Header{}, c.headers[start.Uint64():end.Uint64()+1]...), nil
+}
+
+type memBlockStore struct {
+	blocks map[uint64]model.Block
+}
+
+func (s *memBlockStore) LatestBlock(uint64) (*model.Block, error) {
+	if len(s.blocks) == 0 {
+		return nil, nil
+	}
+	var latest uint64
+	for number := range s.blocks {
+		if number > latest {
+			latest = number
+		}
+	}
+	block := s.blocks[latest]
+	return &block, nil
+}
+
+func (s *memBlockStore) StoreBlock(block *model.Block) error {
+	s.blocks[block.Number] = *block
+	return nil
+}
+
+func (s *memBlockStore) DeleteBlock(_, number uint64) error {
+	delete(s.blocks, number)
+	return nil
+}
+
+func (s *memBlockStore) PruneBlocks(_, number uint64) error {
+	for n := range s.blocks {
+		if n < number {
+			delete(s.blocks, n)
+		}
+	}
+	return nil
+}
+
+type recordListener struct {
+	added    []common.Hash
+	reverted []common.Hash
+}
+
+func (l *recordListener) BlockAdded(header *types.Header) error {
+	l.added = append(l.added, header.Hash())
+	return nil
+}
+
+func (l *recordListener) BlockReverted(block BlockRef) error {
+	l.reverted = append(l.reverted, block.Hash)
+	return nil
+}
+
+func newTestScanner(t *testing.T, chain *fakeChain, window uint64) (*Scanner, *memBlockStore, *recordListener) {
+	store := &memBlockStore{blocks: map[uint64]model.Block{}}
+	scanner, err := NewScanner(chain, store, ScannerConfig{ChainId: 1, StartingHeight: 1, BlocksStep: 4, ReorgWindow: window}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &recordListener{}
+	scanner.AddListener(listener)
+	return scanner, store, listener
+}
+
+func scanToHead(t *testing.T, scanner *Scanner, rounds int) {
+	for i := 0; i < rounds; i++ {
+		if err := scanner.Scan(); err != nil {
+			t.Fatalf("scan round %d: %v", i, err)
+		}
+	}
+}
+
+func TestScannerFollowsHeadInBatches(t *testing.T) {
+	chain := &fakeChain{}
+	chain.extend(11, 0)
+	scanner, store, listener := newTestScanner(t, chain, 100)
+
+	// 区块 1~10 每批 4 个，需要三轮
+	scanToHead(t, scanner, 3)
+	if len(listener.added) != 10 {
+		t.Fatalf("expected 10 added blocks, got %d", len(listener.added))
+	}
+	latest, _ := store.LatestBlock(1)
+	if latest.Number != 10 || latest.Hash != chain.headers[10].Hash().String() {
+		t.Fatalf("unexpected latest stored block %+v", latest)
+	}
+
+	// 追上链头后不再产生事件
+	scanToHead(t, scanner, 1)
+	if len(listener.added) != 10 {
+		t.Fatalf("expected no new blocks, got %d", len(listener.added))
+	}
+}
+
+func TestScannerRollsBackToCommonAncestor(t *testing.T) {
+	chain := &fakeChain{}
+	chain.extend(9, 0)
+	scanner, store, listener := newTestScanner(t, chain, 100)
+	scanToHead(t, scanner, 2)
+
+	orphaned := []common.Hash{chain.headers[8].Hash(), chain.headers[7].Hash(), chain.headers[6].Hash()}
+	chain.reorg(3, 5, 1)
+
+	// 第一轮检测到重组并回滚，第二、三轮补齐新链
+	scanToHead(t, scanner, 3)
+	if len(listener.reverted) != 3 {
+		t.Fatalf("expected 3 reverted blocks, got %d", len(listener.reverted))
+	}
+	for i, hash := range orphaned {
+		if listener.reverted[i] != hash {
+			t.Fatalf("reverted[%d] = %s, want %s", i, listener.reverted[i], hash)
+		}
+	}
+
+	numbers := make([]int, 0, len(store.blocks))
+	for number, block := range store.blocks {
+		if block.Hash != chain.headers[number].Hash().String() {
+			t.Fatalf("stored block %d is not canonical", number)
+		}
+		numbers = append(numbers, int(number))
+	}
+	sort.Ints(numbers)
+	if numbers[len(numbers)-1] != len(chain.headers)-1 {
+		t.Fatalf("scanner did not reach the new head, latest %d", numbers[len(numbers)-1])
+	}
+}
+
+func TestScannerPrunesWindowAndDetectsDeepReorg(t *testing.T) {
+	chain := &fakeChain{}
+	chain.extend(9, 0)
+	scanner, store, _ := newTestScanner(t, chain, 3)
+	scanToHead(t, scanner, 2)
+	if len(store.blocks) != 3 {
+		t.Fatalf("expected window of 3 blocks, got %d", len(store.blocks))
+	}
+
+	chain.reorg(5, 6, 1)
+	if err := scanner.Scan(); err != ErrReorgTooDeep {
+		t.Fatalf("expected ErrReorgTooDeep, got %v", err)
+	}
+}
+
+func TestScannerCatchUp(t *testing.T) {
+	chain := &fakeChain{}
+	chain.extend(11, 0)
+	scanner, _, listener := newTestScanner(t, chain, 100)
+
+	// 订阅断开期间产生的区块一次追赶完成，不等待下一轮
+	if err := scanner.catchUp(); err != nil {
+		t.Fatal(err)
+	}
+	if len(listener.added) != 10 {
+		t.Fatalf("expected 10 added blocks, got %d", len(listener.added))
+	}
+	// 12 秒出块，每 6 秒轮询一次
+	if scanner.nextPoll() != 6*time.Second {
+		t.Fatalf("unexpected poll interval %s", scanner.nextPoll())
+	}
+
+	scanner.SubscribeHeads(&fakeHeads{active: true})
+	if scanner.nextPoll() != subscribedPollInterval {
+		t.Fatalf("expected fallback poll interval while subscribed, got %s", scanner.nextPoll())
+	}
+}
+
+type fakeHeads struct {
+	active bool
+}
+
+func (h *fakeHeads) Run(context.Context)    {}
+func (h *fakeHeads) Heads() <-chan struct{} { return nil }
+func (h *fakeHeads) Active() bool           { return h.active }
+
+func TestScannerCapsBlocksStep(t *testing.T) {
+	tests := []struct {
+		step  uint64
+		batch int
+		want  uint64
+	}{
+		{step: 0, batch: 0, want: maxStepBatches * defaultMaxBatchSize},
+		{step: 10000, batch: 50, want: maxStepBatches * 50},
+		{step: 20, batch: 50, want: 20},
+	}
+	for _, tt := range tests {
+		scanner, err := NewScanner(&fakeChain{}, &memBlockStore{}, ScannerConfig{ChainId: 1, BlocksStep: tt.step, ReorgWindow: 10, MaxBatchSize: tt.batch}, func(error) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scanner.cfg.BlocksStep != tt.want {
+			t.Fatalf("step %d batch %d: got %d, want %d", tt.step, tt.batch, scanner.cfg.BlocksStep, tt.want)
+		}
+	}
+}
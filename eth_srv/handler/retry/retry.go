@@ -0,0 +1,218 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	retryBatchSize = 20
+	maxErrorLength = 1024
+)
+
+// Handler 重新执行 refId 对应的任务，返回错误时按退避策略安排下一次重试
+type Handler func(refId uint64) error
+
+type Retry struct {
+	store          Store
+	maxAttempts    uint32
+	baseDelay      time.Duration
+	maxDelay       time.Duration
+	handlersLock   sync.RWMutex
+	handlers       map[string]Handler
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+}
+
+func NewRetry(store Store, cfg config.RetryConfig, shutdown context.CancelCauseFunc) (*Retry, error) {
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = time.Second * 5
+	}
+	if cfg.MaxDelay < cfg.BaseDelay {
+		cfg.MaxDelay = cfg.BaseDelay
+	}
+	resCtx, resCancel := context.WithCancel(context.Background())
+
+	return &Retry{
+		store:          store,
+		maxAttempts:    cfg.MaxAttempts,
+		baseDelay:      cfg.BaseDelay,
+		maxDelay:       cfg.MaxDelay,
+		handlers:       make(map[string]Handler),
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{
+			HandleCrit: func(err error) {
+				shutdown(fmt.Errorf("critical error in retry: %w", err))
+			},
+		},
+	}, nil
+}
+
+// Register 登记 kind 对应的处理函数，需要在 Start 之前完成
+func (r *Retry) Register(kind string, handler Handler) {
+	r.handlersLock.Lock()
+	defer r.handlersLock.Unlock()
+	r.handlers[kind] = handler
+}
+
+func (r *Retry) Close() error {
+	var result error
+	r.resourceCancel()
+	if err := r.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("failed to await retry: %w", err))
+	}
+	return result
+}
+
+func (r *Retry) Start() error {
+	log.Info("start retry......")
+	tickerRetryWorker := time.NewTicker(time.Second * 5)
+	r.tasks.Go(func() error {
+		defer tickerRetryWorker.Stop()
+		for {
+			select {
+			case <-tickerRetryWorker.C:
+				if err := r.runDue(); err != nil {
+					log.Error("run retry tasks fail", "err", err)
+				}
+			case <-r.resourceCtx.Done():
+				log.Info("stop retry......")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+// Submit 登记失败的任务，第一次重试在退避时间之后执行
+func (r *Retry) Submit(kind string, refId uint64, cause error) error {
+	task := &model.RetryTask{
+		Kind:        kind,
+		RefId:       refId,
+		Status:      global_const.RetryStatusPending,
+		MaxAttempts: r.maxAttempts,
+		NextRunAt:   time.Now().Add(backoff(1, r.baseDelay, r.maxDelay)),
+		LastError:   errorMessage(cause),
+	}
+	if err := r.store.SubmitTask(task); err != nil {
+		return fmt.Errorf("unable to submit %s retry of %d: %w", kind, refId, err)
+	}
+	log.Warn("retry task submitted", "kind", kind, "refId", refId, "err", cause)
+	return nil
+}
+
+// Active 对应记录是否已经交给重试模块处理（等待重试、已进入死信或已放弃）。
+// 死信同样视为已接管，常规流程会跳过该记录，直到运营人员通过 RequeueRetryTask 重新执行；
+// 通过 AbandonRetryTask 放弃后记录同样被跳过，不会被常规流程重新执行
+func (r *Retry) Active(kind string, refId uint64) (bool, error) {
+	return r.store.Active(kind, refId)
+}
+
+// Tasks 列出指定状态的任务
+func (r *Retry) Tasks(status uint8, limit int) ([]model.RetryTask, error) {
+	return r.store.Tasks(status, limit)
+}
+
+// Requeue 运营人员把死信或已放弃的任务重新放回队列，立即重试
+func (r *Retry) Requeue(id uint64) error {
+	return r.store.Requeue(id, time.Now())
+}
+
+// Abandon 运营人员放弃任务，之后不再自动重试，常规流程也不再处理对应记录
+func (r *Retry) Abandon(id uint64) error {
+	return r.store.Abandon(id)
+}
+
+func (r *Retry) runDue() error {
+	due, err := r.store.DueTasks(time.Now(), retryBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		if err := r.run(&due[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run 领取并执行一个到期任务，执行期间任务被租约推迟，进程中途退出时租约到期后会再次执行
+func (r *Retry) run(task *model.RetryTask) error {
+	claimed, err := r.store.ClaimTask(task, time.Now().Add(r.maxDelay))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	r.handlersLock.RLock()
+	handler, ok := r.handlers[task.Kind]
+	r.handlersLock.RUnlock()
+	if !ok {
+		err = fmt.Errorf("no handler for retry kind %q", task.Kind)
+	} else {
+		err = handler(task.RefId)
+	}
+
+	attempts := task.Attempts + 1
+	if err == nil {
+		log.Info("retry task done", "kind", task.Kind, "refId", task.RefId, "attempts", attempts)
+		return r.store.UpdateTask(task.ID, map[string]interface{}{
+			"status":   global_const.RetryStatusDone,
+			"attempts": attempts,
+		})
+	}
+
+	fields := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": errorMessage(err),
+	}
+	if attempts >= task.MaxAttempts {
+		fields["status"] = global_const.RetryStatusDead
+		log.Error("retry task dead", "kind", task.Kind, "refId", task.RefId, "attempts", attempts, "err", err)
+	} else {
+		fields["next_run_at"] = time.Now().Add(backoff(attempts+1, r.baseDelay, r.maxDelay))
+		log.Warn("retry task failed", "kind", task.Kind, "refId", task.RefId, "attempts", attempts, "err", err)
+	}
+	return r.store.UpdateTask(task.ID, fields)
+}
+
+// backoff 第 attempt 次执行前的等待时间：base * 2^(attempt-1)，不超过 max。
+// 一半固定一半随机，避免同时失败的任务在同一时刻集中重试
+func backoff(attempt uint32, base, max time.Duration) time.Duration {
+	delay := max
+	if attempt > 0 && attempt < 32 {
+		if d := base << (attempt - 1); d > 0 && d < max {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+	return msg
+}
@@ -0,0 +1,261 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+)
+
+type memStore struct {
+	tasks []model.RetryTask
+}
+
+func (s *memStore) SubmitTask(task *model.RetryTask) error {
+	for i := range s.tasks {
+		if s.tasks[i].Kind == task.Kind && s.tasks[i].RefId == task.RefId && isActive(s.tasks[i].Status) {
+			s.tasks[i].LastError = task.LastError
+			*task = s.tasks[i]
+			return nil
+		}
+	}
+	task.ID = uint64(len(s.tasks) + 1)
+	s.tasks = append(s.tasks, *task)
+	return nil
+}
+
+func (s *memStore) Active(kind string, refId uint64) (bool, error) {
+	for _, task := range s.tasks {
+		if task.Kind == kind && task.RefId == refId && (isActive(task.Status) || task.Status == global_const.RetryStatusAbandoned) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memStore) DueTasks(now time.Time, limit int) ([]model.RetryTask, error) {
+	var due []model.RetryTask
+	for _, task := range s.tasks {
+		if task.Status == global_const.RetryStatusPending && !task.NextRunAt.After(now) {
+			due = append(due, task)
+		}
+	}
+	return due, nil
+}
+
+func (s *memStore) ClaimTask(task *model.RetryTask, lease time.Time) (bool, error) {
+	for i := range s.tasks {
+		if s.tasks[i].ID == task.ID && s.tasks[i].NextRunAt.Equal(task.NextRunAt) {
+			s.tasks[i].NextRunAt = lease
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memStore) UpdateTask(id uint64, fields map[string]interface{}) error {
+	for i := range s.tasks {
+		if s.tasks[i].ID != id {
+			continue
+		}
+		for k, v := range fields {
+			switch k {
+			case "status":
+				s.tasks[i].Status = uint8(v.(int))
+			case "attempts":
+				s.tasks[i].Attempts = v.(uint32)
+			case "next_run_at":
+				s.tasks[i].NextRunAt = v.(time.Time)
+			case "last_error":
+				s.tasks[i].LastError = v.(string)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memStore) Tasks(status uint8, limit int) ([]model.RetryTask, error) {
+	return nil, nil
+}
+
+func (s *memStore) Requeue(id uint64, now time.Time) error {
+	for i := range s.tasks {
+		if s.tasks[i].ID == id && (s.tasks[i].Status == global_const.RetryStatusDead || s.tasks[i].Status == global_const.RetryStatusAbandoned) {
+			s.tasks[i].Status = global_const.RetryStatusPending
+			s.tasks[i].Attempts = 0
+			s.tasks[i].NextRunAt = now
+			return nil
+		}
+	}
+	return ErrTaskState
+}
+
+func (s *memStore) Abandon(id uint64) error {
+	for i := range s.tasks {
+		if s.tasks[i].ID == id && isActive(s.tasks[i].Status) {
+			s.tasks[i].Status = global_const.RetryStatusAbandoned
+			return nil
+		}
+	}
+	return ErrTaskState
+}
+
+func isActive(status uint8) bool {
+	return status == global_const.RetryStatusPending || status == global_const.RetryStatusDead
+}
+
+// makeDue 把任务的下次执行时间提前到当前，模拟退避时间已过
+func (s *memStore) makeDue() {
+	for i := range s.tasks {
+		s.tasks[i].NextRunAt = time.Now().Add(-time.Second)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := time.Second, time.Minute
+	for attempt := uint32(1); attempt <= 40; attempt++ {
+		want := max
+		if attempt <= 6 {
+			want = base << (attempt - 1)
+		}
+		for i := 0; i < 50; i++ {
+			got := backoff(attempt, base, max)
+			if got < want/2 || got > want {
+				t.Fatalf("attempt %d: backoff %s out of [%s, %s]", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestRetryDeadLetter(t *testing.T) {
+	store := &memStore{}
+	r, err := NewRetry(store, config.RetryConfig{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	r.Register(global_const.RetryKindWithdrawBroadcast, func(refId uint64) error {
+		calls++
+		return errors.New("connection refused")
+	})
+
+	if err := r.Submit(global_const.RetryKindWithdrawBroadcast, 42, errors.New("connection refused")); err != nil {
+		t.Fatal(err)
+	}
+	// 同一记录重复提交不会产生第二个任务
+	if err := r.Submit(global_const.RetryKindWithdrawBroadcast, 42, errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.tasks) != 1 || store.tasks[0].LastError != "timeout" {
+		t.Fatalf("unexpected tasks %+v", store.tasks)
+	}
+
+	// 未到期的任务不会执行
+	if err := r.runDue(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatalf("task ran before its backoff, calls = %d", calls)
+	}
+
+	for i := 0; i < 3; i++ {
+		store.makeDue()
+		if err := r.runDue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	task := store.tasks[0]
+	if calls != 3 || task.Attempts != 3 || task.Status != global_const.RetryStatusDead {
+		t.Fatalf("expected dead task after 3 attempts, calls %d task %+v", calls, task)
+	}
+	if active, _ := r.Active(global_const.RetryKindWithdrawBroadcast, 42); !active {
+		t.Fatal("dead task should keep the record out of the regular loop")
+	}
+
+	store.makeDue()
+	if err := r.runDue(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatal("dead task should not run again")
+	}
+
+	if err := r.Requeue(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	r.Register(global_const.RetryKindWithdrawBroadcast, func(refId uint64) error {
+		calls++
+		return nil
+	})
+	if err := r.runDue(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 || store.tasks[0].Status != global_const.RetryStatusDone {
+		t.Fatalf("requeued task should run and finish, calls %d task %+v", calls, store.tasks[0])
+	}
+	if err := r.Abandon(task.ID); !errors.Is(err, ErrTaskState) {
+		t.Fatalf("done task cannot be abandoned, got %v", err)
+	}
+}
+
+func TestRetryUnknownKind(t *testing.T) {
+	store := &memStore{}
+	r, err := NewRetry(store, config.RetryConfig{MaxAttempts: 5}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Submit("unknown", 1, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	store.makeDue()
+	if err := r.runDue(); err != nil {
+		t.Fatal(err)
+	}
+	task := store.tasks[0]
+	if task.Status != global_const.RetryStatusPending || task.Attempts != 1 || !task.NextRunAt.After(time.Now()) {
+		t.Fatalf("task without handler should be rescheduled, got %+v", task)
+	}
+}
+
+func TestAbandonKeepsRecordOutOfRegularLoop(t *testing.T) {
+	store := &memStore{}
+	r, err := NewRetry(store, config.RetryConfig{MaxAttempts: 5}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	r.Register(global_const.RetryKindWithdrawBroadcast, func(uint64) error {
+		calls++
+		return errors.New("still failing")
+	})
+	if err := r.Submit(global_const.RetryKindWithdrawBroadcast, 42, errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Abandon(store.tasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	// 放弃后既不自动重试，常规流程（如补发已签名的提现）也会跳过该记录
+	store.makeDue()
+	if err := r.runDue(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatalf("abandoned task ran %d times", calls)
+	}
+	if active, _ := r.Active(global_const.RetryKindWithdrawBroadcast, 42); !active {
+		t.Fatal("abandoned task should keep the record out of the regular loop")
+	}
+
+	if err := r.Requeue(store.tasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.runDue(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("requeued task should run again, calls %d", calls)
+	}
+}
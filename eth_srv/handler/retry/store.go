@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTaskState 任务当前状态不允许该操作
+var ErrTaskState = errors.New("retry task state does not allow this operation")
+
+// 等待重试或已进入死信的任务仍由重试模块接管，同一记录再次失败时合并到这些任务上。
+// 死信不会自动恢复，只能由运营人员 Requeue 重新执行，或 Abandon 放弃
+var activeStatuses = []uint8{global_const.RetryStatusPending, global_const.RetryStatusDead}
+
+// 放弃的任务是终止标记，对应记录和活跃任务一样不再进入常规流程（广播、入账、归集），
+// 直到运营人员 Requeue
+var heldStatuses = []uint8{global_const.RetryStatusPending, global_const.RetryStatusDead, global_const.RetryStatusAbandoned}
+
+// Store 重试模块的持久化接口
+type Store interface {
+	// SubmitTask 登记失败任务。同一 kind 和 refId 已有活跃任务时只更新错误信息，不重复登记
+	SubmitTask(task *model.RetryTask) error
+	// Active 同一 kind 和 refId 是否有等待重试、已进入死信或已放弃的任务
+	Active(kind string, refId uint64) (bool, error)
+	// DueTasks 返回到期等待重试的任务
+	DueTasks(now time.Time, limit int) ([]model.RetryTask, error)
+	// ClaimTask 把到期任务的下次执行时间推迟到 lease，多个进程只有一个能领取成功
+	ClaimTask(task *model.RetryTask, lease time.Time) (bool, error)
+	// UpdateTask 记录一次执行的结果
+	UpdateTask(id uint64, fields map[string]interface{}) error
+	// Tasks 按状态列出任务，供运营人员查看
+	Tasks(status uint8, limit int) ([]model.RetryTask, error)
+	// Requeue 把死信或已放弃的任务重新放回队列并清零重试次数
+	Requeue(id uint64, now time.Time) error
+	// Abandon 放弃等待重试或死信中的任务，对应记录之后不再被常规流程处理
+	Abandon(id uint64) error
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) SubmitTask(task *model.RetryTask) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing model.RetryTask
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND ref_id = ? AND status IN ?", task.Kind, task.RefId, activeStatuses).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(task).Error
+		} else if err != nil {
+			return err
+		}
+		*task = existing
+		return tx.Model(&model.RetryTask{}).Where("id = ?", existing.ID).Update("last_error", task.LastError).Error
+	})
+}
+
+func (s *store) Active(kind string, refId uint64) (bool, error) {
+	var count int64
+	err := s.db.Model(&model.RetryTask{}).Where("kind = ? AND ref_id = ? AND status IN ?", kind, refId, heldStatuses).Count(&count).Error
+	return count > 0, err
+}
+
+func (s *store) DueTasks(now time.Time, limit int) ([]model.RetryTask, error) {
+	var tasks []model.RetryTask
+	err := s.db.Where("status = ? AND next_run_at <= ?", global_const.RetryStatusPending, now).
+		Order("next_run_at ASC").Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *store) ClaimTask(task *model.RetryTask, lease time.Time) (bool, error) {
+	result := s.db.Model(&model.RetryTask{}).
+		Where("id = ? AND status = ? AND next_run_at = ?", task.ID, global_const.RetryStatusPending, task.NextRunAt).
+		Update("next_run_at", lease)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (s *store) UpdateTask(id uint64, fields map[string]interface{}) error {
+	return s.db.Model(&model.RetryTask{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (s *store) Tasks(status uint8, limit int) ([]model.RetryTask, error) {
+	var tasks []model.RetryTask
+	err := s.db.Where("status = ?", status).Order("id DESC").Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+func (s *store) Requeue(id uint64, now time.Time) error {
+	result := s.db.Model(&model.RetryTask{}).
+		Where("id = ? AND status IN ?", id, []uint8{global_const.RetryStatusDead, global_const.RetryStatusAbandoned}).
+		Updates(map[string]interface{}{
+			"status":      global_const.RetryStatusPending,
+			"attempts":    0,
+			"next_run_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskState
+	}
+	return nil
+}
+
+func (s *store) Abandon(id uint64) error {
+	result := s.db.Model(&model.RetryTask{}).
+		Where("id = ? AND status IN ?", id, activeStatuses).
+		Update("status", global_const.RetryStatusAbandoned)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskState
+	}
+	return nil
+}
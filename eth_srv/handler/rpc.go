@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"sort"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// dialEthClient 按配置连接链节点，配置了多个节点时按优先级路由并自动切换，否则使用 rpc_url
+func dialEthClient(ctx context.Context, cnf config.EthConfig) (node.EthClient, error) {
+	if len(cnf.Rpc.Endpoints) == 0 {
+		return node.DialEthClient(ctx, cnf.RpcUrl, rpcLimits(cnf.Rpc.Limits))
+	}
+	rpc, err := node.DialMultiRPC(ctx, rpcEndpoints(cnf.Rpc), node.MultiRPCConfig{
+		HealthInterval: cnf.Rpc.HealthInterval,
+		MaxLag:         cnf.Rpc.MaxLag,
+		StuckTimeout:   cnf.Rpc.StuckTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return node.NewEthClient(rpc), nil
+}
+
+// dialQuorumClient 配置了 quorum 时为充值入账创建多节点投票的客户端，未配置时返回 nil
+func dialQuorumClient(ctx context.Context, primary node.EthClient, cnf config.RpcConfig) (*node.QuorumClient, error) {
+	if cnf.Quorum == 0 {
+		return nil, nil
+	}
+	return node.DialQuorumClient(ctx, primary, rpcEndpoints(cnf), cnf.Quorum)
+}
+
+// headsUrl 订阅新区块使用的 WebSocket 地址，没有 WebSocket 节点时返回空
+func headsUrl(cnf config.EthConfig) string {
+	if cnf.Rpc.WsUrl != "" {
+		return cnf.Rpc.WsUrl
+	}
+	if node.IsWebsocketUrl(cnf.RpcUrl) {
+		return cnf.RpcUrl
+	}
+	endpoints := rpcEndpoints(cnf.Rpc)
+	sort.SliceStable(endpoints, func(i, j int) bool { return endpoints[i].Priority < endpoints[j].Priority })
+	for _, endpoint := range endpoints {
+		if node.IsWebsocketUrl(endpoint.Url) {
+			return endpoint.Url
+		}
+	}
+	return ""
+}
+
+func rpcEndpoints(cnf config.RpcConfig) []node.RPCEndpoint {
+	endpoints := make([]node.RPCEndpoint, 0, len(cnf.Endpoints))
+	for _, endpoint := range cnf.Endpoints {
+		endpoints = append(endpoints, node.RPCEndpoint{
+			Url:      endpoint.Url,
+			Priority: endpoint.Priority,
+			Limits:   rpcLimits(endpoint.RpcLimitsConfig),
+		})
+	}
+	return endpoints
+}
+
+func rpcLimits(cnf config.RpcLimitsConfig) node.RPCLimits {
+	return node.RPCLimits{
+		RateLimit:    cnf.RateLimit,
+		Burst:        cnf.Burst,
+		MaxBatchSize: cnf.MaxBatchSize,
+		Weights:      cnf.Weights,
+	}
+}
+
+// rpcMaxBatchSize 各节点中最小的批量请求上限，决定每轮扫块儿的区块数上限
+func rpcMaxBatchSize(cnf config.RpcConfig) int {
+	if len(cnf.Endpoints) == 0 {
+		return node.MinBatchSize(rpcLimits(cnf.Limits))
+	}
+	limits := make([]node.RPCLimits, 0, len(cnf.Endpoints))
+	for _, endpoint := range rpcEndpoints(cnf) {
+		limits = append(limits, endpoint.Limits)
+	}
+	return node.MinBatchSize(limits...)
+}
+
+// alertQuorumMismatch 投票节点结果不一致时告警，入账会停在复核步骤并由重试模块接管，需要人工排查节点
+func alertQuorumMismatch(mismatch node.QuorumMismatch) {
+	log.Error("ALERT quorum mismatch", "what", mismatch.What, "results", mismatch.Results)
+}
@@ -0,0 +1,26 @@
+package sequence
+
+import (
+	"fmt"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Allocate 在事务 tx 中从名为 name 的序列分配 n 个连续的值，返回第一个，序列从 1 开始。
+// 分配时锁住计数器行直到事务结束，并发事务按提交顺序拿到递增的值，事务回滚时分配的值一并撤销
+func Allocate(tx *gorm.DB, name string, n uint64) (uint64, error) {
+	seq := model.Sequence{Name: name}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+		return 0, fmt.Errorf("unable to init sequence %s: %w", name, err)
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&seq).Error
+	if err != nil {
+		return 0, fmt.Errorf("unable to lock sequence %s: %w", name, err)
+	}
+	if err := tx.Model(&model.Sequence{}).Where("id = ?", seq.ID).Update("value", seq.Value+n).Error; err != nil {
+		return 0, fmt.Errorf("unable to update sequence %s: %w", name, err)
+	}
+	return seq.Value + 1, nil
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/withdraw"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+const (
+	SignerTypeKeystore = "keystore"
+	SignerTypeRemote   = "remote"
+	SignerTypeHSM      = "hsm"
+
+	defaultHDMaxIndex = 100000
+)
+
+// newKeyring 按配置加载信封加密的主密钥，未配置时返回 nil
+func newKeyring(cnf config.KeyEncryptionConfig) (*wallet.Keyring, error) {
+	if len(cnf.MasterKeys) == 0 {
+		return nil, nil
+	}
+	keys := make([]wallet.MasterKey, 0, len(cnf.MasterKeys))
+	for _, masterKey := range cnf.MasterKeys {
+		key, err := wallet.NewFileMasterKey(masterKey.Id, masterKey.File)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return wallet.NewKeyring(cnf.Current, keys...)
+}
+
+// newHotSigner 按配置创建热钱包的签名后端，本地后端在启动时校验每个热钱包都有对应的密钥
+func newHotSigner(cnf config.EthConfig) (wallet.Signer, error) {
+	signerCnf := cnf.Signer
+	if signerCnf.Type != SignerTypeRemote {
+		return newLocalSigner(signerCnf, cnf.HotWallets)
+	}
+	tlsConfig, err := wallet.NewSignerTLSConfig(signerCnf.RemoteTLS.CertFile, signerCnf.RemoteTLS.KeyFile, signerCnf.RemoteTLS.CaFile, signerCnf.RemoteTLS.ServerName, false)
+	if err != nil {
+		return nil, err
+	}
+	token, err := wallet.ReadSignerToken(signerCnf.RemoteTLS.TokenFile)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.DialRemoteSigner(signerCnf.RemoteTarget, tlsConfig, token, signerCnf.RemoteTimeout)
+}
+
+// newLocalSigner 创建持有 wallets 密钥的 keystore 或 hsm 签名后端
+func newLocalSigner(signerCnf config.SignerConfig, wallets []config.WalletConfig) (wallet.Signer, error) {
+	switch signerCnf.Type {
+	case SignerTypeKeystore:
+		signer, err := wallet.NewKeystoreSigner(signerCnf.KeystoreDir, signerCnf.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		addresses := signer.Addresses()
+		for _, w := range wallets {
+			if !slices.Contains(addresses, common.HexToAddress(w.Address)) {
+				return nil, fmt.Errorf("wallet %s not found in keystore %s", w.Address, signerCnf.KeystoreDir)
+			}
+		}
+		return signer, nil
+	case SignerTypeHSM:
+		module, err := wallet.OpenHSMModule(signerCnf.HsmModule, signerCnf.HsmOptions)
+		if err != nil {
+			return nil, err
+		}
+		keys := make(map[common.Address]string, len(wallets))
+		for _, w := range wallets {
+			if w.KeyId == "" {
+				return nil, fmt.Errorf("wallet %s has no hsm key id", w.Address)
+			}
+			keys[common.HexToAddress(w.Address)] = w.KeyId
+		}
+		return wallet.NewHSMSigner(module, keys)
+	default:
+		return nil, fmt.Errorf("unknown signer type %q", signerCnf.Type)
+	}
+}
+
+// NewSignerHost 按签名机配置创建签名服务和双向 TLS 配置，只为 wallets 中的地址和 hd_xprv_file 派生的地址签名
+func NewSignerHost(cnf config.SignerHostConfig) (*wallet.SignerServer, *tls.Config, error) {
+	if len(cnf.Wallets) == 0 {
+		return nil, nil, errors.New("signer host requires at least one wallet")
+	}
+	allowed := make([]common.Address, 0, len(cnf.Wallets))
+	for _, w := range cnf.Wallets {
+		if !common.IsHexAddress(w.Address) {
+			return nil, nil, fmt.Errorf("invalid wallet address %q", w.Address)
+		}
+		allowed = append(allowed, common.HexToAddress(w.Address))
+	}
+	signer, err := newLocalSigner(cnf.Signer, cnf.Wallets)
+	if err != nil {
+		return nil, nil, err
+	}
+	hd, err := newHostHDSigner(cnf.Signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsConfig, err := wallet.NewSignerTLSConfig(cnf.TLS.CertFile, cnf.TLS.KeyFile, cnf.TLS.CaFile, "", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := wallet.ReadSignerToken(cnf.TLS.TokenFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet.NewSignerServer(signer, hd, allowed, token), tlsConfig, nil
+}
+
+// newHDSigner 配置 hd_xpub 后用户充值地址由扩展公钥派生，扩展私钥只保存在签名机上，
+// 归集这些地址时按派生序号交给远程签名服务签名，因此必须使用远程签名
+func newHDSigner(cnf config.EthConfig, db *gorm.DB, hotSigner wallet.Signer) (wallet.Signer, error) {
+	if cnf.Signer.HdXprvFile != "" {
+		return nil, errors.New("signer.hd_xprv_file is only read by the signer host, move it out of the service config")
+	}
+	if cnf.HdXpub == "" {
+		return nil, nil
+	}
+	remote, ok := hotSigner.(withdraw.HDRemote)
+	if !ok {
+		return nil, errors.New("hd_xpub requires a remote signer holding the hd keys")
+	}
+	return withdraw.NewHDSigner(db, remote), nil
+}
+
+// newHostHDSigner 签名机加载与 hd_xpub 对应的账户扩展私钥，未配置时不为派生地址签名
+func newHostHDSigner(signerCnf config.SignerConfig) (*wallet.HDSigner, error) {
+	if signerCnf.HdXprvFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(signerCnf.HdXprvFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read hd xprv file: %w", err)
+	}
+	account, err := wallet.ParseExtendedKey(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hd xprv: %w", err)
+	}
+	if !account.IsPrivate() {
+		return nil, errors.New("hd_xprv_file must contain an extended private key")
+	}
+	maxIndex := signerCnf.HdMaxIndex
+	if maxIndex == 0 {
+		maxIndex = defaultHDMaxIndex
+	}
+	return wallet.NewHDSigner(account, maxIndex)
+}
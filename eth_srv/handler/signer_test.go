@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+)
+
+func testHDAccount(t *testing.T, seed byte) *wallet.ExtendedKey {
+	t.Helper()
+	master, err := wallet.NewMasterKey([]byte{seed, seed, seed, seed, seed, seed, seed, seed, seed, seed, seed, seed, seed, seed, seed, seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive(wallet.EthAccountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func TestNewHDSigner(t *testing.T) {
+	account := testHDAccount(t, 1)
+	xprvFile := filepath.Join(t.TempDir(), "hd-xprv")
+	if err := os.WriteFile(xprvFile, []byte(account.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	xpub := account.Neuter().String()
+	remote := wallet.NewRemoteSigner(nil, 0)
+
+	if signer, err := newHDSigner(config.EthConfig{}, nil, remote); err != nil || signer != nil {
+		t.Fatalf("no hd signer without hd_xpub, got %v %v", signer, err)
+	}
+	// 在线服务不读取扩展私钥
+	if _, err := newHDSigner(config.EthConfig{HdXpub: xpub, Signer: config.SignerConfig{Type: SignerTypeRemote, HdXprvFile: xprvFile}}, nil, remote); err == nil {
+		t.Fatal("hd_xprv_file in the service config should be rejected")
+	}
+	if _, err := newHDSigner(config.EthConfig{HdXpub: xpub, Signer: config.SignerConfig{Type: SignerTypeKeystore}}, nil, wallet.NewKeySigner()); err == nil {
+		t.Fatal("hd_xpub without a remote signer should be rejected")
+	}
+	if signer, err := newHDSigner(config.EthConfig{HdXpub: xpub, Signer: config.SignerConfig{Type: SignerTypeRemote}}, nil, remote); err != nil || signer == nil {
+		t.Fatalf("expected hd signer backed by the remote signer, got %v %v", signer, err)
+	}
+}
+
+func TestNewHostHDSigner(t *testing.T) {
+	account := testHDAccount(t, 1)
+	dir := t.TempDir()
+	xprvFile := filepath.Join(dir, "hd-xprv")
+	if err := os.WriteFile(xprvFile, []byte(account.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	xpubFile := filepath.Join(dir, "hd-xpub")
+	if err := os.WriteFile(xpubFile, []byte(account.Neuter().String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if signer, err := newHostHDSigner(config.SignerConfig{}); err != nil || signer != nil {
+		t.Fatalf("no hd signer without hd_xprv_file, got %v %v", signer, err)
+	}
+	if _, err := newHostHDSigner(config.SignerConfig{HdXprvFile: xpubFile}); err == nil {
+		t.Fatal("extended public key should be rejected")
+	}
+	if signer, err := newHostHDSigner(config.SignerConfig{HdXprvFile: xprvFile}); err != nil || signer == nil {
+		t.Fatalf("expected hd signer, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderTimestamp 签名时间，unix 秒
+	HeaderTimestamp = "X-CoinNest-Timestamp"
+	// HeaderSignature 签名，格式为 sha256=<hex>
+	HeaderSignature = "X-CoinNest-Signature"
+	// HeaderDelivery 投递记录 ID，重试时不变，接收方可据此去重
+	HeaderDelivery = "X-CoinNest-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+// ErrInvalidSignature 签名不匹配或时间戳超出允许范围
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign 计算 HMAC-SHA256(secret, timestamp + "." + body)，时间戳参与签名，防止旧请求被重放
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 供接收方校验回调请求，时间戳与 now 相差超过 tolerance 时拒绝
+func Verify(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrInvalidSignature
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) || !hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
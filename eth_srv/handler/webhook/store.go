@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store 回调模块的持久化接口
+type Store interface {
+	// LastEventSeq 返回订阅方已生成投递记录的最大事件序号
+	LastEventSeq(subscriber string) (uint64, error)
+	// EventsAfter 按序号返回 seq 之后的事件，eventTypes 为空时不过滤类型
+	EventsAfter(seq uint64, eventTypes []string, limit int) ([]model.Event, error)
+	// CreateDeliveries 写入投递记录，同一订阅方同一事件的记录会被忽略
+	CreateDeliveries(deliveries []model.WebhookDelivery) error
+	// PendingDeliveries 返回还没有投递过的记录
+	PendingDeliveries(limit int) ([]model.WebhookDelivery, error)
+	// DeliveryById 按 ID 查询投递记录
+	DeliveryById(id uint64) (*model.WebhookDelivery, error)
+	// UpdateDelivery 记录一次投递的结果
+	UpdateDelivery(id uint64, fields map[string]interface{}) error
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) LastEventSeq(subscriber string) (uint64, error) {
+	var seq uint64
+	err := s.db.Model(&model.WebhookDelivery{}).Where("subscriber = ?", subscriber).
+		Select("COALESCE(MAX(event_seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+func (s *store) EventsAfter(seq uint64, eventTypes []string, limit int) ([]model.Event, error) {
+	query := s.db.Where("seq > ?", seq)
+	if len(eventTypes) > 0 {
+		query = query.Where("event_type IN ?", eventTypes)
+	}
+	var events []model.Event
+	err := query.Order("seq ASC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (s *store) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (s *store) PendingDeliveries(limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := s.db.Where("status = ?", global_const.WebhookStatusPending).Order("event_seq ASC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (s *store) DeliveryById(id uint64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := s.db.Where("id = ?", id).First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *store) UpdateDelivery(id uint64, fields map[string]interface{}) error {
+	return s.db.Model(&model.WebhookDelivery{}).Where("id = ?", id).Updates(fields).Error
+}
@@ -0,0 +1,302 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/log"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookBatchSize = 100
+	maxErrorLength   = 255
+	// 只读取少量响应内容用于排查，避免对方返回大响应占用内存
+	maxResponseLength = 1024
+)
+
+// Retrier 投递失败的记录交给重试模块按退避策略重新投递
+type Retrier interface {
+	Submit(kind string, refId uint64, cause error) error
+}
+
+// Payload 回调请求体，字段与 gRPC 事件流一致
+type Payload struct {
+	Cursor       string `json:"cursor"`
+	EventType    string `json:"event_type"`
+	RefId        uint64 `json:"ref_id"`
+	RequestId    string `json:"request_id,omitempty"`
+	Status       uint8  `json:"status"`
+	TxHash       string `json:"tx_hash"`
+	BlockNumber  uint64 `json:"block_number"`
+	Address      string `json:"address"`
+	FromAddress  string `json:"from_address"`
+	ToAddress    string `json:"to_address"`
+	TokenAddress string `json:"token_address"`
+	Amount       string `json:"amount"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+type WebhookConfig struct {
+	Endpoints []config.WebhookConfig
+	Interval  time.Duration
+	Timeout   time.Duration
+}
+
+// Webhook 按订阅方订阅的事件类型生成投递记录并回调，同一订阅方的事件按序号生成记录，
+// 失败的记录交给重试模块，不阻塞后续事件，接收方需要按 cursor 处理乱序和重复。
+// 事件不区分归属，每个订阅方都会收到全部地址的事件，只按 event_types 过滤
+
+type Webhook struct {
+	store          Store
+	retry          Retrier
+	endpoints      map[string]config.WebhookConfig
+	client         *http.Client
+	interval       time.Duration
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+}
+
+func NewWebhook(store Store, retry Retrier, cfg WebhookConfig, shutdown context.CancelCauseFunc) (*Webhook, error) {
+	endpoints, err := parseEndpoints(cfg.Endpoints)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second * 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Second * 10
+	}
+	resCtx, resCancel := context.WithCancel(context.Background())
+
+	return &Webhook{
+		store:          store,
+		retry:          retry,
+		endpoints:      endpoints,
+		client:         &http.Client{Timeout: cfg.Timeout},
+		interval:       cfg.Interval,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{
+			HandleCrit: func(err error) {
+				shutdown(fmt.Errorf("critical error in webhook: %w", err))
+			},
+		},
+	}, nil
+}
+
+func parseEndpoints(endpoints []config.WebhookConfig) (map[string]config.WebhookConfig, error) {
+	result := make(map[string]config.WebhookConfig, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Subscriber == "" {
+			return nil, errors.New("webhook subscriber is empty")
+		}
+		if _, ok := result[endpoint.Subscriber]; ok {
+			return nil, fmt.Errorf("duplicate webhook subscriber %q", endpoint.Subscriber)
+		}
+		u, err := url.Parse(endpoint.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook url %q of subscriber %q", endpoint.Url, endpoint.Subscriber)
+		}
+		if endpoint.Secret == "" {
+			return nil, fmt.Errorf("webhook secret of subscriber %q is empty", endpoint.Subscriber)
+		}
+		for _, eventType := range endpoint.EventTypes {
+			if eventType != global_const.EventTypeDeposit && eventType != global_const.EventTypeWithdraw {
+				return nil, fmt.Errorf("invalid webhook event type %q of subscriber %q", eventType, endpoint.Subscriber)
+			}
+		}
+		result[endpoint.Subscriber] = endpoint
+	}
+	return result, nil
+}
+
+func (w *Webhook) Close() error {
+	var result error
+	w.resourceCancel()
+	if err := w.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("failed to await webhook: %w", err))
+	}
+	return result
+}
+
+func (w *Webhook) Start() error {
+	log.Info("start webhook......")
+	if len(w.endpoints) == 0 {
+		log.Warn("webhooks not configured, webhook disabled")
+		return nil
+	}
+	tickerWebhookWorker := time.NewTicker(w.interval)
+	w.tasks.Go(func() error {
+		defer tickerWebhookWorker.Stop()
+		for {
+			select {
+			case <-tickerWebhookWorker.C:
+				if err := w.dispatch(); err != nil {
+					log.Error("dispatch webhook events fail", "err", err)
+				}
+				if err := w.deliverPending(); err != nil {
+					log.Error("deliver webhooks fail", "err", err)
+				}
+			case <-w.resourceCtx.Done():
+				log.Info("stop webhook......")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+// dispatch 为每个订阅方生成上次进度之后的事件的投递记录，先落库再投递，进程中途退出不会漏投
+func (w *Webhook) dispatch() error {
+	for _, endpoint := range w.endpoints {
+		seq, err := w.store.LastEventSeq(endpoint.Subscriber)
+		if err != nil {
+			return err
+		}
+		events, err := w.store.EventsAfter(seq, endpoint.EventTypes, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+		deliveries := make([]model.WebhookDelivery, 0, len(events))
+		for i := range events {
+			payload, err := json.Marshal(newPayload(&events[i]))
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, model.WebhookDelivery{
+				Subscriber: endpoint.Subscriber,
+				EventSeq:   events[i].Seq,
+				EventType:  events[i].EventType,
+				RefId:      events[i].RefId,
+				Url:        endpoint.Url,
+				Payload:    string(payload),
+				Status:     global_const.WebhookStatusPending,
+			})
+		}
+		if err := w.store.CreateDeliveries(deliveries); err != nil {
+			return fmt.Errorf("unable to create deliveries of subscriber %s: %w", endpoint.Subscriber, err)
+		}
+	}
+	return nil
+}
+
+// deliverPending 首次投递新生成的记录，失败的记录交给重试模块
+func (w *Webhook) deliverPending() error {
+	deliveries, err := w.store.PendingDeliveries(webhookBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		if err := w.deliver(&deliveries[i]); err != nil {
+			log.Warn("webhook delivery fail", "id", deliveries[i].ID, "subscriber", deliveries[i].Subscriber, "err", err)
+			if err := w.retry.Submit(global_const.RetryKindWebhook, deliveries[i].ID, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RetryDeliver 重试模块回调，已投递成功的记录不再发送
+func (w *Webhook) RetryDeliver(id uint64) error {
+	delivery, err := w.store.DeliveryById(id)
+	if err != nil {
+		return err
+	}
+	if delivery.Status == global_const.WebhookStatusDelivered {
+		return nil
+	}
+	return w.deliver(delivery)
+}
+
+// deliver 发送一次回调并记录结果，每次发送重新生成时间戳和签名
+func (w *Webhook) deliver(delivery *model.WebhookDelivery) error {
+	endpoint, ok := w.endpoints[delivery.Subscriber]
+	if !ok {
+		return fmt.Errorf("webhook subscriber %q is not configured", delivery.Subscriber)
+	}
+	code, sendErr := w.send(endpoint, delivery)
+	fields := map[string]interface{}{
+		"attempts":      gorm.Expr("attempts + ?", 1),
+		"response_code": code,
+	}
+	if sendErr == nil {
+		now := time.Now()
+		fields["status"] = global_const.WebhookStatusDelivered
+		fields["error_message"] = ""
+		fields["delivered_at"] = &now
+	} else {
+		fields["status"] = global_const.WebhookStatusFailed
+		fields["error_message"] = errorMessage(sendErr)
+	}
+	if err := w.store.UpdateDelivery(delivery.ID, fields); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	return sendErr
+}
+
+func (w *Webhook) send(endpoint config.WebhookConfig, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(w.resourceCtx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, body))
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(delivery.ID, 10))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		content, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLength))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, content)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseLength))
+	return resp.StatusCode, nil
+}
+
+func newPayload(event *model.Event) *Payload {
+	return &Payload{
+		Cursor:       strconv.FormatUint(event.Seq, 10),
+		EventType:    event.EventType,
+		RefId:        event.RefId,
+		RequestId:    event.RequestId,
+		Status:       event.Status,
+		TxHash:       event.TxHash,
+		BlockNumber:  event.BlockNumber,
+		Address:      event.Address,
+		FromAddress:  event.FromAddress,
+		ToAddress:    event.ToAddress,
+		TokenAddress: event.TokenAddress,
+		Amount:       event.Amount,
+		CreatedAt:    event.CreatedAt.Unix(),
+	}
+}
+
+func errorMessage(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+	return msg
+}
@@ -0,0 +1,276 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"gorm.io/gorm"
+)
+
+const secret = "test-secret"
+
+type memStore struct {
+	events     []model.Event
+	deliveries []model.WebhookDelivery
+}
+
+func (s *memStore) LastEventSeq(subscriber string) (uint64, error) {
+	var seq uint64
+	for _, delivery := range s.deliveries {
+		if delivery.Subscriber == subscriber && delivery.EventSeq > seq {
+			seq = delivery.EventSeq
+		}
+	}
+	return seq, nil
+}
+
+func (s *memStore) EventsAfter(seq uint64, eventTypes []string, limit int) ([]model.Event, error) {
+	var events []model.Event
+	for _, event := range s.events {
+		if event.Seq <= seq || !contains(eventTypes, event.EventType) {
+			continue
+		}
+		events = append(events, event)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func contains(eventTypes []string, eventType string) bool {
+	if len(eventTypes) == 0 {
+		return true
+	}
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memStore) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	for _, delivery := range deliveries {
+		delivery.ID = uint64(len(s.deliveries) + 1)
+		s.deliveries = append(s.deliveries, delivery)
+	}
+	return nil
+}
+
+func (s *memStore) PendingDeliveries(limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == global_const.WebhookStatusPending {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *memStore) DeliveryById(id uint64) (*model.WebhookDelivery, error) {
+	for i := range s.deliveries {
+		if s.deliveries[i].ID == id {
+			delivery := s.deliveries[i]
+			return &delivery, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memStore) UpdateDelivery(id uint64, fields map[string]interface{}) error {
+	for i := range s.deliveries {
+		delivery := &s.deliveries[i]
+		if delivery.ID != id {
+			continue
+		}
+		delivery.Attempts++
+		delivery.Status = uint8(fields["status"].(int))
+		delivery.ResponseCode = fields["response_code"].(int)
+		delivery.ErrorMessage = fields["error_message"].(string)
+		if deliveredAt, ok := fields["delivered_at"]; ok {
+			delivery.DeliveredAt = deliveredAt.(*time.Time)
+		}
+	}
+	return nil
+}
+
+type memRetry struct {
+	refIds []uint64
+}
+
+func (r *memRetry) Submit(kind string, refId uint64, cause error) error {
+	r.refIds = append(r.refIds, refId)
+	return nil
+}
+
+// receiver 校验签名后记录收到的事件，fail 为 true 时返回 500
+type receiver struct {
+	lock     sync.Mutex
+	fail     bool
+	payloads []Payload
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	err := Verify(secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, time.Now(), time.Minute)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.fail {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+		return
+	}
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, payload)
+}
+
+func newTestWebhook(t *testing.T, store *memStore, retry *memRetry, endpoints ...config.WebhookConfig) *Webhook {
+	w, err := NewWebhook(store, retry, WebhookConfig{Endpoints: endpoints}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	return w
+}
+
+func testEvents() []model.Event {
+	return []model.Event{
+		{Seq: 1, EventType: global_const.EventTypeDeposit, RefId: 11, Status: global_const.DepositStatusConfirming, Amount: "100"},
+		{Seq: 2, EventType: global_const.EventTypeWithdraw, RefId: 21, RequestId: "w-1", Status: global_const.WithdrawStatusQueued, Amount: "5"},
+		{Seq: 3, EventType: global_const.EventTypeDeposit, RefId: 11, Status: global_const.DepositStatusCredited, Amount: "100"},
+	}
+}
+
+func TestDeliverSignedEvents(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	store := &memStore{events: testEvents()}
+	retry := &memRetry{}
+	w := newTestWebhook(t, store, retry, config.WebhookConfig{
+		Subscriber: "exchange",
+		Url:        server.URL,
+		Secret:     secret,
+		EventTypes: []string{global_const.EventTypeDeposit},
+	})
+
+	if err := w.dispatch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.deliverPending(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recv.payloads) != 2 || recv.payloads[0].Cursor != "1" || recv.payloads[1].Cursor != "3" {
+		t.Fatalf("unexpected payloads %+v", recv.payloads)
+	}
+	if recv.payloads[1].Status != global_const.DepositStatusCredited {
+		t.Fatalf("unexpected status %d", recv.payloads[1].Status)
+	}
+	for _, delivery := range store.deliveries {
+		if delivery.Status != global_const.WebhookStatusDelivered || delivery.Attempts != 1 || delivery.DeliveredAt == nil {
+			t.Fatalf("delivery not recorded: %+v", delivery)
+		}
+	}
+	if len(retry.refIds) != 0 {
+		t.Fatalf("unexpected retries %v", retry.refIds)
+	}
+
+	// 进度按已生成的记录计算，不会重复投递
+	if err := w.dispatch(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deliveries) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(store.deliveries))
+	}
+}
+
+func TestFailedDeliverySubmitsRetry(t *testing.T) {
+	recv := &receiver{fail: true}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	store := &memStore{events: testEvents()[:1]}
+	retry := &memRetry{}
+	w := newTestWebhook(t, store, retry, config.WebhookConfig{Subscriber: "exchange", Url: server.URL, Secret: secret})
+
+	if err := w.dispatch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.deliverPending(); err != nil {
+		t.Fatal(err)
+	}
+	delivery := store.deliveries[0]
+	if delivery.Status != global_const.WebhookStatusFailed || delivery.ResponseCode != http.StatusInternalServerError || delivery.ErrorMessage == "" {
+		t.Fatalf("failure not recorded: %+v", delivery)
+	}
+	if len(retry.refIds) != 1 || retry.refIds[0] != delivery.ID {
+		t.Fatalf("expected retry of delivery %d, got %v", delivery.ID, retry.refIds)
+	}
+
+	recv.lock.Lock()
+	recv.fail = false
+	recv.lock.Unlock()
+	if err := w.RetryDeliver(delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+	delivery = store.deliveries[0]
+	if delivery.Status != global_const.WebhookStatusDelivered || delivery.Attempts != 2 {
+		t.Fatalf("retry not recorded: %+v", delivery)
+	}
+	if len(recv.payloads) != 1 || recv.payloads[0].RefId != 11 {
+		t.Fatalf("unexpected payloads %+v", recv.payloads)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"cursor":"1"}`)
+	now := time.Unix(1700000000, 0)
+	signature := Sign(secret, now.Unix(), body)
+
+	if err := Verify(secret, "1700000000", signature, body, now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify("other", "1700000000", signature, body, now, time.Minute); err != ErrInvalidSignature {
+		t.Fatalf("wrong secret: %v", err)
+	}
+	if err := Verify(secret, "1700000000", signature, []byte(`{"cursor":"2"}`), now, time.Minute); err != ErrInvalidSignature {
+		t.Fatalf("tampered body: %v", err)
+	}
+	if err := Verify(secret, "1700000000", signature, body, now.Add(time.Hour), time.Minute); err != ErrInvalidSignature {
+		t.Fatalf("expired timestamp: %v", err)
+	}
+}
+
+func TestParseEndpoints(t *testing.T) {
+	valid := config.WebhookConfig{Subscriber: "a", Url: "https://example.com/hook", Secret: secret}
+	if _, err := parseEndpoints([]config.WebhookConfig{valid, valid}); err == nil {
+		t.Fatal("expected duplicate subscriber error")
+	}
+	invalid := []config.WebhookConfig{
+		{Subscriber: "a", Url: "ftp://example.com", Secret: secret},
+		{Subscriber: "a", Url: "https://example.com/hook"},
+		{Subscriber: "a", Url: "https://example.com/hook", Secret: secret, EventTypes: []string{"sweep"}},
+	}
+	for _, endpoint := range invalid {
+		if _, err := parseEndpoints([]config.WebhookConfig{endpoint}); err == nil {
+			t.Fatalf("expected error for %+v", endpoint)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package withdraw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const rewrapBatchSize = 100
+
+type dbSigner struct {
+	db      *gorm.DB
+	keyring *wallet.Keyring
+}
+
+// NewDBSigner 使用加密保存在数据库中的私钥签名，用于从随机生成的用户地址归集，私钥只在签名时解密
+func NewDBSigner(db *gorm.DB, keyring *wallet.Keyring) wallet.Signer {
+	return &dbSigner{db: db, keyring: keyring}
+}
+
+func (s *dbSigner) SignTx(_ context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	var row model.EncryptedKey
+	err := s.db.Where("address = ?", address.String()).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", wallet.ErrUnknownAccount, address)
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to query encrypted key of %s: %w", address, err)
+	}
+	plaintext, err := s.keyring.Open(envelopeOf(&row), []byte(row.Address))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt key of %s: %w", address, err)
+	}
+	key, err := crypto.ToECDSA(plaintext)
+	clear(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key of %s: %w", address, err)
+	}
+	defer key.D.SetInt64(0)
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), key)
+}
+
+// HDRemote 签名机按派生序号签名的接口，扩展私钥只保存在签名机上
+type HDRemote interface {
+	SignHDTx(ctx context.Context, index uint32, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+}
+
+type hdSigner struct {
+	db     *gorm.DB
+	remote HDRemote
+}
+
+// NewHDSigner 为 HD 派生的用户地址签名，按地址查出派生序号后交给签名机签名，本进程只持有扩展公钥
+func NewHDSigner(db *gorm.DB, remote HDRemote) wallet.Signer {
+	return &hdSigner{db: db, remote: remote}
+}
+
+func (s *hdSigner) SignTx(ctx context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	var row model.Address
+	err := s.db.WithContext(ctx).Where("address = ? AND hd_index IS NOT NULL", address.String()).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", wallet.ErrUnknownAccount, address)
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to query hd index of %s: %w", address, err)
+	}
+	return s.remote.SignHDTx(ctx, *row.HdIndex, address, tx, chainId)
+}
+
+// SealKey 加密地址私钥，返回待写入的记录，地址作为附加数据与密文绑定
+func SealKey(sealer wallet.Sealer, address common.Address, privateKey []byte) (*model.EncryptedKey, error) {
+	envelope, err := sealer.Seal(privateKey, []byte(address.String()))
+	if err != nil {
+		return nil, err
+	}
+	return &model.EncryptedKey{
+		Address:        address.String(),
+		MasterKeyId:    envelope.MasterKeyId,
+		WrappedDataKey: envelope.WrappedDataKey,
+		Ciphertext:     envelope.Ciphertext,
+	}, nil
+}
+
+// RewrapKeys 把非当前主密钥包裹的数据密钥用当前主密钥重新包裹，密文不变，返回处理的记录数
+func RewrapKeys(db *gorm.DB, keyring *wallet.Keyring) (int, error) {
+	total := 0
+	for {
+		var rows []model.EncryptedKey
+		err := db.Where("master_key_id <> ?", keyring.Current()).Order("id ASC").Limit(rewrapBatchSize).Find(&rows).Error
+		if err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+		for i := range rows {
+			envelope, err := keyring.Rewrap(envelopeOf(&rows[i]))
+			if err != nil {
+				return total, fmt.Errorf("unable to rewrap key of %s: %w", rows[i].Address, err)
+			}
+			// 以旧主密钥 id 为条件，并发轮换时不会覆盖其他进程的结果
+			err = db.Model(&model.EncryptedKey{}).
+				Where("id = ? AND master_key_id = ?", rows[i].ID, rows[i].MasterKeyId).
+				Updates(map[string]interface{}{
+					"master_key_id":    envelope.MasterKeyId,
+					"wrapped_data_key": envelope.WrappedDataKey,
+				}).Error
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+// legacyAddressKey 旧版本明文保存在地址表 private_key 列中的私钥
+type legacyAddressKey struct {
+	Id         uint64
+	Address    string
+	PrivateKey string
+}
+
+// MigratePlaintextKeys 把地址表中明文保存的私钥加密写入 encrypted_key 并清空原列，返回迁移的记录数
+func MigratePlaintextKeys(db *gorm.DB, keyring *wallet.Keyring) (int, error) {
+	if !db.Migrator().HasColumn(&model.Address{}, "private_key") {
+		return 0, nil
+	}
+	var rows []legacyAddressKey
+	err := db.Model(&model.Address{}).Select("id", "address", "private_key").Where("private_key <> ''").Scan(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		privateKey := common.FromHex(row.PrivateKey)
+		key, err := crypto.ToECDSA(privateKey)
+		if err != nil {
+			return 0, fmt.Errorf("invalid plaintext key of %s: %w", row.Address, err)
+		}
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		if address != common.HexToAddress(row.Address) {
+			return 0, fmt.Errorf("plaintext key does not match address %s", row.Address)
+		}
+		encrypted, err := SealKey(keyring, address, privateKey)
+		clear(privateKey)
+		if err != nil {
+			return 0, err
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(encrypted).Error; err != nil {
+				return err
+			}
+			return tx.Model(&model.Address{}).Where("id = ?", row.Id).Update("private_key", "").Error
+		})
+		if err != nil {
+			return 0, fmt.Errorf("unable to migrate key of %s: %w", row.Address, err)
+		}
+	}
+	return len(rows), nil
+}
+
+func envelopeOf(row *model.EncryptedKey) *wallet.Envelope {
+	return &wallet.Envelope{
+		MasterKeyId:    row.MasterKeyId,
+		WrappedDataKey: row.WrappedDataKey,
+		Ciphertext:     row.Ciphertext,
+	}
+}
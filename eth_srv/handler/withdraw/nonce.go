@@ -0,0 +1,60 @@
+package withdraw
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NonceManager 基于数据库行锁的 nonce 分配器。
+// 同一地址的分配通过 SELECT ... FOR UPDATE 串行化，分配结果与签名后的交易在同一事务内提交，
+// 因此多个协程或多个进程并发出款也不会复用 nonce。
+type NonceManager struct {
+	client  node.EthClient
+	chainId uint64
+}
+
+func NewNonceManager(client node.EthClient, chainId uint64) *NonceManager {
+	return &NonceManager{client: client, chainId: chainId}
+}
+
+// Allocate 在事务 tx 中为地址分配下一个 nonce，调用方需要在同一事务内保存使用该 nonce 的交易
+func (m *NonceManager) Allocate(ctx context.Context, tx *gorm.DB, address common.Address) (uint64, error) {
+	row := model.Nonce{ChainId: m.chainId, Address: address.String()}
+	// 首次使用时插入占位行，已存在则忽略，保证后面的行锁一定能锁到记录
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return 0, fmt.Errorf("unable to init nonce of %s: %w", address, err)
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("chain_id = ? AND address = ?", m.chainId, address.String()).
+		First(&row).Error
+	if err != nil {
+		return 0, fmt.Errorf("unable to lock nonce of %s: %w", address, err)
+	}
+
+	// 以链上已确认的交易数重新校准，地址被外部使用过时跳过已占用的 nonce
+	chainNonce, err := m.client.TxCountByAddressContext(ctx, address)
+	if err != nil {
+		return 0, fmt.Errorf("unable to query nonce of %s: %w", address, err)
+	}
+	nonce := nextNonce(row.Nonce, uint64(chainNonce))
+
+	err = tx.Model(&model.Nonce{}).Where("id = ?", row.ID).Update("nonce", nonce+1).Error
+	if err != nil {
+		return 0, fmt.Errorf("unable to update nonce of %s: %w", address, err)
+	}
+	return nonce, nil
+}
+
+// nextNonce 本地记录的下一个 nonce 包含了已签名未上链的交易，链上计数更大时说明地址被外部使用过
+func nextNonce(stored, chain uint64) uint64 {
+	if chain > stored {
+		return chain
+	}
+	return stored
+}
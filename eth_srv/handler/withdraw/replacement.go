@@ -0,0 +1,190 @@
+package withdraw
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// 节点要求同 nonce 替换交易的 tip 和 feeCap 都至少比原交易高 10%
+const replacementBumpPercent = 110
+
+// bumpFee 返回替换交易的手续费：不低于原交易的 110%，当前建议值更高时使用建议值
+func bumpFee(old, suggested *big.Int) *big.Int {
+	minimum := new(big.Int).Mul(old, big.NewInt(replacementBumpPercent))
+	minimum.Add(minimum, big.NewInt(99))
+	minimum.Div(minimum, big.NewInt(100))
+	if suggested != nil && suggested.Cmp(minimum) > 0 {
+		return new(big.Int).Set(suggested)
+	}
+	return minimum
+}
+
+// replaceStuck 广播后超过 stuckTimeout 仍未上链的交易，以相同 nonce 提高手续费重新签名广播。
+// 当前交易是取消交易时继续加速取消交易，否则加速原提现。
+func (w *Withdraw) replaceStuck() error {
+	withdraws, err := w.store.StuckWithdraws(w.chainId.Uint64(), time.Now().Add(-w.stuckTimeout), withdrawBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(withdraws) == 0 {
+		return nil
+	}
+	fees, err := w.fees.Fees(w.resourceCtx)
+	if errors.Is(err, fee.ErrFeeAboveCap) {
+		log.Warn("stuck withdraws not replaced", "err", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, stuck := range withdraws {
+		// 已经被打包的交易交给确认流程处理，避免无意义的替换
+		mined, _, err := w.minedTx(stuck.ID)
+		if err != nil {
+			return err
+		}
+		if mined != nil {
+			continue
+		}
+
+		replaced, err := w.store.ReplaceWithdraw(stuck.ID, func(withdraw *model.Withdraw, latest *model.WithdrawTx) (*model.WithdrawTx, error) {
+			if withdraw.Status != global_const.WithdrawStatusBroadcast || latest == nil {
+				return nil, ErrWithdrawNotPending
+			}
+			txType := uint8(global_const.WithdrawTxTypeSpeedUp)
+			if latest.TxType == global_const.WithdrawTxTypeCancel {
+				txType = global_const.WithdrawTxTypeCancel
+			}
+			return w.resign(withdraw, latest, txType, fees)
+		})
+		if errors.Is(err, ErrWithdrawNotPending) {
+			continue
+		} else if errors.Is(err, fee.ErrFeeAboveCap) {
+			// 提价后超过上限，保留当前交易等待上链或手续费回落
+			log.Warn("stuck withdraw not replaced", "id", stuck.ID, "err", err)
+			continue
+		} else if err != nil {
+			return err
+		}
+		log.Info("stuck withdraw replaced", "id", replaced.ID, "nonce", *replaced.Nonce, "hash", replaced.TxHash, "gasFeeCap", replaced.GasFeeCap)
+		w.broadcastOrRetry(replaced)
+	}
+	return nil
+}
+
+// Cancel 运营人员取消提现。排队中的提现直接取消，不依赖手续费；已签名或已广播的提现以相同 nonce
+// 发送一笔 0 金额转给自己的交易，该交易先于原交易上链即取消成功。
+func (w *Withdraw) Cancel(id uint64) error {
+	cancelled, err := w.store.ReplaceWithdraw(id, func(withdraw *model.Withdraw, _ *model.WithdrawTx) (*model.WithdrawTx, error) {
+		if withdraw.Status != global_const.WithdrawStatusQueued {
+			return nil, errNotQueued
+		}
+		withdraw.Status = global_const.WithdrawStatusCancelled
+		return nil, nil
+	})
+	if err == nil {
+		log.Info("queued withdraw cancelled", "id", cancelled.ID)
+		return nil
+	} else if !errors.Is(err, errNotQueued) {
+		return err
+	}
+
+	fees, err := w.fees.Fees(w.resourceCtx)
+	if err != nil {
+		return err
+	}
+	replaced, err := w.store.ReplaceWithdraw(id, func(withdraw *model.Withdraw, latest *model.WithdrawTx) (*model.WithdrawTx, error) {
+		switch withdraw.Status {
+		case global_const.WithdrawStatusSigned, global_const.WithdrawStatusBroadcast:
+			if latest == nil {
+				return nil, fmt.Errorf("withdraw %d has no transaction", withdraw.ID)
+			}
+			return w.resign(withdraw, latest, global_const.WithdrawTxTypeCancel, fees)
+		default:
+			return nil, ErrWithdrawNotPending
+		}
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("withdraw cancel signed", "id", replaced.ID, "nonce", *replaced.Nonce, "hash", replaced.TxHash)
+	w.broadcastOrRetry(replaced)
+	return nil
+}
+
+// errNotQueued 提现已分配 nonce，取消需要签名替换交易
+var errNotQueued = errors.New("withdraw not queued")
+
+// resign 以相同 nonce 和提高后的手续费重新签名，提现记录指向新交易并回到已签名状态等待广播
+func (w *Withdraw) resign(withdraw *model.Withdraw, latest *model.WithdrawTx, txType uint8, suggested *fee.Fees) (*model.WithdrawTx, error) {
+	oldTip, ok := new(big.Int).SetString(latest.GasTipCap, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid gas tip cap %q", latest.GasTipCap)
+	}
+	oldFeeCap, ok := new(big.Int).SetString(latest.GasFeeCap, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid gas fee cap %q", latest.GasFeeCap)
+	}
+	fees := &fee.Fees{
+		GasTipCap: bumpFee(oldTip, suggested.GasTipCap),
+		GasFeeCap: bumpFee(oldFeeCap, suggested.GasFeeCap),
+	}
+	if fees.GasFeeCap.Cmp(fees.GasTipCap) < 0 {
+		fees.GasFeeCap = new(big.Int).Set(fees.GasTipCap)
+	}
+	if err := w.fees.CheckCap(fees); err != nil {
+		return nil, err
+	}
+
+	from := common.HexToAddress(withdraw.FromAddress)
+	to := common.HexToAddress(withdraw.ToAddress)
+	token := common.HexToAddress(withdraw.TokenAddress)
+	amount, ok := new(big.Int).SetString(withdraw.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", withdraw.Amount)
+	}
+	gas := withdraw.GasLimit
+	if gas == 0 {
+		gas = defaultGasLimit(token)
+	}
+	if txType == global_const.WithdrawTxTypeCancel {
+		to, token, amount = from, common.HexToAddress(global_const.EthAddress), big.NewInt(0)
+		gas = fee.NativeTransferGasLimit
+	}
+	req, err := buildTransferTx(w.chainId, latest.Nonce, token, to, amount, fees, gas)
+	if err != nil {
+		return nil, err
+	}
+	rawTx, txHash, err := w.signTx(from, req)
+	if err != nil {
+		return nil, err
+	}
+
+	withdraw.Status = global_const.WithdrawStatusSigned
+	withdraw.GasLimit = req.Gas
+	withdraw.GasTipCap = fees.GasTipCap.String()
+	withdraw.GasFeeCap = fees.GasFeeCap.String()
+	withdraw.TxHash = txHash
+	withdraw.RawTx = rawTx
+	return &model.WithdrawTx{
+		Nonce:     latest.Nonce,
+		TxType:    txType,
+		TxHash:    txHash,
+		RawTx:     rawTx,
+		GasTipCap: withdraw.GasTipCap,
+		GasFeeCap: withdraw.GasFeeCap,
+	}, nil
+}
+
+func isUnderpricedError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "underpriced")
+}
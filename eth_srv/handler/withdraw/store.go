@@ -0,0 +1,345 @@
+package withdraw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/event"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrWithdrawNotQueued 提现已被其他协程处理
+	ErrWithdrawNotQueued = errors.New("withdraw is not queued")
+	// ErrWithdrawNotPending 提现已经上链或已取消，不能再替换交易
+	ErrWithdrawNotPending = errors.New("withdraw is not pending")
+)
+
+// SignFunc 使用分配到的 nonce 构建并签名交易，返回值会写回提现记录
+type SignFunc func(withdraw *model.Withdraw, nonce uint64) error
+
+// ReplaceFunc 基于当前交易生成同 nonce 的替换交易并更新提现记录，排队中的提现没有当前交易，latest 为 nil。
+// 返回的交易会作为新的上链尝试保存，返回 nil 表示不产生新交易。
+type ReplaceFunc func(withdraw *model.Withdraw, latest *model.WithdrawTx) (*model.WithdrawTx, error)
+
+// Store 提现模块的持久化接口
+type Store interface {
+	// EnsureAddresses 将配置中的钱包地址登记到地址表，充值扫描据此识别内部转账
+	EnsureAddresses(addresses []common.Address, addressType uint8) error
+	// CreateWithdraw 创建排队中的提现，RequestId 重复时返回已存在的记录。
+	// 用户提现在同一事务内锁定并扣减 UserAddress 的余额，余额不足时返回 ErrInsufficientBalance
+	CreateWithdraw(withdraw *model.Withdraw) error
+	// WithdrawById 按 ID 查询提现
+	WithdrawById(id uint64) (*model.Withdraw, error)
+	// WithdrawsByStatus 按创建顺序返回指定状态的提现
+	WithdrawsByStatus(chainId uint64, status uint8, limit int) ([]model.Withdraw, error)
+	// SignWithdraw 锁定排队中的提现并分配 nonce，签名结果与 nonce 在同一事务内提交，ctx 取消时事务回滚
+	SignWithdraw(ctx context.Context, id uint64, sign SignFunc) (*model.Withdraw, error)
+	// StuckWithdraws 返回广播时间早于 before 仍未上链的提现
+	StuckWithdraws(chainId uint64, before time.Time, limit int) ([]model.Withdraw, error)
+	// WithdrawTxs 返回提现的全部上链尝试，最新的在前
+	WithdrawTxs(withdrawId uint64) ([]model.WithdrawTx, error)
+	// ReplaceWithdraw 锁定提现记录，用 replace 生成的交易替换当前交易并保存新的上链尝试
+	ReplaceWithdraw(id uint64, replace ReplaceFunc) (*model.Withdraw, error)
+	// UpdateWithdrawStatus 推进提现状态，只有当前状态仍为 from 时才会更新。
+	// 提现的每次状态变化都与提现事件在同一事务内写入
+	UpdateWithdrawStatus(id uint64, from, to uint8, fields map[string]interface{}) error
+	// RevertWithdraws 所在区块被回滚的提现退回已广播状态，等待重新确认
+	RevertWithdraws(blockHash string) error
+}
+
+type store struct {
+	db     *gorm.DB
+	nonces *NonceManager
+}
+
+func NewStore(db *gorm.DB, nonces *NonceManager) Store {
+	return &store{db: db, nonces: nonces}
+}
+
+func (s *store) EnsureAddresses(addresses []common.Address, addressType uint8) error {
+	for _, address := range addresses {
+		err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Address{
+			Address:     address.String(),
+			AddressType: addressType,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *store) CreateWithdraw(withdraw *model.Withdraw) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(withdraw)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Where("request_id = ?", withdraw.RequestId).First(withdraw).Error
+		}
+		if err := debitBalance(tx, withdraw); err != nil {
+			return err
+		}
+		return appendWithdrawEvents(tx, withdraw)
+	})
+}
+
+func (s *store) WithdrawById(id uint64) (*model.Withdraw, error) {
+	var withdraw model.Withdraw
+	if err := s.db.Where("id = ?", id).First(&withdraw).Error; err != nil {
+		return nil, err
+	}
+	return &withdraw, nil
+}
+
+func (s *store) WithdrawsByStatus(chainId uint64, status uint8, limit int) ([]model.Withdraw, error) {
+	var withdraws []model.Withdraw
+	err := s.db.Where("chain_id = ? AND status = ?", chainId, status).Order("id ASC").Limit(limit).Find(&withdraws).Error
+	return withdraws, err
+}
+
+func (s *store) SignWithdraw(ctx context.Context, id uint64, sign SignFunc) (*model.Withdraw, error) {
+	var withdraw model.Withdraw
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&withdraw).Error
+		if err != nil {
+			return err
+		}
+		if withdraw.Status != global_const.WithdrawStatusQueued {
+			return ErrWithdrawNotQueued
+		}
+
+		nonce, err := s.nonces.Allocate(ctx, tx, common.HexToAddress(withdraw.FromAddress))
+		if err != nil {
+			return err
+		}
+		if err := sign(&withdraw, nonce); err != nil {
+			return fmt.Errorf("unable to sign withdraw %d: %w", withdraw.ID, err)
+		}
+		withdraw.Nonce = &nonce
+		withdraw.Status = global_const.WithdrawStatusSigned
+		if err := tx.Save(&withdraw).Error; err != nil {
+			return err
+		}
+		if err := appendWithdrawEvents(tx, &withdraw); err != nil {
+			return err
+		}
+		return tx.Create(&model.WithdrawTx{
+			WithdrawId: withdraw.ID,
+			Nonce:      nonce,
+			TxType:     global_const.WithdrawTxTypeOriginal,
+			TxHash:     withdraw.TxHash,
+			RawTx:      withdraw.RawTx,
+			GasTipCap:  withdraw.GasTipCap,
+			GasFeeCap:  withdraw.GasFeeCap,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &withdraw, nil
+}
+
+func (s *store) StuckWithdraws(chainId uint64, before time.Time, limit int) ([]model.Withdraw, error) {
+	var withdraws []model.Withdraw
+	err := s.db.Where("chain_id = ? AND status = ? AND broadcast_at < ?", chainId, global_const.WithdrawStatusBroadcast, before).
+		Order("id ASC").Limit(limit).Find(&withdraws).Error
+	return withdraws, err
+}
+
+func (s *store) WithdrawTxs(withdrawId uint64) ([]model.WithdrawTx, error) {
+	var txs []model.WithdrawTx
+	err := s.db.Where("withdraw_id = ?", withdrawId).Order("id DESC").Find(&txs).Error
+	return txs, err
+}
+
+func (s *store) ReplaceWithdraw(id uint64, replace ReplaceFunc) (*model.Withdraw, error) {
+	var withdraw model.Withdraw
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&withdraw).Error
+		if err != nil {
+			return err
+		}
+		var latest *model.WithdrawTx
+		if withdraw.TxHash != "" {
+			latest = new(model.WithdrawTx)
+			if err := tx.Where("tx_hash = ?", withdraw.TxHash).First(latest).Error; err != nil {
+				return err
+			}
+		}
+
+		status := withdraw.Status
+		replacement, err := replace(&withdraw, latest)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(&withdraw).Error; err != nil {
+			return err
+		}
+		if withdraw.Status != status {
+			if err := refundBalance(tx, &withdraw); err != nil {
+				return err
+			}
+			if err := appendWithdrawEvents(tx, &withdraw); err != nil {
+				return err
+			}
+		}
+		if replacement == nil {
+			return nil
+		}
+		replacement.WithdrawId = withdraw.ID
+		return tx.Create(replacement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &withdraw, nil
+}
+
+func (s *store) UpdateWithdrawStatus(id uint64, from, to uint8, fields map[string]interface{}) error {
+	updates := map[string]interface{}{"status": to}
+	for k, v := range fields {
+		updates[k] = v
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Withdraw{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || from == to {
+			return nil
+		}
+		var withdraw model.Withdraw
+		if err := tx.Where("id = ?", id).First(&withdraw).Error; err != nil {
+			return err
+		}
+		if err := refundBalance(tx, &withdraw); err != nil {
+			return err
+		}
+		return appendWithdrawEvents(tx, &withdraw)
+	})
+}
+
+func (s *store) RevertWithdraws(blockHash string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var reverted []model.Withdraw
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("block_hash = ? AND status IN ?", blockHash, []uint8{
+				global_const.WithdrawStatusConfirmed,
+				global_const.WithdrawStatusFailed,
+				global_const.WithdrawStatusCancelled,
+			}).
+			Find(&reverted).Error
+		if err != nil || len(reverted) == 0 {
+			return err
+		}
+		ids := make([]uint64, 0, len(reverted))
+		withdraws := make([]*model.Withdraw, 0, len(reverted))
+		for i := range reverted {
+			// 失败或取消时已退回的余额重新扣减，等待交易重新确认
+			if reverted[i].Status != global_const.WithdrawStatusConfirmed {
+				if err := redebitBalance(tx, &reverted[i]); err != nil {
+					return err
+				}
+			}
+			reverted[i].Status = global_const.WithdrawStatusBroadcast
+			reverted[i].BlockHash = ""
+			reverted[i].BlockNumber = 0
+			ids = append(ids, reverted[i].ID)
+			withdraws = append(withdraws, &reverted[i])
+		}
+		err = tx.Model(&model.Withdraw{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":       global_const.WithdrawStatusBroadcast,
+				"block_hash":   "",
+				"block_number": 0,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return appendWithdrawEvents(tx, withdraws...)
+	})
+}
+
+// debitBalance 锁定用户余额并扣减提现金额，内部转账不扣减余额
+func debitBalance(tx *gorm.DB, withdraw *model.Withdraw) error {
+	if withdraw.UserAddress == "" {
+		return nil
+	}
+	var balance model.Balance
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("address = ? AND token_address = ?", withdraw.UserAddress, withdraw.TokenAddress).
+		First(&balance).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s has no balance of token %s", ErrInsufficientBalance, withdraw.UserAddress, withdraw.TokenAddress)
+	}
+	if err != nil {
+		return err
+	}
+	remaining, err := subtractBalance(balance.Balance, withdraw.Amount)
+	if err != nil {
+		return fmt.Errorf("%w: %s of token %s", err, withdraw.UserAddress, withdraw.TokenAddress)
+	}
+	return tx.Model(&model.Balance{}).Where("id = ?", balance.ID).Update("balance", remaining).Error
+}
+
+// subtractBalance 返回扣减后的余额，余额不足时返回 ErrInsufficientBalance
+func subtractBalance(balance, amount string) (string, error) {
+	have, ok := new(big.Int).SetString(balance, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid balance %q", balance)
+	}
+	want, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid amount %q", amount)
+	}
+	if have.Cmp(want) < 0 {
+		return "", fmt.Errorf("%w: balance %s is less than amount %s", ErrInsufficientBalance, have, want)
+	}
+	return have.Sub(have, want).String(), nil
+}
+
+// refundBalance 提现失败或取消时把扣减的金额退回用户余额
+func refundBalance(tx *gorm.DB, withdraw *model.Withdraw) error {
+	if withdraw.UserAddress == "" ||
+		(withdraw.Status != global_const.WithdrawStatusFailed && withdraw.Status != global_const.WithdrawStatusCancelled) {
+		return nil
+	}
+	return tx.Model(&model.Balance{}).
+		Where("address = ? AND token_address = ?", withdraw.UserAddress, withdraw.TokenAddress).
+		Update("balance", gorm.Expr("balance + ?", withdraw.Amount)).Error
+}
+
+// redebitBalance 回滚失败或取消的提现时重新扣减已退回的金额，余额可能因此为负，需要人工处理
+func redebitBalance(tx *gorm.DB, withdraw *model.Withdraw) error {
+	if withdraw.UserAddress == "" {
+		return nil
+	}
+	return tx.Model(&model.Balance{}).
+		Where("address = ? AND token_address = ?", withdraw.UserAddress, withdraw.TokenAddress).
+		Update("balance", gorm.Expr("balance - ?", withdraw.Amount)).Error
+}
+
+// appendWithdrawEvents 写入提现的状态变更事件，归集等内部转账不产生事件，不会推送给业务方
+func appendWithdrawEvents(tx *gorm.DB, withdraws ...*model.Withdraw) error {
+	return event.Append(tx, withdrawEvents(withdraws)...)
+}
+
+func withdrawEvents(withdraws []*model.Withdraw) []*model.Event {
+	events := make([]*model.Event, 0, len(withdraws))
+	for _, withdraw := range withdraws {
+		if withdraw.WithdrawType == global_const.WithdrawTypeUser {
+			events = append(events, event.WithdrawEvent(withdraw))
+		}
+	}
+	return events
+}
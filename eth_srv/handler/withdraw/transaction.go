@@ -0,0 +1,47 @@
+package withdraw
+
+import (
+	"math/big"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// buildTransferTx 构建原生币或 ERC-20 转账的签名请求
+func buildTransferTx(chainId *big.Int, nonce uint64, token, to common.Address, amount *big.Int, fees *fee.Fees, gas uint64) (*wallet.TxRequest, error) {
+	if token == common.HexToAddress(global_const.EthAddress) {
+		return &wallet.TxRequest{
+			ChainId:   chainId,
+			Nonce:     nonce,
+			GasTipCap: fees.GasTipCap,
+			GasFeeCap: fees.GasFeeCap,
+			Gas:       gas,
+			To:        &to,
+			Value:     amount,
+		}, nil
+	}
+	data, err := wallet.Erc20TransferData(to, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.TxRequest{
+		ChainId:   chainId,
+		Nonce:     nonce,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Gas:       gas,
+		To:        &token,
+		Value:     big.NewInt(0),
+		Data:      data,
+	}, nil
+}
+
+// defaultGasLimit 没有估算结果时转账使用的 gas 上限
+func defaultGasLimit(token common.Address) uint64 {
+	if token == common.HexToAddress(global_const.EthAddress) {
+		return fee.NativeTransferGasLimit
+	}
+	return fee.Erc20TransferGasLimit
+}
@@ -4,24 +4,86 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
 	"github.com/0xweb-3/CoinNest/eth_srv/common/tasks"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
-	"time"
 )
 
+const (
+	withdrawBatchSize = 20
+	maxErrorLength    = 255
+)
+
+// ErrInvalidWithdraw 提现请求参数不合法
+var ErrInvalidWithdraw = errors.New("invalid withdraw")
+
+// ErrInsufficientBalance 用户余额不足以支付提现金额
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+type WithdrawConfig struct {
+	ChainId      uint64
+	TxCapability global_const.TxCapability
+	HotWallets   []common.Address // 第一个为默认出款地址
+	Workers      int
+	StuckTimeout time.Duration
+}
+
+// Retrier 失败任务交给重试模块按退避策略重新执行
+type Retrier interface {
+	Submit(kind string, refId uint64, cause error) error
+	Active(kind string, refId uint64) (bool, error)
+}
+
 type Withdraw struct {
 	client         node.EthClient
+	fees           *fee.Oracle
+	store          Store
+	signer         wallet.Signer
+	retry          Retrier
+	chainId        *big.Int
+	capability     global_const.TxCapability
+	hotWallets     []common.Address
+	workers        int
+	stuckTimeout   time.Duration
 	resourceCtx    context.Context
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 }
 
-func NewWithdraw(client node.EthClient, shutdown context.CancelCauseFunc) (*Withdraw, error) {
+func NewWithdraw(client node.EthClient, fees *fee.Oracle, store Store, signer wallet.Signer, retry Retrier, cfg WithdrawConfig, shutdown context.CancelCauseFunc) (*Withdraw, error) {
+	if len(cfg.HotWallets) == 0 {
+		return nil, errors.New("at least one hot wallet is required")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.StuckTimeout <= 0 {
+		cfg.StuckTimeout = time.Minute * 3
+	}
 	resCtx, resCancel := context.WithCancel(context.Background())
 
 	return &Withdraw{
 		client:         client,
+		fees:           fees,
+		store:          store,
+		signer:         signer,
+		retry:          retry,
+		chainId:        new(big.Int).SetUint64(cfg.ChainId),
+		capability:     cfg.TxCapability,
+		hotWallets:     cfg.HotWallets,
+		workers:        cfg.Workers,
+		stuckTimeout:   cfg.StuckTimeout,
 		resourceCtx:    resCtx,
 		resourceCancel: resCancel,
 		tasks: tasks.Group{
@@ -36,19 +98,426 @@ func (w *Withdraw) Close() error {
 	var result error
 	w.resourceCancel()
 	if err := w.tasks.Wait(); err != nil {
-		result = errors.Join(result, fmt.Errorf("failed to await withdraw %w"), err)
+		result = errors.Join(result, fmt.Errorf("failed to await withdraw: %w", err))
 	}
-	return nil
+	return result
 }
 
 func (w *Withdraw) Start() error {
 	log.Info("start withdraw......")
-	tickerWithdrawWorker := time.NewTicker(time.Second * 5)
+	if err := w.store.EnsureAddresses(w.hotWallets, global_const.AddressTypeHot); err != nil {
+		return err
+	}
+
+	// 多个签名协程并发处理排队中的提现，nonce 由数据库行锁串行分配
+	for i := 0; i < w.workers; i++ {
+		worker := i
+		w.tasks.Go(func() error {
+			tickerWithdrawWorker := time.NewTicker(time.Second * 5)
+			defer tickerWithdrawWorker.Stop()
+			for {
+				select {
+				case <-tickerWithdrawWorker.C:
+					if err := w.processQueued(); err != nil {
+						log.Error("process queued withdraws fail", "worker", worker, "err", err)
+					}
+				case <-w.resourceCtx.Done():
+					return nil
+				}
+			}
+		})
+	}
+
+	tickerConfirmWorker := time.NewTicker(time.Second * 5)
 	w.tasks.Go(func() error {
-		for range tickerWithdrawWorker.C {
-			log.Info("Withdraw work task go")
+		defer tickerConfirmWorker.Stop()
+		for {
+			select {
+			case <-tickerConfirmWorker.C:
+				if err := w.rebroadcastSigned(); err != nil {
+					log.Error("rebroadcast signed withdraws fail", "err", err)
+				}
+				if err := w.confirmBroadcast(); err != nil {
+					log.Error("confirm withdraws fail", "err", err)
+				}
+				if err := w.replaceStuck(); err != nil {
+					log.Error("replace stuck withdraws fail", "err", err)
+				}
+			case <-w.resourceCtx.Done():
+				log.Info("stop withdraw......")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+// Submit 提交提现请求，未指定出款地址时使用默认热钱包，未指定类型时为用户提现；
+// 用户提现必须指定 UserAddress，创建时在同一事务内扣减该地址的余额，余额不足返回 ErrInsufficientBalance；
+// RequestId 重复时返回已有记录，已被其他类型的提现使用时返回 ErrInvalidWithdraw
+func (w *Withdraw) Submit(withdraw *model.Withdraw) error {
+	if withdraw.RequestId == "" {
+		return fmt.Errorf("%w: request id is required", ErrInvalidWithdraw)
+	}
+	if !common.IsHexAddress(withdraw.ToAddress) {
+		return fmt.Errorf("%w: invalid to address %q", ErrInvalidWithdraw, withdraw.ToAddress)
+	}
+	if withdraw.TokenAddress == "" {
+		withdraw.TokenAddress = global_const.EthAddress
+	} else if !common.IsHexAddress(withdraw.TokenAddress) {
+		return fmt.Errorf("%w: invalid token address %q", ErrInvalidWithdraw, withdraw.TokenAddress)
+	}
+	amount, ok := new(big.Int).SetString(withdraw.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("%w: invalid amount %q", ErrInvalidWithdraw, withdraw.Amount)
+	}
+	if withdraw.FromAddress == "" {
+		withdraw.FromAddress = w.hotWallets[0].String()
+	}
+
+	withdraw.ChainId = w.chainId.Uint64()
+	withdraw.ToAddress = common.HexToAddress(withdraw.ToAddress).String()
+	withdraw.TokenAddress = common.HexToAddress(withdraw.TokenAddress).String()
+	withdraw.FromAddress = common.HexToAddress(withdraw.FromAddress).String()
+	if withdraw.WithdrawType == 0 {
+		withdraw.WithdrawType = global_const.WithdrawTypeUser
+	}
+	if withdraw.WithdrawType == global_const.WithdrawTypeUser {
+		if !common.IsHexAddress(withdraw.UserAddress) {
+			return fmt.Errorf("%w: invalid user address %q", ErrInvalidWithdraw, withdraw.UserAddress)
+		}
+		withdraw.UserAddress = common.HexToAddress(withdraw.UserAddress).String()
+	}
+	withdrawType := withdraw.WithdrawType
+
+	withdraw.Status = global_const.WithdrawStatusQueued
+	if err := w.store.CreateWithdraw(withdraw); err != nil {
+		return err
+	}
+	if withdraw.WithdrawType != withdrawType {
+		return fmt.Errorf("%w: request id %q is already used", ErrInvalidWithdraw, withdraw.RequestId)
+	}
+	return nil
+}
+
+// processQueued 为排队中的提现分配 nonce、签名并广播
+func (w *Withdraw) processQueued() error {
+	withdraws, err := w.store.WithdrawsByStatus(w.chainId.Uint64(), global_const.WithdrawStatusQueued, withdrawBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(withdraws) == 0 {
+		return nil
+	}
+	fees, err := w.fees.Fees(w.resourceCtx)
+	if errors.Is(err, fee.ErrFeeAboveCap) {
+		// 手续费回落前提现保持排队，不占用 nonce
+		log.Warn("withdraw postponed", "err", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, queued := range withdraws {
+		gas, err := w.gasLimit(&queued)
+		if errors.Is(err, fee.ErrEstimateRejected) {
+			// 交易无法执行（如热钱包代币余额不足），不分配 nonce，保持排队等待条件满足或人工取消
+			log.Warn("withdraw estimate rejected, keep queued", "id", queued.ID, "err", err)
+			if err := w.store.UpdateWithdrawStatus(queued.ID, global_const.WithdrawStatusQueued, global_const.WithdrawStatusQueued, map[string]interface{}{
+				"error_message": truncateError(err),
+			}); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		signed, err := w.store.SignWithdraw(w.resourceCtx, queued.ID, func(withdraw *model.Withdraw, nonce uint64) error {
+			return w.signWithdraw(withdraw, nonce, fees, gas)
+		})
+		if errors.Is(err, ErrWithdrawNotQueued) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		log.Info("withdraw signed", "id", signed.ID, "from", signed.FromAddress, "nonce", *signed.Nonce, "hash", signed.TxHash)
+		w.broadcastOrRetry(signed)
+	}
+	return nil
+}
+
+// gasLimit 在签名前估算提现交易的 gas，估算需要访问节点，放在数据库事务之外
+func (w *Withdraw) gasLimit(withdraw *model.Withdraw) (uint64, error) {
+	amount, ok := new(big.Int).SetString(withdraw.Amount, 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", withdraw.Amount)
+	}
+	return w.fees.TransferGasLimit(w.resourceCtx, common.HexToAddress(withdraw.FromAddress), common.HexToAddress(withdraw.TokenAddress), common.HexToAddress(withdraw.ToAddress), amount)
+}
+
+func (w *Withdraw) signWithdraw(withdraw *model.Withdraw, nonce uint64, fees *fee.Fees, gas uint64) error {
+	amount, ok := new(big.Int).SetString(withdraw.Amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q", withdraw.Amount)
+	}
+	from := common.HexToAddress(withdraw.FromAddress)
+	req, err := buildTransferTx(w.chainId, nonce, common.HexToAddress(withdraw.TokenAddress), common.HexToAddress(withdraw.ToAddress), amount, fees, gas)
+	if err != nil {
+		return err
+	}
+	rawTx, txHash, err := w.signTx(from, req)
+	if err != nil {
+		return err
+	}
+
+	withdraw.GasLimit = req.Gas
+	withdraw.GasTipCap = req.GasTipCap.String()
+	withdraw.GasFeeCap = req.GasFeeCap.String()
+	withdraw.TxHash = txHash
+	withdraw.RawTx = rawTx
+	return nil
+}
+
+// signTx 按链支持的交易类型构建并签名交易，远程签名和 HSM 签名随模块关闭取消
+func (w *Withdraw) signTx(from common.Address, req *wallet.TxRequest) (string, string, error) {
+	return wallet.SignTxRequest(w.resourceCtx, w.signer, from, req, w.capability)
+}
+
+// broadcast 广播已签名的交易，节点已经收到过该交易时视为成功
+func (w *Withdraw) broadcast(withdraw *model.Withdraw) error {
+	if err := w.client.SendRawTransactionContext(w.resourceCtx, withdraw.RawTx); err != nil && !isKnownTxError(err) {
+		return err
+	}
+	return w.store.UpdateWithdrawStatus(withdraw.ID, global_const.WithdrawStatusSigned, global_const.WithdrawStatusBroadcast, map[string]interface{}{
+		"broadcast_at": time.Now(),
+	})
+}
+
+// rebroadcastSigned 补发已签名但没有进入重试队列的交易（如进程在广播前退出）
+func (w *Withdraw) rebroadcastSigned() error {
+	withdraws, err := w.store.WithdrawsByStatus(w.chainId.Uint64(), global_const.WithdrawStatusSigned, withdrawBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range withdraws {
+		active, err := w.retry.Active(global_const.RetryKindWithdrawBroadcast, withdraws[i].ID)
+		if err != nil {
+			return err
+		}
+		if active {
+			continue
 		}
+		w.broadcastOrRetry(&withdraws[i])
+	}
+	return nil
+}
+
+// broadcastOrRetry 广播失败的交易交给重试模块按退避策略重发
+func (w *Withdraw) broadcastOrRetry(withdraw *model.Withdraw) {
+	err := w.rebroadcast(withdraw)
+	if err == nil {
+		return
+	}
+	if err := w.retry.Submit(global_const.RetryKindWithdrawBroadcast, withdraw.ID, err); err != nil {
+		log.Error("submit broadcast retry fail", "id", withdraw.ID, "err", err)
+	}
+}
+
+// rebroadcast 广播交易。替换交易手续费不足以顶替内存池中本提现的旧交易时视为已广播，由卡单处理在超时后继续提价；
+// 首次广播的交易被判定手续费不足说明 nonce 被其他交易占用或低于节点下限，返回错误交给重试和人工处理；
+// 交易被节点永久拒绝时改为发送取消交易占住 nonce
+func (w *Withdraw) rebroadcast(withdraw *model.Withdraw) error {
+	err := w.broadcast(withdraw)
+	switch {
+	case err == nil:
 		return nil
+	case isUnderpricedError(err):
+		txs, txErr := w.store.WithdrawTxs(withdraw.ID)
+		if txErr != nil {
+			return errors.Join(err, txErr)
+		}
+		if len(txs) < 2 {
+			return err
+		}
+		return w.store.UpdateWithdrawStatus(withdraw.ID, global_const.WithdrawStatusSigned, global_const.WithdrawStatusBroadcast, map[string]interface{}{
+			"broadcast_at": time.Now(),
+		})
+	case isNonceTooLowError(err):
+		return w.markMined(withdraw.ID, err)
+	case isRejectedTxError(err):
+		return w.cancelRejected(withdraw.ID, err)
+	}
+	return err
+}
+
+// markMined 替换交易广播时同 nonce 的旧交易可能已经上链，节点返回 nonce 过低。
+// 提现的某次上链尝试已被打包时转为已广播，由确认流程按实际上链的交易更新状态；
+// 都没有打包说明 nonce 被提现以外的交易占用，返回原因交给重试和人工处理
+func (w *Withdraw) markMined(id uint64, cause error) error {
+	mined, _, err := w.minedTx(id)
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	if mined == nil {
+		return cause
+	}
+	log.Info("withdraw nonce already mined", "id", id, "hash", mined.TxHash)
+	return w.store.UpdateWithdrawStatus(id, global_const.WithdrawStatusSigned, global_const.WithdrawStatusBroadcast, map[string]interface{}{
+		"broadcast_at": time.Now(),
+	})
+}
+
+// cancelRejected 被节点永久拒绝的交易不会上链，它占用的 nonce 会让该地址之后的所有交易无法打包。
+// 以相同 nonce 签名取消交易（0 金额转给自己）填补 nonce，取消交易上链后提现标记为已取消，
+// 拒绝原因保存在提现的错误信息中。取消交易本身也被拒绝时返回原因，交给重试和人工处理
+func (w *Withdraw) cancelRejected(id uint64, cause error) error {
+	fees, err := w.fees.Fees(w.resourceCtx)
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	replaced, err := w.store.ReplaceWithdraw(id, func(withdraw *model.Withdraw, latest *model.WithdrawTx) (*model.WithdrawTx, error) {
+		if withdraw.Status != global_const.WithdrawStatusSigned || latest == nil {
+			return nil, ErrWithdrawNotPending
+		}
+		if latest.TxType == global_const.WithdrawTxTypeCancel {
+			return nil, cause
+		}
+		withdraw.ErrorMessage = truncateError(cause)
+		return w.resign(withdraw, latest, global_const.WithdrawTxTypeCancel, fees)
 	})
+	if errors.Is(err, ErrWithdrawNotPending) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.Warn("rejected withdraw replaced by cancel", "id", replaced.ID, "nonce", *replaced.Nonce, "hash", replaced.TxHash, "cause", cause)
+	return w.rebroadcast(replaced)
+}
+
+// RetryBroadcast 重试模块回调，提现仍处于已签名状态时重新广播
+func (w *Withdraw) RetryBroadcast(id uint64) error {
+	withdraw, err := w.store.WithdrawById(id)
+	if err != nil {
+		return err
+	}
+	if withdraw.Status != global_const.WithdrawStatusSigned {
+		return nil
+	}
+	return w.rebroadcast(withdraw)
+}
+
+// minedTx 在提现的全部上链尝试中查找已被打包的交易，都未打包时返回 nil
+func (w *Withdraw) minedTx(withdrawId uint64) (*model.WithdrawTx, *types.Receipt, error) {
+	txs, err := w.store.WithdrawTxs(withdrawId)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range txs {
+		receipt, err := w.client.TxReceiptByHashContext(w.resourceCtx, common.HexToHash(txs[i].TxHash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		return &txs[i], receipt, nil
+	}
+	return nil, nil, nil
+}
+
+// confirmBroadcast 查询已广播提现所有上链尝试的回执，按被打包的交易标记成功、失败或已取消
+func (w *Withdraw) confirmBroadcast() error {
+	withdraws, err := w.store.WithdrawsByStatus(w.chainId.Uint64(), global_const.WithdrawStatusBroadcast, withdrawBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, withdraw := range withdraws {
+		mined, receipt, err := w.minedTx(withdraw.ID)
+		if err != nil {
+			return err
+		}
+		if mined == nil {
+			continue
+		}
+
+		fields := map[string]interface{}{
+			"tx_hash":      mined.TxHash,
+			"raw_tx":       mined.RawTx,
+			"gas_tip_cap":  mined.GasTipCap,
+			"gas_fee_cap":  mined.GasFeeCap,
+			"block_hash":   receipt.BlockHash.String(),
+			"block_number": receipt.BlockNumber.Uint64(),
+		}
+		// 取消交易上链时保留取消前记录的原因（如交易被节点拒绝）
+		status := uint8(global_const.WithdrawStatusCancelled)
+		if mined.TxType != global_const.WithdrawTxTypeCancel {
+			status = global_const.WithdrawStatusConfirmed
+			fields["error_message"] = ""
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				status = global_const.WithdrawStatusFailed
+				fields["error_message"] = "transaction reverted"
+			}
+		}
+		err = w.store.UpdateWithdrawStatus(withdraw.ID, global_const.WithdrawStatusBroadcast, status, fields)
+		if err != nil {
+			return err
+		}
+		log.Info("withdraw mined", "id", withdraw.ID, "hash", mined.TxHash, "txType", mined.TxType, "status", receipt.Status)
+	}
+	return nil
+}
+
+// rejectedTxErrors 节点对交易本身的校验失败，同一笔交易无论重发多少次都不会被接受。
+// 余额不足不在其中，热钱包补充资金后原交易仍可以广播
+var rejectedTxErrors = []string{
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"exceeds the configured cap",
+	"invalid sender",
+	"oversized data",
+	"transaction type not supported",
+	"max priority fee per gas higher than max fee per gas",
+}
+
+func isRejectedTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, rejected := range rejectedTxErrors {
+		if strings.Contains(msg, rejected) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateError 错误信息按提现表 error_message 字段的长度截断
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+	return msg
+}
+
+func isNonceTooLowError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce has already been used")
+}
+
+func isKnownTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// BlockAdded 扫块儿新区块回调
+func (w *Withdraw) BlockAdded(header *types.Header) error {
+	log.Debug("withdraw block added", "number", header.Number, "hash", header.Hash())
+	return nil
+}
+
+// BlockReverted 链重组区块回滚回调，该区块中已上链的提现退回已广播状态重新确认
+func (w *Withdraw) BlockReverted(block node.BlockRef) error {
+	if err := w.store.RevertWithdraws(block.Hash.String()); err != nil {
+		return fmt.Errorf("unable to revert withdraws of block %s: %w", block.Hash, err)
+	}
+	log.Debug("withdraw block reverted", "number", block.Number, "hash", block.Hash)
 	return nil
 }
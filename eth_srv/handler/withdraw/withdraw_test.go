@@ -0,0 +1,632 @@
+package withdraw
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/fee"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler/node"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
+	"github.com/0xweb-3/CoinNest/eth_srv/wallet"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testPrivateKey = "0cbb2ff952da876c4779200c83f6b90d73ea85a8da82e06c2276a11499922720"
+
+func testHotWallet(t *testing.T) (config.WalletConfig, wallet.Signer) {
+	key, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.WalletConfig{Address: crypto.PubkeyToAddress(key.PublicKey).String()}, wallet.NewKeySigner(key)
+}
+
+func testOracle(chainId uint64, maxFeeCap *big.Int) *fee.Oracle {
+	return fee.NewOracle(nil, fee.OracleConfig{Capability: global_const.TxCapabilityOf(chainId), MaxFeeCap: maxFeeCap})
+}
+
+// fakeClient 记录广播的交易，sendErr 决定节点对每笔交易的响应
+type fakeClient struct {
+	node.EthClient
+	sent     []string
+	sendErr  func(rawTx string) error
+	receipts map[common.Hash]*types.Receipt
+	estErr   error
+	feeErr   error
+}
+
+func (c *fakeClient) EstimateGasContext(context.Context, ethereum.CallMsg) (uint64, error) {
+	return fee.NativeTransferGasLimit, c.estErr
+}
+
+// revertError 模拟节点返回的执行回滚错误
+type revertError struct{}
+
+func (revertError) Error() string          { return "execution reverted" }
+func (revertError) ErrorCode() int         { return 3 }
+func (revertError) ErrorData() interface{} { return "0x" }
+
+func (c *fakeClient) SendRawTransactionContext(_ context.Context, rawTx string) error {
+	c.sent = append(c.sent, rawTx)
+	if c.sendErr != nil {
+		return c.sendErr(rawTx)
+	}
+	return nil
+}
+
+func (c *fakeClient) TxReceiptByHashContext(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := c.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (c *fakeClient) FeeHistoryContext(context.Context, uint64, *big.Int, []float64) (*ethereum.FeeHistory, error) {
+	if c.feeErr != nil {
+		return nil, c.feeErr
+	}
+	return &ethereum.FeeHistory{
+		BaseFee: []*big.Int{big.NewInt(10e9)},
+		Reward:  [][]*big.Int{{big.NewInt(1e9), big.NewInt(1e9), big.NewInt(1e9)}},
+	}, nil
+}
+
+// memStore 只实现测试用到的提现和上链尝试的读写
+type memStore struct {
+	Store
+	withdraws map[uint64]*model.Withdraw
+	txs       []model.WithdrawTx
+}
+
+func newMemStore(withdraws ...*model.Withdraw) *memStore {
+	s := &memStore{withdraws: make(map[uint64]*model.Withdraw)}
+	for _, withdraw := range withdraws {
+		s.withdraws[withdraw.ID] = withdraw
+		if withdraw.TxHash != "" {
+			s.txs = append(s.txs, model.WithdrawTx{
+				WithdrawId: withdraw.ID,
+				Nonce:      *withdraw.Nonce,
+				TxType:     global_const.WithdrawTxTypeOriginal,
+				TxHash:     withdraw.TxHash,
+				RawTx:      withdraw.RawTx,
+				GasTipCap:  withdraw.GasTipCap,
+				GasFeeCap:  withdraw.GasFeeCap,
+			})
+		}
+	}
+	return s
+}
+
+func (s *memStore) CreateWithdraw(withdraw *model.Withdraw) error {
+	for _, existing := range s.withdraws {
+		if existing.RequestId == withdraw.RequestId {
+			*withdraw = *existing
+			return nil
+		}
+	}
+	withdraw.ID = uint64(len(s.withdraws) + 1)
+	created := *withdraw
+	s.withdraws[withdraw.ID] = &created
+	return nil
+}
+
+func (s *memStore) WithdrawById(id uint64) (*model.Withdraw, error) {
+	withdraw := *s.withdraws[id]
+	return &withdraw, nil
+}
+
+func (s *memStore) WithdrawsByStatus(_ uint64, status uint8, _ int) ([]model.Withdraw, error) {
+	var withdraws []model.Withdraw
+	for _, withdraw := range s.withdraws {
+		if withdraw.Status == status {
+			withdraws = append(withdraws, *withdraw)
+		}
+	}
+	return withdraws, nil
+}
+
+func (s *memStore) WithdrawTxs(withdrawId uint64) ([]model.WithdrawTx, error) {
+	var txs []model.WithdrawTx
+	for i := len(s.txs) - 1; i >= 0; i-- {
+		if s.txs[i].WithdrawId == withdrawId {
+			txs = append(txs, s.txs[i])
+		}
+	}
+	return txs, nil
+}
+
+func (s *memStore) ReplaceWithdraw(id uint64, replace ReplaceFunc) (*model.Withdraw, error) {
+	withdraw := *s.withdraws[id]
+	var latest *model.WithdrawTx
+	for i := range s.txs {
+		if s.txs[i].TxHash == withdraw.TxHash {
+			latest = &s.txs[i]
+		}
+	}
+	replacement, err := replace(&withdraw, latest)
+	if err != nil {
+		return nil, err
+	}
+	s.withdraws[id] = &withdraw
+	if replacement != nil {
+		replacement.WithdrawId = id
+		s.txs = append(s.txs, *replacement)
+	}
+	result := withdraw
+	return &result, nil
+}
+
+func (s *memStore) UpdateWithdrawStatus(id uint64, from, to uint8, fields map[string]interface{}) error {
+	withdraw := s.withdraws[id]
+	if withdraw.Status != from {
+		return nil
+	}
+	withdraw.Status = to
+	for k, v := range fields {
+		switch k {
+		case "tx_hash":
+			withdraw.TxHash = v.(string)
+		case "error_message":
+			withdraw.ErrorMessage = v.(string)
+		case "block_hash":
+			withdraw.BlockHash = v.(string)
+		}
+	}
+	return nil
+}
+
+// newSignedWithdraw 创建一笔使用 nonce 7 签名的原生币提现
+func newSignedWithdraw(t *testing.T, client *fakeClient, hot config.WalletConfig, signer wallet.Signer) (*Withdraw, *memStore) {
+	w, err := NewWithdraw(client, fee.NewOracle(client, fee.OracleConfig{Capability: global_const.TxCapabilityOf(global_const.EthereumSepoliaChainId)}), nil, signer, nil, WithdrawConfig{
+		ChainId:      global_const.EthereumSepoliaChainId,
+		TxCapability: global_const.TxCapabilityOf(global_const.EthereumSepoliaChainId),
+		HotWallets:   []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := uint64(7)
+	withdraw := &model.Withdraw{
+		ChainId:      global_const.EthereumSepoliaChainId,
+		FromAddress:  hot.Address,
+		ToAddress:    "0x35096AD62E57e86032a3Bb35aDaCF2240d55421D",
+		TokenAddress: global_const.EthAddress,
+		Amount:       "1000",
+		Status:       global_const.WithdrawStatusSigned,
+		Nonce:        &nonce,
+	}
+	withdraw.ID = 1
+	if err := w.signWithdraw(withdraw, nonce, &fee.Fees{GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(30e9)}, fee.NativeTransferGasLimit); err != nil {
+		t.Fatal(err)
+	}
+	store := newMemStore(withdraw)
+	w.store = store
+	return w, store
+}
+
+func minedReceipt(status uint64) *types.Receipt {
+	return &types.Receipt{Status: status, BlockHash: common.HexToHash("0x01"), BlockNumber: big.NewInt(100)}
+}
+
+func TestRejectedBroadcastCancelsNonce(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	client := &fakeClient{receipts: make(map[common.Hash]*types.Receipt)}
+	w, store := newSignedWithdraw(t, client, hot, signer)
+	original := store.withdraws[1].RawTx
+	client.sendErr = func(rawTx string) error {
+		if rawTx == original {
+			return errors.New("intrinsic gas too low")
+		}
+		return nil
+	}
+
+	if err := w.rebroadcast(store.withdraws[1]); err != nil {
+		t.Fatal(err)
+	}
+	withdraw := store.withdraws[1]
+	if withdraw.Status != global_const.WithdrawStatusBroadcast || !strings.Contains(withdraw.ErrorMessage, "intrinsic gas too low") {
+		t.Fatalf("rejected withdraw should be cancelled at its nonce, got status %d error %q", withdraw.Status, withdraw.ErrorMessage)
+	}
+	if len(client.sent) != 2 || client.sent[1] != withdraw.RawTx {
+		t.Fatalf("cancel tx should be broadcast after the rejection, sent %d txs", len(client.sent))
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(hexutil.MustDecode(withdraw.RawTx)); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce() != 7 || *tx.To() != common.HexToAddress(hot.Address) || tx.Value().Sign() != 0 {
+		t.Fatalf("expected self transfer at nonce 7, got nonce %d to %s value %s", tx.Nonce(), tx.To(), tx.Value())
+	}
+
+	client.receipts[tx.Hash()] = minedReceipt(types.ReceiptStatusSuccessful)
+	if err := w.confirmBroadcast(); err != nil {
+		t.Fatal(err)
+	}
+	if withdraw.Status != global_const.WithdrawStatusCancelled || !strings.Contains(withdraw.ErrorMessage, "intrinsic gas too low") {
+		t.Fatalf("expected cancelled withdraw keeping the rejection reason, got status %d error %q", withdraw.Status, withdraw.ErrorMessage)
+	}
+}
+
+func TestRejectedCancelNotReplacedAgain(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	client := &fakeClient{sendErr: func(string) error { return errors.New("invalid sender") }}
+	w, store := newSignedWithdraw(t, client, hot, signer)
+
+	err := w.rebroadcast(store.withdraws[1])
+	if err == nil || !strings.Contains(err.Error(), "invalid sender") {
+		t.Fatalf("rejected cancel should be returned for retry, got %v", err)
+	}
+	if len(store.txs) != 2 || len(client.sent) != 2 {
+		t.Fatalf("expected a single cancel attempt, got %d txs %d broadcasts", len(store.txs), len(client.sent))
+	}
+}
+
+func TestReplacementAfterOriginalMined(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	client := &fakeClient{receipts: make(map[common.Hash]*types.Receipt)}
+	w, store := newSignedWithdraw(t, client, hot, signer)
+	original := store.withdraws[1].TxHash
+	store.withdraws[1].Status = global_const.WithdrawStatusBroadcast
+
+	// 卡单替换签出加速交易后，原交易先被打包，加速交易广播时节点返回 nonce 过低
+	_, err := store.ReplaceWithdraw(1, func(withdraw *model.Withdraw, latest *model.WithdrawTx) (*model.WithdrawTx, error) {
+		return w.resign(withdraw, latest, global_const.WithdrawTxTypeSpeedUp, &fee.Fees{GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.receipts[common.HexToHash(original)] = minedReceipt(types.ReceiptStatusSuccessful)
+	client.sendErr = func(string) error { return errors.New("nonce too low: next nonce 8, tx nonce 7") }
+
+	if err := w.rebroadcast(store.withdraws[1]); err != nil {
+		t.Fatalf("nonce too low after the original was mined should not be retried, got %v", err)
+	}
+	if err := w.confirmBroadcast(); err != nil {
+		t.Fatal(err)
+	}
+	withdraw := store.withdraws[1]
+	if withdraw.Status != global_const.WithdrawStatusConfirmed || withdraw.TxHash != original {
+		t.Fatalf("expected withdraw confirmed by the original tx %s, got status %d hash %s", original, withdraw.Status, withdraw.TxHash)
+	}
+}
+
+func TestUnderpricedBroadcast(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	client := &fakeClient{sendErr: func(string) error { return errors.New("replacement transaction underpriced") }}
+	w, store := newSignedWithdraw(t, client, hot, signer)
+
+	// 首次广播就被判定替换手续费不足，说明 nonce 被其他交易占用
+	if err := w.rebroadcast(store.withdraws[1]); err == nil {
+		t.Fatal("underpriced first broadcast should be returned for retry")
+	}
+	if store.withdraws[1].Status != global_const.WithdrawStatusSigned {
+		t.Fatalf("withdraw should stay signed, got status %d", store.withdraws[1].Status)
+	}
+
+	// 加速交易不足以顶替本提现的原交易，原交易仍在内存池中
+	_, err := store.ReplaceWithdraw(1, func(withdraw *model.Withdraw, latest *model.WithdrawTx) (*model.WithdrawTx, error) {
+		return w.resign(withdraw, latest, global_const.WithdrawTxTypeSpeedUp, &fee.Fees{GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.rebroadcast(store.withdraws[1]); err != nil {
+		t.Fatal(err)
+	}
+	if store.withdraws[1].Status != global_const.WithdrawStatusBroadcast {
+		t.Fatalf("underpriced replacement should wait as broadcast, got status %d", store.withdraws[1].Status)
+	}
+}
+
+func TestCancelQueuedWithoutFees(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	client := &fakeClient{feeErr: errors.New("connection refused")}
+	w, store := newSignedWithdraw(t, client, hot, signer)
+	queued := store.withdraws[1]
+	queued.Status, queued.Nonce, queued.TxHash = global_const.WithdrawStatusQueued, nil, ""
+
+	if err := w.Cancel(1); err != nil {
+		t.Fatal(err)
+	}
+	if store.withdraws[1].Status != global_const.WithdrawStatusCancelled {
+		t.Fatalf("queued withdraw should be cancelled, got status %d", store.withdraws[1].Status)
+	}
+}
+
+func TestNonceUsedElsewhere(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	client := &fakeClient{sendErr: func(string) error { return errors.New("nonce too low") }}
+	w, store := newSignedWithdraw(t, client, hot, signer)
+
+	if err := w.rebroadcast(store.withdraws[1]); err == nil {
+		t.Fatal("nonce taken by an unknown tx should be returned for retry")
+	}
+	if store.withdraws[1].Status != global_const.WithdrawStatusSigned {
+		t.Fatalf("withdraw should stay signed, got status %d", store.withdraws[1].Status)
+	}
+}
+
+func TestRejectedEstimateKeepsQueued(t *testing.T) {
+	client := &fakeClient{estErr: revertError{}}
+	hot, signer := testHotWallet(t)
+	w, store := newSignedWithdraw(t, client, hot, signer)
+	queued := store.withdraws[1]
+	queued.Status, queued.Nonce, queued.TxHash = global_const.WithdrawStatusQueued, nil, ""
+
+	// 估算被拒绝的提现不签名、不占用 nonce，memStore 未实现 SignWithdraw
+	if err := w.processQueued(); err != nil {
+		t.Fatal(err)
+	}
+	if queued.Status != global_const.WithdrawStatusQueued || !strings.Contains(queued.ErrorMessage, "execution reverted") {
+		t.Fatalf("withdraw should stay queued with the revert reason, got %+v", queued)
+	}
+}
+
+func TestSubmitRequestIdOfInternalTransfer(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	store := newMemStore()
+	w, err := NewWithdraw(nil, testOracle(global_const.EthereumSepoliaChainId, nil), store, signer, nil, WithdrawConfig{
+		ChainId:    global_const.EthereumSepoliaChainId,
+		HotWallets: []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sweep := &model.Withdraw{RequestId: "sweep-1", ToAddress: hot.Address, Amount: "1000", WithdrawType: global_const.WithdrawTypeSweep}
+	if err := w.Submit(sweep); err != nil {
+		t.Fatal(err)
+	}
+
+	// 业务方不能通过相同的 RequestId 取到内部转账
+	user := &model.Withdraw{RequestId: "sweep-1", ToAddress: hot.Address, Amount: "1000", UserAddress: hot.Address}
+	if err := w.Submit(user); !errors.Is(err, ErrInvalidWithdraw) {
+		t.Fatalf("expected request id conflict, got %v", err)
+	}
+	retry := &model.Withdraw{RequestId: "sweep-1", ToAddress: hot.Address, Amount: "1000", WithdrawType: global_const.WithdrawTypeSweep}
+	if err := w.Submit(retry); err != nil || retry.ID != sweep.ID {
+		t.Fatalf("resubmitting the sweep should return the existing withdraw, got id %d err %v", retry.ID, err)
+	}
+}
+
+func TestSubmitUserWithdrawNeedsUserAddress(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	w, err := NewWithdraw(nil, testOracle(global_const.EthereumSepoliaChainId, nil), newMemStore(), signer, nil, WithdrawConfig{
+		ChainId:    global_const.EthereumSepoliaChainId,
+		HotWallets: []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 用户提现没有扣款地址时无法扣减余额，直接拒绝
+	user := &model.Withdraw{RequestId: "user-1", ToAddress: hot.Address, Amount: "1000"}
+	if err := w.Submit(user); !errors.Is(err, ErrInvalidWithdraw) {
+		t.Fatalf("expected invalid withdraw, got %v", err)
+	}
+	user.UserAddress = strings.ToLower(hot.Address)
+	if err := w.Submit(user); err != nil {
+		t.Fatal(err)
+	}
+	if user.UserAddress != hot.Address {
+		t.Fatalf("user address should be checksummed, got %s", user.UserAddress)
+	}
+}
+
+func TestSubtractBalance(t *testing.T) {
+	if remaining, err := subtractBalance("1000", "400"); err != nil || remaining != "600" {
+		t.Fatalf("unexpected remaining %q %v", remaining, err)
+	}
+	if remaining, err := subtractBalance("1000", "1000"); err != nil || remaining != "0" {
+		t.Fatalf("withdrawing the full balance should leave zero, got %q %v", remaining, err)
+	}
+	if _, err := subtractBalance("999", "1000"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance, got %v", err)
+	}
+}
+
+func TestWithdrawEventsSkipInternal(t *testing.T) {
+	withdraws := []*model.Withdraw{
+		{RequestId: "user-1", WithdrawType: global_const.WithdrawTypeUser},
+		{RequestId: "sweep-1", WithdrawType: global_const.WithdrawTypeSweep},
+	}
+	events := withdrawEvents(withdraws)
+	if len(events) != 1 || events[0].RequestId != "user-1" {
+		t.Fatalf("only user withdraws should produce events, got %d", len(events))
+	}
+}
+
+func TestNextNonce(t *testing.T) {
+	if got := nextNonce(5, 3); got != 5 {
+		t.Errorf("pending local nonce should win, got %d", got)
+	}
+	if got := nextNonce(5, 8); got != 8 {
+		t.Errorf("chain nonce should win after external txs, got %d", got)
+	}
+}
+
+func TestSignWithdraw(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	w, err := NewWithdraw(nil, testOracle(global_const.EthereumSepoliaChainId, nil), nil, signer, nil, WithdrawConfig{
+		ChainId:      global_const.EthereumSepoliaChainId,
+		TxCapability: global_const.TxCapabilityOf(global_const.EthereumSepoliaChainId),
+		HotWallets:   []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees := &fee.Fees{GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(30e9)}
+	to := common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	token := common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+
+	tests := []struct {
+		name      string
+		token     common.Address
+		wantTo    common.Address
+		wantValue int64
+		wantData  string
+	}{
+		{name: "native", token: common.HexToAddress(global_const.EthAddress), wantTo: to, wantValue: 1000},
+		{
+			name:   "erc20",
+			token:  token,
+			wantTo: token,
+			wantData: "a9059cbb" +
+				"00000000000000000000000035096ad62e57e86032a3bb35adacf2240d55421d" +
+				"00000000000000000000000000000000000000000000000000000000000003e8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withdraw := &model.Withdraw{
+				FromAddress:  hot.Address,
+				ToAddress:    to.String(),
+				TokenAddress: tt.token.String(),
+				Amount:       "1000",
+			}
+			if err := w.signWithdraw(withdraw, 7, fees, 60000); err != nil {
+				t.Fatal(err)
+			}
+
+			var tx types.Transaction
+			if err := tx.UnmarshalBinary(hexutil.MustDecode(withdraw.RawTx)); err != nil {
+				t.Fatalf("raw tx is not broadcastable: %v", err)
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sender != common.HexToAddress(hot.Address) || tx.Nonce() != 7 || tx.Hash().String() != withdraw.TxHash {
+				t.Fatalf("unexpected tx sender %s nonce %d hash %s", sender, tx.Nonce(), tx.Hash())
+			}
+			if *tx.To() != tt.wantTo || tx.Value().Int64() != tt.wantValue || hex.EncodeToString(tx.Data()) != tt.wantData {
+				t.Fatalf("unexpected tx to %s value %s data %x", tx.To(), tx.Value(), tx.Data())
+			}
+			if tx.GasTipCap().Cmp(fees.GasTipCap) != 0 || tx.GasFeeCap().Cmp(fees.GasFeeCap) != 0 || tx.Gas() != 60000 || withdraw.GasLimit != 60000 {
+				t.Fatalf("unexpected fees %s %s gas %d", tx.GasTipCap(), tx.GasFeeCap(), tx.Gas())
+			}
+		})
+	}
+}
+
+func TestSignWithdrawLegacy(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	w, err := NewWithdraw(nil, testOracle(global_const.OkxChainId, nil), nil, signer, nil, WithdrawConfig{
+		ChainId:      global_const.OkxChainId,
+		TxCapability: global_const.TxCapabilityOf(global_const.OkxChainId),
+		HotWallets:   []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdraw := &model.Withdraw{
+		FromAddress:  hot.Address,
+		ToAddress:    "0x35096AD62E57e86032a3Bb35aDaCF2240d55421D",
+		TokenAddress: global_const.EthAddress,
+		Amount:       "1000",
+	}
+	price := big.NewInt(5e9)
+	if err := w.signWithdraw(withdraw, 3, &fee.Fees{GasTipCap: price, GasFeeCap: price}, fee.NativeTransferGasLimit); err != nil {
+		t.Fatal(err)
+	}
+
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(hexutil.MustDecode(withdraw.RawTx)); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.LegacyTxType || !tx.Protected() || tx.ChainId().Uint64() != global_const.OkxChainId || tx.GasPrice().Cmp(price) != 0 {
+		t.Fatalf("expected eip155 legacy tx, got type %d chain %s price %s", tx.Type(), tx.ChainId(), tx.GasPrice())
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx)
+	if err != nil || sender != common.HexToAddress(hot.Address) {
+		t.Fatalf("unexpected sender %s %v", sender, err)
+	}
+}
+
+func TestBumpFee(t *testing.T) {
+	if got := bumpFee(big.NewInt(100), big.NewInt(50)); got.Int64() != 110 {
+		t.Errorf("replacement fee should be bumped by 10%%, got %s", got)
+	}
+	if got := bumpFee(big.NewInt(101), nil); got.Int64() != 112 {
+		t.Errorf("bumped fee should round up, got %s", got)
+	}
+	if got := bumpFee(big.NewInt(100), big.NewInt(200)); got.Int64() != 200 {
+		t.Errorf("higher suggested fee should win, got %s", got)
+	}
+}
+
+func TestResignCancel(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	w, err := NewWithdraw(nil, testOracle(global_const.EthereumSepoliaChainId, nil), nil, signer, nil, WithdrawConfig{
+		ChainId:      global_const.EthereumSepoliaChainId,
+		TxCapability: global_const.TxCapabilityOf(global_const.EthereumSepoliaChainId),
+		HotWallets:   []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdraw := &model.Withdraw{
+		FromAddress:  hot.Address,
+		ToAddress:    "0x35096AD62E57e86032a3Bb35aDaCF2240d55421D",
+		TokenAddress: "0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238",
+		Amount:       "1000",
+		Status:       global_const.WithdrawStatusBroadcast,
+	}
+	latest := &model.WithdrawTx{Nonce: 7, TxType: global_const.WithdrawTxTypeOriginal, GasTipCap: "1000000000", GasFeeCap: "30000000000"}
+	suggested := &fee.Fees{GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)}
+
+	replacement, err := w.resign(withdraw, latest, global_const.WithdrawTxTypeCancel, suggested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdraw.Status != global_const.WithdrawStatusSigned || replacement.TxHash != withdraw.TxHash || replacement.TxType != global_const.WithdrawTxTypeCancel {
+		t.Fatalf("unexpected withdraw status %d hash %s replacement %+v", withdraw.Status, withdraw.TxHash, replacement)
+	}
+
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(hexutil.MustDecode(withdraw.RawTx)); err != nil {
+		t.Fatal(err)
+	}
+	if *tx.To() != common.HexToAddress(hot.Address) || tx.Value().Sign() != 0 || len(tx.Data()) != 0 || tx.Nonce() != 7 {
+		t.Fatalf("cancel should be an empty self transfer with the same nonce, got to %s value %s nonce %d", tx.To(), tx.Value(), tx.Nonce())
+	}
+	if tx.GasTipCap().Int64() != 1.1e9 || tx.GasFeeCap().Int64() != 33e9 || tx.Gas() != fee.NativeTransferGasLimit {
+		t.Fatalf("unexpected replacement fees %s %s gas %d", tx.GasTipCap(), tx.GasFeeCap(), tx.Gas())
+	}
+}
+
+func TestResignAboveCap(t *testing.T) {
+	hot, signer := testHotWallet(t)
+	w, err := NewWithdraw(nil, testOracle(global_const.EthereumSepoliaChainId, big.NewInt(32e9)), nil, signer, nil, WithdrawConfig{
+		ChainId:      global_const.EthereumSepoliaChainId,
+		TxCapability: global_const.TxCapabilityOf(global_const.EthereumSepoliaChainId),
+		HotWallets:   []common.Address{common.HexToAddress(hot.Address)},
+	}, func(error) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdraw := &model.Withdraw{
+		FromAddress:  hot.Address,
+		ToAddress:    "0x35096AD62E57e86032a3Bb35aDaCF2240d55421D",
+		TokenAddress: global_const.EthAddress,
+		Amount:       "1000",
+		Status:       global_const.WithdrawStatusBroadcast,
+	}
+	latest := &model.WithdrawTx{Nonce: 7, TxType: global_const.WithdrawTxTypeOriginal, GasTipCap: "1000000000", GasFeeCap: "30000000000"}
+	// 提价 10% 后 maxFeePerGas 为 33 gwei，超过 32 gwei 的上限
+	_, err = w.resign(withdraw, latest, global_const.WithdrawTxTypeSpeedUp, &fee.Fees{GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)})
+	if !errors.Is(err, fee.ErrFeeAboveCap) {
+		t.Fatalf("expected fee above cap, got %v", err)
+	}
+	if withdraw.Status != global_const.WithdrawStatusBroadcast || withdraw.RawTx != "" {
+		t.Fatalf("withdraw should be untouched, got status %d", withdraw.Status)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/0xweb-3/CoinNest/eth_srv/global"
 	"github.com/0xweb-3/CoinNest/eth_srv/handler"
 	"github.com/0xweb-3/CoinNest/eth_srv/initialize"
+	"github.com/0xweb-3/CoinNest/eth_srv/model"
 	"github.com/0xweb-3/CoinNest/eth_srv/service"
 	"github.com/0xweb-3/CoinNest/proto"
 	"go.uber.org/zap"
@@ -14,9 +16,23 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+// shutdownTimeout 等待进行中的请求结束的最长时间，超时后强制关闭连接
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	// 离线工具子命令，不依赖配置和数据库
+	if len(os.Args) > 1 && os.Args[1] == "decode-tx" {
+		runDecodeTx(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "signer" {
+		runSignerHost(os.Args[2:])
+		return
+	}
+
 	// 1. 初始化日志
 	initialize.InitLogger()
 
@@ -25,6 +41,9 @@ func main() {
 
 	// 3. 初始化数据库
 	initialize.InitDB()
+	if err := model.CheckIDGenerator(); err != nil {
+		zap.S().Fatalf("failed to init id generator: %v", err)
+	}
 
 	IP := global.ServerConfig.Host
 	Port := global.ServerConfig.Port
@@ -33,13 +52,27 @@ func main() {
 	if err != nil {
 		zap.S().Fatalf("failed to listen: %s", err.Error())
 	}
+	// 启动钱包后台任务，任一模块出现致命错误时触发退出
+	ctx, shutdown := context.WithCancelCause(context.Background())
+	ethWallet, err := handler.NewEthWallet(ctx, global.DB, global.ServerConfig.Eth, shutdown)
+	if err != nil {
+		zap.S().Fatalf("failed to create eth wallet: %v", err)
+	}
+	if err := ethWallet.Start(ctx); err != nil {
+		zap.S().Fatalf("failed to start eth wallet: %v", err)
+	}
+
 	s := grpc.NewServer()
 	//  注册服务
-	ethRepo := handler.NewUserRepo(global.DB)
+	ethRepo, err := handler.NewEthRepo(global.DB, ethWallet.Withdraw(), ethWallet.Retry(), ethWallet.KeySealer(), global.ServerConfig.Eth)
+	if err != nil {
+		zap.S().Fatalf("failed to create eth repo: %v", err)
+	}
 	srv := service.NewEthServer(ethRepo)
-	proto.RegisterUserServer(s, srv)
+	proto.RegisterEthServer(s, srv)
 
 	reflection.Register(s)
+
 	// 启动服务
 	zap.S().Debugf("service listening at: %v", lis.Addr())
 
@@ -50,9 +83,32 @@ func main() {
 	}()
 
 	// 接受服务退出信号
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-quit:
+	case <-ctx.Done():
+		zap.S().Errorf("eth wallet shutdown: %v", context.Cause(ctx))
+	}
 	zap.S().Info("Shutting down GRPC server...")
-	s.GracefulStop()
+	srv.Shutdown()
+	stopServer(s, shutdownTimeout)
+	if err := ethWallet.Stop(context.Background()); err != nil {
+		zap.S().Errorf("failed to stop eth wallet: %v", err)
+	}
+}
+
+// stopServer 优雅停止 gRPC 服务，超过 timeout 仍未结束时强制关闭
+func stopServer(s *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		zap.S().Warnf("grpc graceful stop timed out after %s, forcing stop", timeout)
+		s.Stop()
+	}
 }
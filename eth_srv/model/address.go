@@ -0,0 +1,10 @@
+package model
+
+// Address 钱包管理的地址，AddressType 取值见 global_const.AddressType*
+type Address struct {
+	BaseModel
+	UserId      uint64  `gorm:"not null;default:0;index:idx_address_user"`
+	Address     string  `gorm:"type:char(42);not null;uniqueIndex"`
+	AddressType uint8   `gorm:"not null;default:1"`
+	HdIndex     *uint32 `gorm:"uniqueIndex"` // HD 派生地址在 m/44'/60'/0'/0 下的序号，私钥不保存在服务中
+}
@@ -0,0 +1,9 @@
+package model
+
+// Balance 地址维度的入账余额，充值入账时累加
+type Balance struct {
+	BaseModel
+	Address      string `gorm:"type:char(42);not null;uniqueIndex:idx_balance_address_token"`
+	TokenAddress string `gorm:"type:char(42);not null;uniqueIndex:idx_balance_address_token"`
+	Balance      string `gorm:"type:decimal(65,0);not null;default:0"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"github.com/sony/sonyflake"
@@ -17,18 +18,35 @@ type BaseModel struct {
 // Sonyflake generator
 var sf *sonyflake.Sonyflake
 
+// 固定起始时间，保证服务重启后生成的 ID 仍然递增且不与历史数据冲突。
+// 原先以 time.Now() 作为起始时间，每次重启 ID 都从零开始，会与已有数据的主键冲突
+var sfStartTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// errNoMachineID 机器号取自私有 IPv4 的低 16 位，取不到时拒绝生成 ID，
+// 不能用主机名哈希等可能在多台机器间重复的值代替，否则会生成重复的主键
+var errNoMachineID = errors.New("sonyflake: no private ipv4 address for machine id")
+
 func init() {
 	st := sonyflake.Settings{
-		StartTime: time.Now(),
+		StartTime: sfStartTime,
 	}
+	// 创建失败时不在 init 中 panic，只有真正写库时才报错，不影响只引用模型定义的包
 	sf = sonyflake.NewSonyflake(st)
+}
+
+// CheckIDGenerator 启动时检查 ID 生成器是否可用
+func CheckIDGenerator() error {
 	if sf == nil {
-		panic("Sonyflake not created")
+		return errNoMachineID
 	}
+	return nil
 }
 
 // BeforeCreate will set a unique ID using Sonyflake
 func (base *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if sf == nil {
+		return errNoMachineID
+	}
 	id, err := sf.NextID()
 	if err != nil {
 		return err
@@ -0,0 +1,11 @@
+package model
+
+// Block 扫块儿持久化的最近区块头窗口，用于检测链重组
+type Block struct {
+	BaseModel
+	ChainId    uint64 `gorm:"not null;uniqueIndex:idx_block_chain_number"`
+	Number     uint64 `gorm:"not null;uniqueIndex:idx_block_chain_number"`
+	Hash       string `gorm:"type:char(66);not null;index:idx_block_hash"`
+	ParentHash string `gorm:"type:char(66);not null"`
+	Timestamp  uint64 `gorm:"not null;default:0"`
+}
@@ -0,0 +1,19 @@
+package model
+
+// Collection 用户充值地址归集到热钱包的记录。每一步都先落库再执行，进程中途退出后从记录的状态继续，
+// 对应的补手续费和归集转账都作为提现提交，RequestId 由归集 ID 决定，不会重复转账。
+type Collection struct {
+	BaseModel
+	ChainId       uint64 `gorm:"not null;index:idx_collection_chain_status"`
+	UserAddress   string `gorm:"type:char(42);not null;index:idx_collection_user_token"`
+	HotAddress    string `gorm:"type:char(42);not null"`
+	TokenAddress  string `gorm:"type:char(42);not null;index:idx_collection_user_token"` // 原生币为 global_const.EthAddress
+	Balance       string `gorm:"type:decimal(65,0);not null"`                            // 发现时用户地址的链上余额
+	Amount        string `gorm:"type:decimal(65,0);not null;default:0"`                  // 实际归集金额，原生币扣除预留手续费后确定
+	GasAmount     string `gorm:"type:decimal(65,0);not null;default:0"`                  // ERC-20 归集前补充的手续费
+	GasWithdrawId uint64 `gorm:"not null;default:0"`
+	WithdrawId    uint64 `gorm:"not null;default:0"`
+	TxHash        string `gorm:"type:char(66);not null;default:''"`
+	Status        uint8  `gorm:"not null;default:1;index:idx_collection_chain_status"` // 取值见 global_const.CollectionStatus*
+	ErrorMessage  string `gorm:"type:varchar(255);not null;default:''"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// Deposit 充值记录，同一笔交易在重组后被打包进不同区块时会产生新的记录
+type Deposit struct {
+	BaseModel
+	ChainId       uint64 `gorm:"not null;index:idx_deposit_chain_number"`
+	BlockHash     string `gorm:"type:char(66);not null;uniqueIndex:idx_deposit_block_tx"`
+	BlockNumber   uint64 `gorm:"not null;index:idx_deposit_chain_number"`
+	TxHash        string `gorm:"type:char(66);not null;uniqueIndex:idx_deposit_block_tx"`
+	TxIndex       uint64 `gorm:"not null;default:0"`
+	LogIndex      uint64 `gorm:"not null;default:0;uniqueIndex:idx_deposit_block_tx"`     // ERC-20 充值对应 Transfer 日志的序号
+	TokenAddress  string `gorm:"type:char(42);not null;uniqueIndex:idx_deposit_block_tx"` // 原生币为 global_const.EthAddress
+	FromAddress   string `gorm:"type:char(42);not null"`
+	ToAddress     string `gorm:"type:char(42);not null;index:idx_deposit_to"`
+	Amount        string `gorm:"type:decimal(65,0);not null"`
+	Status        uint8  `gorm:"not null;default:1;index:idx_deposit_status"` // 取值见 global_const.DepositStatus*
+	Confirmations uint64 `gorm:"not null;default:0"`
+}
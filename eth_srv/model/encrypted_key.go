@@ -0,0 +1,11 @@
+package model
+
+// EncryptedKey 信封加密保存的地址私钥，Ciphertext 由每条记录独立的数据密钥加密，
+// 数据密钥由 MasterKeyId 对应的主密钥包裹，轮换主密钥时只重新包裹数据密钥
+type EncryptedKey struct {
+	BaseModel
+	Address        string `gorm:"type:char(42);not null;uniqueIndex"`
+	MasterKeyId    string `gorm:"type:varchar(32);not null;index"`
+	WrappedDataKey []byte `gorm:"type:varbinary(512);not null"` // KMS 返回的密文比本地包裹长
+	Ciphertext     []byte `gorm:"type:varbinary(128);not null"`
+}
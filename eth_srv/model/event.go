@@ -0,0 +1,19 @@
+package model
+
+// Event 充值和提现的状态变更事件，与状态变更在同一事务内写入。
+// Seq 在计数器行锁内分配，提交顺序与序号顺序一致，订阅方按 Seq 续传不会漏掉事件
+type Event struct {
+	BaseModel
+	Seq          uint64 `gorm:"not null;uniqueIndex"`
+	EventType    string `gorm:"type:varchar(16);not null"` // 取值见 global_const.EventType*
+	RefId        uint64 `gorm:"not null"`                  // 充值或提现的 ID
+	RequestId    string `gorm:"type:varchar(64);not null;default:''"`
+	Status       uint8  `gorm:"not null"` // 变更后的状态，按 EventType 对应 DepositStatus* 或 WithdrawStatus*
+	TxHash       string `gorm:"type:char(66);not null;default:''"`
+	Address      string `gorm:"type:char(42);not null"` // 充值的收款地址或提现的出款地址
+	FromAddress  string `gorm:"type:char(42);not null;default:''"`
+	ToAddress    string `gorm:"type:char(42);not null;default:''"`
+	TokenAddress string `gorm:"type:char(42);not null"`
+	Amount       string `gorm:"type:decimal(65,0);not null"`
+	BlockNumber  uint64 `gorm:"not null;default:0"`
+}
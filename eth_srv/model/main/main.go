@@ -48,7 +48,7 @@ func main() {
 		panic(err)
 	}
 	// 迁移生成表
-	err = db.AutoMigrate(&model.User{})
+	err = db.AutoMigrate(&model.User{}, &model.Block{}, &model.Address{}, &model.Deposit{}, &model.Balance{}, &model.Withdraw{}, &model.WithdrawTx{}, &model.Nonce{}, &model.Sweep{}, &model.Collection{}, &model.RetryTask{}, &model.Event{}, &model.Sequence{}, &model.WebhookDelivery{}, &model.EncryptedKey{})
 	if err != nil {
 		panic(err)
 	}
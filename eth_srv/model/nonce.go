@@ -0,0 +1,9 @@
+package model
+
+// Nonce 热钱包地址的下一个可用 nonce，分配时加行锁保证并发安全
+type Nonce struct {
+	BaseModel
+	ChainId uint64 `gorm:"not null;uniqueIndex:idx_nonce_chain_address"`
+	Address string `gorm:"type:char(42);not null;uniqueIndex:idx_nonce_chain_address"`
+	Nonce   uint64 `gorm:"not null;default:0"`
+}
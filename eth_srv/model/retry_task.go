@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// RetryTask 失败后等待重试的任务，Kind 决定由哪个模块处理，RefId 为对应记录的 ID
+type RetryTask struct {
+	BaseModel
+	Kind        string    `gorm:"type:varchar(32);not null;index:idx_retry_task_ref"` // 取值见 global_const.RetryKind*
+	RefId       uint64    `gorm:"not null;index:idx_retry_task_ref"`
+	Status      uint8     `gorm:"not null;default:1;index:idx_retry_task_due"` // 取值见 global_const.RetryStatus*
+	Attempts    uint32    `gorm:"not null;default:0"`
+	MaxAttempts uint32    `gorm:"not null"`
+	NextRunAt   time.Time `gorm:"not null;index:idx_retry_task_due"`
+	LastError   string    `gorm:"type:varchar(1024);not null;default:''"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// Sequence 按名称分配的递增序号，Value 为已分配的最大值
+type Sequence struct {
+	BaseModel
+	Name  string `gorm:"type:varchar(32);not null;uniqueIndex"`
+	Value uint64 `gorm:"not null;default:0"`
+}
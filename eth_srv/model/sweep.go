@@ -0,0 +1,18 @@
+package model
+
+// Sweep 热钱包归集到冷钱包的记录，转账本身作为一笔提现走统一的签名和 nonce 流程
+type Sweep struct {
+	BaseModel
+	ChainId       uint64 `gorm:"not null;index:idx_sweep_chain_status"`
+	HotAddress    string `gorm:"type:char(42);not null;index:idx_sweep_hot_token"`
+	ColdAddress   string `gorm:"type:char(42);not null"`
+	TokenAddress  string `gorm:"type:char(42);not null;index:idx_sweep_hot_token"` // 原生币为 global_const.EthAddress
+	Balance       string `gorm:"type:decimal(65,0);not null"`                      // 触发归集时热钱包的链上余额
+	HighWatermark string `gorm:"type:decimal(65,0);not null"`
+	LowWatermark  string `gorm:"type:decimal(65,0);not null"`
+	Amount        string `gorm:"type:decimal(65,0);not null"`
+	Reason        string `gorm:"type:varchar(255);not null;default:''"`
+	WithdrawId    uint64 `gorm:"not null;default:0"` // 对应提现的 RequestId 为 "sweep-<ID>"
+	TxHash        string `gorm:"type:char(66);not null;default:''"`
+	Status        uint8  `gorm:"not null;default:1;index:idx_sweep_chain_status"` // 与对应提现同步，取值见 global_const.WithdrawStatus*
+}
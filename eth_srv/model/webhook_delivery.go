@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// WebhookDelivery 一个事件到一个订阅方回调地址的投递记录，Payload 为首次生成的请求体，重试时原样发送
+type WebhookDelivery struct {
+	BaseModel
+	Subscriber   string `gorm:"type:varchar(32);not null;uniqueIndex:idx_webhook_delivery_event;index:idx_webhook_delivery_subscriber"`
+	EventSeq     uint64 `gorm:"not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventType    string `gorm:"type:varchar(16);not null"`
+	RefId        uint64 `gorm:"not null"`
+	Url          string `gorm:"type:varchar(255);not null"`
+	Payload      string `gorm:"type:text;not null"`
+	Status       uint8  `gorm:"not null;default:1;index:idx_webhook_delivery_status"` // 取值见 global_const.WebhookStatus*
+	Attempts     uint32 `gorm:"not null;default:0"`
+	ResponseCode int    `gorm:"not null;default:0"`
+	ErrorMessage string `gorm:"type:varchar(255);not null;default:''"`
+	DeliveredAt  *time.Time
+}
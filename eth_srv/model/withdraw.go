@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Withdraw 提现记录，从排队到上链的整个流程都持久化在这张表上
+type Withdraw struct {
+	BaseModel
+	ChainId      uint64     `gorm:"not null;index:idx_withdraw_chain_status"`
+	RequestId    string     `gorm:"type:varchar(64);not null;uniqueIndex"` // 业务方请求号，用于幂等
+	FromAddress  string     `gorm:"type:char(42);not null;index:idx_withdraw_from_nonce"`
+	UserAddress  string     `gorm:"type:char(42);not null;default:''"` // 扣减余额的用户地址，内部转账为空
+	ToAddress    string     `gorm:"type:char(42);not null"`
+	TokenAddress string     `gorm:"type:char(42);not null"` // 原生币为 global_const.EthAddress
+	Amount       string     `gorm:"type:decimal(65,0);not null"`
+	WithdrawType uint8      `gorm:"not null;default:1"`                                 // 取值见 global_const.WithdrawType*，内部转账不产生提现事件
+	Status       uint8      `gorm:"not null;default:1;index:idx_withdraw_chain_status"` // 取值见 global_const.WithdrawStatus*
+	Nonce        *uint64    `gorm:"index:idx_withdraw_from_nonce"`
+	GasLimit     uint64     `gorm:"not null;default:0"`
+	GasTipCap    string     `gorm:"type:decimal(65,0);not null;default:0"`
+	GasFeeCap    string     `gorm:"type:decimal(65,0);not null;default:0"`
+	TxHash       string     `gorm:"type:char(66);not null;default:'';index:idx_withdraw_tx_hash"`
+	RawTx        string     `gorm:"type:text"`
+	BroadcastAt  *time.Time // 当前交易最近一次广播的时间，用于判断是否卡住
+	BlockHash    string     `gorm:"type:char(66);not null;default:''"`
+	BlockNumber  uint64     `gorm:"not null;default:0"`
+	ErrorMessage string     `gorm:"type:varchar(255);not null;default:''"`
+}
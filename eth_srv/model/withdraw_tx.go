@@ -0,0 +1,14 @@
+package model
+
+// WithdrawTx 提现的每一次上链尝试。加速和取消都会以相同 nonce 签出新交易，
+// 所有哈希都关联到同一笔提现，任何一笔被打包都能找回对应的提现。
+type WithdrawTx struct {
+	BaseModel
+	WithdrawId uint64 `gorm:"not null;index:idx_withdraw_tx_withdraw"`
+	Nonce      uint64 `gorm:"not null"`
+	TxType     uint8  `gorm:"not null;default:1"` // 取值见 global_const.WithdrawTxType*
+	TxHash     string `gorm:"type:char(66);not null;uniqueIndex"`
+	RawTx      string `gorm:"type:text"`
+	GasTipCap  string `gorm:"type:decimal(65,0);not null;default:0"`
+	GasFeeCap  string `gorm:"type:decimal(65,0);not null;default:0"`
+}
@@ -2,32 +2,211 @@ package service
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/handler"
 	"github.com/0xweb-3/CoinNest/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type EthRepo interface {
-	// GetUserById 获取账号信息
-	GetUserById(ctx context.Context, userId uint64) (*proto.UserInfo, error)
+	// CreateAddress 为用户生成新的充值地址
+	CreateAddress(ctx context.Context, userId uint64) (*proto.AddressInfo, error)
+	// GetAddress 按地址或用户查询充值地址
+	GetAddress(ctx context.Context, userId uint64, address string) (*proto.AddressInfo, error)
+	// GetBalance 查询地址已入账的余额
+	GetBalance(ctx context.Context, address, tokenAddress string) (*proto.GetBalanceResp, error)
+	// SubmitWithdraw 提交提现
+	SubmitWithdraw(ctx context.Context, req *proto.SubmitWithdrawReq) (*proto.WithdrawInfo, error)
+	// GetWithdraw 按 id 或 request_id 查询提现
+	GetWithdraw(ctx context.Context, id uint64, requestId string) (*proto.WithdrawInfo, error)
+	// CancelWithdraw 按 id 或 request_id 取消提现
+	CancelWithdraw(ctx context.Context, id uint64, requestId string) (*proto.WithdrawInfo, error)
+	// ListDeposits 分页查询充值
+	ListDeposits(ctx context.Context, req *proto.ListDepositsReq) (*proto.ListDepositsResp, error)
+	// ListTransactions 分页查询充值和提现
+	ListTransactions(ctx context.Context, req *proto.ListTransactionsReq) (*proto.ListTransactionsResp, error)
+	// EventsAfter 按序号返回 cursor 之后的充值和提现事件
+	EventsAfter(ctx context.Context, cursor string, eventTypes []string, limit int) ([]*proto.Event, error)
+	// ListWebhookDeliveries 分页查询回调投递记录
+	ListWebhookDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesReq) (*proto.ListWebhookDeliveriesResp, error)
+	// ListRetryTasks 按状态查询重试任务
+	ListRetryTasks(ctx context.Context, req *proto.ListRetryTasksReq) (*proto.ListRetryTasksResp, error)
+	// RequeueRetryTask 把死信或已放弃的任务放回队列
+	RequeueRetryTask(ctx context.Context, id uint64) (*proto.RetryTask, error)
+	// AbandonRetryTask 放弃等待重试或死信中的任务
+	AbandonRetryTask(ctx context.Context, id uint64) (*proto.RetryTask, error)
 }
 
+const (
+	eventBatchSize    = 100
+	eventPollInterval = time.Second
+)
+
 type EthServer struct {
-	proto.UnimplementedUserServer
-	userRepo EthRepo
+	proto.UnimplementedEthServer
+	ethRepo  EthRepo
 	log      *zap.SugaredLogger
+	quit     chan struct{}
+	quitOnce sync.Once
 }
 
 func NewEthServer(repo EthRepo) *EthServer {
 	return &EthServer{
-		userRepo: repo,
-		log:      zap.S(),
+		ethRepo: repo,
+		log:     zap.S(),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Shutdown 通知事件订阅流结束，需在 GracefulStop 之前调用，否则长连接会一直阻塞停机
+func (s *EthServer) Shutdown() {
+	s.quitOnce.Do(func() { close(s.quit) })
+}
+
+func (s *EthServer) CreateAddress(ctx context.Context, req *proto.CreateAddressReq) (*proto.AddressInfo, error) {
+	address, err := s.ethRepo.CreateAddress(ctx, req.GetUserId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return address, nil
+}
+
+func (s *EthServer) GetAddress(ctx context.Context, req *proto.GetAddressReq) (*proto.AddressInfo, error) {
+	address, err := s.ethRepo.GetAddress(ctx, req.GetUserId(), req.GetAddress())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return address, nil
+}
+
+func (s *EthServer) GetBalance(ctx context.Context, req *proto.GetBalanceReq) (*proto.GetBalanceResp, error) {
+	balance, err := s.ethRepo.GetBalance(ctx, req.GetAddress(), req.GetTokenAddress())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return balance, nil
+}
+
+func (s *EthServer) SubmitWithdraw(ctx context.Context, req *proto.SubmitWithdrawReq) (*proto.WithdrawInfo, error) {
+	withdraw, err := s.ethRepo.SubmitWithdraw(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(err)
 	}
+	return withdraw, nil
 }
 
-func (s *EthServer) GetUserById(ctx context.Context, req *proto.GetUserByIdReq) (*proto.UserInfo, error) {
-	user, err := s.userRepo.GetUserById(ctx, req.GetId())
+func (s *EthServer) GetWithdraw(ctx context.Context, req *proto.GetWithdrawReq) (*proto.WithdrawInfo, error) {
+	withdraw, err := s.ethRepo.GetWithdraw(ctx, req.GetId(), req.GetRequestId())
 	if err != nil {
-		return nil, err
+		return nil, s.toStatus(err)
+	}
+	return withdraw, nil
+}
+
+func (s *EthServer) CancelWithdraw(ctx context.Context, req *proto.CancelWithdrawReq) (*proto.WithdrawInfo, error) {
+	withdraw, err := s.ethRepo.CancelWithdraw(ctx, req.GetId(), req.GetRequestId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return withdraw, nil
+}
+
+func (s *EthServer) ListDeposits(ctx context.Context, req *proto.ListDepositsReq) (*proto.ListDepositsResp, error) {
+	deposits, err := s.ethRepo.ListDeposits(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return deposits, nil
+}
+
+func (s *EthServer) ListTransactions(ctx context.Context, req *proto.ListTransactionsReq) (*proto.ListTransactionsResp, error) {
+	transactions, err := s.ethRepo.ListTransactions(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return transactions, nil
+}
+
+// StreamEvents 先补发 cursor 之后的历史事件，追上后轮询新事件，直到客户端断开或服务停机
+func (s *EthServer) StreamEvents(req *proto.StreamEventsReq, stream proto.Eth_StreamEventsServer) error {
+	ctx := stream.Context()
+	cursor := req.GetCursor()
+	for {
+		events, err := s.ethRepo.EventsAfter(ctx, cursor, req.GetEventTypes(), eventBatchSize)
+		if err != nil {
+			return s.toStatus(err)
+		}
+		for _, event := range events {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+			cursor = event.GetCursor()
+		}
+		if len(events) == eventBatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return s.toStatus(ctx.Err())
+		case <-s.quit:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-time.After(eventPollInterval):
+		}
+	}
+}
+
+func (s *EthServer) ListWebhookDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesReq) (*proto.ListWebhookDeliveriesResp, error) {
+	deliveries, err := s.ethRepo.ListWebhookDeliveries(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return deliveries, nil
+}
+
+func (s *EthServer) ListRetryTasks(ctx context.Context, req *proto.ListRetryTasksReq) (*proto.ListRetryTasksResp, error) {
+	tasks, err := s.ethRepo.ListRetryTasks(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return tasks, nil
+}
+
+func (s *EthServer) RequeueRetryTask(ctx context.Context, req *proto.RetryTaskReq) (*proto.RetryTask, error) {
+	task, err := s.ethRepo.RequeueRetryTask(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return task, nil
+}
+
+func (s *EthServer) AbandonRetryTask(ctx context.Context, req *proto.RetryTaskReq) (*proto.RetryTask, error) {
+	task, err := s.ethRepo.AbandonRetryTask(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return task, nil
+}
+
+// toStatus 将仓储层错误转换为 gRPC 状态码，内部错误只记录日志不返回细节
+func (s *EthServer) toStatus(err error) error {
+	switch {
+	case errors.Is(err, handler.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, handler.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, handler.ErrFailedPrecondition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		s.log.Errorf("eth service internal error: %v", err)
+		return status.Error(codes.Internal, "internal error")
 	}
-	return user, nil
 }
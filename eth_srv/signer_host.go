@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/0xweb-3/CoinNest/eth_srv/config"
+	"github.com/0xweb-3/CoinNest/eth_srv/handler"
+	"github.com/0xweb-3/CoinNest/proto"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// runSignerHost signer 子命令，在签名机上用 keystore 或 hsm 后端提供远程签名服务，不依赖数据库和节点
+func runSignerHost(args []string) {
+	if err := signerHost(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func signerHost(args []string) error {
+	flags := flag.NewFlagSet("signer", flag.ContinueOnError)
+	configFile := flags.String("config", "./eth_srv/config/conf/signer.yaml", "signer host config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	v := viper.New()
+	v.SetConfigFile(*configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	var cnf config.SignerHostConfig
+	if err := v.Unmarshal(&cnf); err != nil {
+		return err
+	}
+	if cnf.Listen == "" {
+		return errors.New("signer host listen address is empty")
+	}
+
+	signerServer, tlsConfig, err := handler.NewSignerHost(cnf)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", cnf.Listen)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	proto.RegisterSignerServer(s, signerServer)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-quit
+		stopServer(s, shutdownTimeout)
+	}()
+	fmt.Fprintf(os.Stderr, "signer listening at %s\n", lis.Addr())
+	return s.Serve(lis)
+}
@@ -8,9 +8,9 @@ import (
 )
 
 type EthAddress struct {
-	PrivateKey string `json:"private_key"` // 地址密钥
-	PublicKey  string `json:"public_key"`  // 地址公钥
-	Address    string `json:"address"`     // 地址
+	PrivateKey string `json:"-"`          // 地址密钥，不参与序列化，需要保存时使用信封加密
+	PublicKey  string `json:"public_key"` // 地址公钥
+	Address    string `json:"address"`    // 地址
 }
 
 // CreateAddressFromPrivateKey 通过密钥生成地址
@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const erc20ABI = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"approve","inputs":[{"name":"spender","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+const erc721ABI = `[
+	{"type":"function","name":"transferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"approve","inputs":[{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+const erc1155ABI = `[
+	{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"safeBatchTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"ids","type":"uint256[]"},{"name":"values","type":"uint256[]"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+// gasPriceOracleABI OP-stack GasPriceOracle 与 Scroll L1GasPriceOracle 共同的 L1 数据费查询接口
+const gasPriceOracleABI = `[
+	{"type":"function","name":"getL1Fee","stateMutability":"view","inputs":[{"name":"_data","type":"bytes"}],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+var (
+	Erc20ABI          = mustParseABI(erc20ABI)
+	Erc721ABI         = mustParseABI(erc721ABI)
+	Erc1155ABI        = mustParseABI(erc1155ABI)
+	GasPriceOracleABI = mustParseABI(gasPriceOracleABI)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// packCall 按方法签名编码调用数据，重载方法在 abi 包中会被重命名，因此按签名而不是方法名查找
+func packCall(contract abi.ABI, sig string, args ...interface{}) ([]byte, error) {
+	for _, method := range contract.Methods {
+		if method.Sig != sig {
+			continue
+		}
+		input, err := method.Inputs.Pack(args...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to pack %s: %w", sig, err)
+		}
+		return append(append([]byte{}, method.ID...), input...), nil
+	}
+	return nil, fmt.Errorf("method %s not found", sig)
+}
+
+// Erc20TransferData 构建 ERC-20 transfer(address,uint256) 调用数据
+func Erc20TransferData(to common.Address, amount *big.Int) ([]byte, error) {
+	return packCall(Erc20ABI, "transfer(address,uint256)", to, amount)
+}
+
+// Erc20ApproveData 构建 ERC-20 approve(address,uint256) 调用数据
+func Erc20ApproveData(spender common.Address, amount *big.Int) ([]byte, error) {
+	return packCall(Erc20ABI, "approve(address,uint256)", spender, amount)
+}
+
+// Erc20TransferFromData 构建 ERC-20 transferFrom(address,address,uint256) 调用数据
+func Erc20TransferFromData(from, to common.Address, amount *big.Int) ([]byte, error) {
+	return packCall(Erc20ABI, "transferFrom(address,address,uint256)", from, to, amount)
+}
+
+// Erc20BalanceOfData 构建 ERC-20 balanceOf(address) 查询数据
+func Erc20BalanceOfData(owner common.Address) ([]byte, error) {
+	return packCall(Erc20ABI, "balanceOf(address)", owner)
+}
+
+// Erc721SafeTransferFromData 构建 ERC-721 safeTransferFrom(address,address,uint256) 调用数据，
+// data 不为 nil 时使用带 bytes 参数的重载
+func Erc721SafeTransferFromData(from, to common.Address, tokenId *big.Int, data []byte) ([]byte, error) {
+	if data == nil {
+		return packCall(Erc721ABI, "safeTransferFrom(address,address,uint256)", from, to, tokenId)
+	}
+	return packCall(Erc721ABI, "safeTransferFrom(address,address,uint256,bytes)", from, to, tokenId, data)
+}
+
+// Erc1155SafeTransferFromData 构建 ERC-1155 safeTransferFrom 调用数据
+func Erc1155SafeTransferFromData(from, to common.Address, id, amount *big.Int, data []byte) ([]byte, error) {
+	if data == nil {
+		data = []byte{}
+	}
+	return packCall(Erc1155ABI, "safeTransferFrom(address,address,uint256,uint256,bytes)", from, to, id, amount, data)
+}
+
+// Erc1155SafeBatchTransferFromData 构建 ERC-1155 safeBatchTransferFrom 调用数据，ids 与 amounts 一一对应
+func Erc1155SafeBatchTransferFromData(from, to common.Address, ids, amounts []*big.Int, data []byte) ([]byte, error) {
+	if len(ids) != len(amounts) {
+		return nil, fmt.Errorf("ids and amounts length mismatch: %d != %d", len(ids), len(amounts))
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return packCall(Erc1155ABI, "safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)", from, to, ids, amounts, data)
+}
+
+// UnpackErc20Balance 解析 balanceOf 的返回值
+func UnpackErc20Balance(result []byte) (*big.Int, error) {
+	values, err := Erc20ABI.Unpack("balanceOf", result)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unpack balanceOf result %x: %w", result, err)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// GetL1FeeData 构建 getL1Fee(bytes) 查询数据，参数为 RLP 编码的未签名交易
+func GetL1FeeData(unsignedTx []byte) ([]byte, error) {
+	return packCall(GasPriceOracleABI, "getL1Fee(bytes)", unsignedTx)
+}
+
+// UnpackL1Fee 解析 getL1Fee 的返回值
+func UnpackL1Fee(result []byte) (*big.Int, error) {
+	values, err := GasPriceOracleABI.Unpack("getL1Fee", result)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unpack getL1Fee result %x: %w", result, err)
+	}
+	return values[0].(*big.Int), nil
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	calldataFrom = common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	calldataTo   = common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+)
+
+const (
+	wordFrom = "00000000000000000000000035096ad62e57e86032a3bb35adacf2240d55421d"
+	wordTo   = "0000000000000000000000001c7d4b196cb0c7b01d743fbc6116a902379c7238"
+)
+
+// word 把数值左补零成 32 字节的十六进制
+func word(v uint64) string {
+	return hex.EncodeToString(common.LeftPadBytes(new(big.Int).SetUint64(v).Bytes(), 32))
+}
+
+func TestCalldata(t *testing.T) {
+	usdtAmount, _ := new(big.Int).SetString("1000000000", 10)
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	tests := []struct {
+		name  string
+		build func() ([]byte, error)
+		want  []string
+	}{
+		{
+			name:  "erc20 transfer",
+			build: func() ([]byte, error) { return Erc20TransferData(calldataTo, usdtAmount) },
+			want:  []string{"a9059cbb", wordTo, "000000000000000000000000000000000000000000000000000000003b9aca00"},
+		},
+		{
+			name:  "erc20 approve unlimited",
+			build: func() ([]byte, error) { return Erc20ApproveData(calldataTo, maxUint256) },
+			want:  []string{"095ea7b3", wordTo, strings.Repeat("f", 64)},
+		},
+		{
+			name:  "erc20 transferFrom",
+			build: func() ([]byte, error) { return Erc20TransferFromData(calldataFrom, calldataTo, big.NewInt(1000)) },
+			want:  []string{"23b872dd", wordFrom, wordTo, word(1000)},
+		},
+		{
+			name:  "erc20 balanceOf",
+			build: func() ([]byte, error) { return Erc20BalanceOfData(calldataFrom) },
+			want:  []string{"70a08231", wordFrom},
+		},
+		{
+			name: "erc721 safeTransferFrom",
+			build: func() ([]byte, error) {
+				return Erc721SafeTransferFromData(calldataFrom, calldataTo, big.NewInt(7), nil)
+			},
+			want: []string{"42842e0e", wordFrom, wordTo, word(7)},
+		},
+		{
+			name: "erc721 safeTransferFrom with data",
+			build: func() ([]byte, error) {
+				return Erc721SafeTransferFromData(calldataFrom, calldataTo, big.NewInt(7), []byte{0x12, 0x34})
+			},
+			want: []string{"b88d4fde", wordFrom, wordTo, word(7), word(0x80),
+				word(2), "1234" + strings.Repeat("0", 60)},
+		},
+		{
+			name: "erc1155 safeTransferFrom",
+			build: func() ([]byte, error) {
+				return Erc1155SafeTransferFromData(calldataFrom, calldataTo, big.NewInt(1), big.NewInt(10), nil)
+			},
+			want: []string{"f242432a", wordFrom, wordTo, word(1), word(10), word(0xa0), word(0)},
+		},
+		{
+			name: "erc1155 safeBatchTransferFrom",
+			build: func() ([]byte, error) {
+				return Erc1155SafeBatchTransferFromData(calldataFrom, calldataTo,
+					[]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)}, nil)
+			},
+			want: []string{"2eb2c2d6", wordFrom, wordTo, word(0xa0), word(0x100), word(0x160),
+				word(2), word(1), word(2),
+				word(2), word(10), word(20),
+				word(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hex.EncodeToString(data), strings.Join(tt.want, ""); got != want {
+				t.Fatalf("calldata mismatch\n got %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCalldataErrors(t *testing.T) {
+	if _, err := Erc1155SafeBatchTransferFromData(calldataFrom, calldataTo, []*big.Int{big.NewInt(1)}, nil, nil); err == nil {
+		t.Fatal("expected mismatched ids and amounts to fail")
+	}
+	if _, err := UnpackErc20Balance(nil); err == nil {
+		t.Fatal("expected empty balanceOf result to fail")
+	}
+	balance, err := UnpackErc20Balance(common.FromHex(word(1000)))
+	if err != nil || balance.Int64() != 1000 {
+		t.Fatalf("unexpected balance %v %v", balance, err)
+	}
+}
+
+// TestCalldataMainnetFixtures 用主网真实交易的 input 校验编码结果，交易取自 go-ethereum
+// beacon/engine 测试数据中收录的主网区块（block_capella.json、block_deneb.json）
+func TestCalldataMainnetFixtures(t *testing.T) {
+	amount := func(s string) *big.Int {
+		v, _ := new(big.Int).SetString(s, 16)
+		return v
+	}
+	tests := []struct {
+		name  string
+		build func() ([]byte, error)
+		input string
+	}{
+		{
+			// USDT transfer, tx 0x950ac999cd986add12a79863236a16df73124307ce4addd6f31c2fbe6602087e
+			name: "erc20 transfer",
+			build: func() ([]byte, error) {
+				return Erc20TransferData(common.HexToAddress("0x95362c2df7b2afaff345a6adbb19ed68e9b1e5fa"), amount("f8b7e0"))
+			},
+			input: "a9059cbb00000000000000000000000095362c2df7b2afaff345a6adbb19ed68e9b1e5fa0000000000000000000000000000000000000000000000000000000000f8b7e0",
+		},
+		{
+			// tx 0xc635fc6be8daa8752186fa97947113f3cd617170c95d3ba42f75a14f6ede5b9e
+			name: "erc20 approve",
+			build: func() ([]byte, error) {
+				return Erc20ApproveData(common.HexToAddress("0x3999d2c5207c06bbc5cf8a6bea52966cabb76d41"), amount(strings.Repeat("f", 64)))
+			},
+			input: "095ea7b30000000000000000000000003999d2c5207c06bbc5cf8a6bea52966cabb76d41ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		},
+		{
+			// tx 0x8f8562e9e1ce57ec350ecc788f5b6186bc7048cd376d83455051090e578e9fcd
+			name: "erc20 transferFrom",
+			build: func() ([]byte, error) {
+				return Erc20TransferFromData(common.HexToAddress("0x72b83a114e3254849679673e97b2ea3bd9a3920a"),
+					common.HexToAddress("0xdcff7bdd67eb501f214faf41c9d596b53dbffc5f"), amount("bcee26cd2632f8657"))
+			},
+			input: "23b872dd00000000000000000000000072b83a114e3254849679673e97b2ea3bd9a3920a000000000000000000000000dcff7bdd67eb501f214faf41c9d596b53dbffc5f00000000000000000000000000000000000000000000000bcee26cd2632f8657",
+		},
+		{
+			// tx 0x3f569988fd533cf2e6cf6f16b9150cf354b792e551b51231495d025a04bbd455，
+			// 原 input 末尾另有市场追加的 4 字节标记 360c6ebe，不属于 ABI 编码
+			name: "erc721 safeTransferFrom with data",
+			build: func() ([]byte, error) {
+				return Erc721SafeTransferFromData(common.HexToAddress("0x2725bc53a2f792d4fff5397092ad631f51700aaa"),
+					common.HexToAddress("0x5a98db5d98a9716ec48012c364d42768d7b1e243"), big.NewInt(0x14), []byte{})
+			},
+			input: "b88d4fde0000000000000000000000002725bc53a2f792d4fff5397092ad631f51700aaa0000000000000000000000005a98db5d98a9716ec48012c364d42768d7b1e243000000000000000000000000000000000000000000000000000000000000001400000000000000000000000000000000000000000000000000000000000000800000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			// tx 0xc9cc2066715d4cafe066f884310568ac5d43d463e249dececa697cfbd7999286，同样去掉末尾的 360c6ebe
+			name: "erc1155 safeTransferFrom",
+			build: func() ([]byte, error) {
+				return Erc1155SafeTransferFromData(common.HexToAddress("0x46365df48693de2bf9da6e7e13f84b96689a05dd"),
+					common.HexToAddress("0x098c19790299f2704c4306ae58aa0f4bdf7e8ad0"), big.NewInt(0x56), big.NewInt(1), nil)
+			},
+			input: "f242432a00000000000000000000000046365df48693de2bf9da6e7e13f84b96689a05dd000000000000000000000000098c19790299f2704c4306ae58aa0f4bdf7e8ad00000000000000000000000000000000000000000000000000000000000000056000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(data); got != tt.input {
+				t.Fatalf("calldata mismatch\n got %s\nwant %s", got, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DecodedTx 已签名交易解析结果，数值字段统一用十进制字符串表示，便于直接输出
+type DecodedTx struct {
+	Type          string           `json:"type"`
+	Hash          string           `json:"hash"`
+	ChainId       string           `json:"chain_id,omitempty"`
+	Protected     bool             `json:"protected"`
+	From          string           `json:"from"`
+	To            string           `json:"to,omitempty"`
+	Nonce         uint64           `json:"nonce"`
+	Value         string           `json:"value"`
+	Gas           uint64           `json:"gas"`
+	GasPrice      string           `json:"gas_price,omitempty"`
+	GasTipCap     string           `json:"max_priority_fee_per_gas,omitempty"`
+	GasFeeCap     string           `json:"max_fee_per_gas,omitempty"`
+	BlobGasFeeCap string           `json:"max_fee_per_blob_gas,omitempty"`
+	BlobHashes    []string         `json:"blob_versioned_hashes,omitempty"`
+	AccessList    types.AccessList `json:"access_list,omitempty"`
+	Data          string           `json:"data,omitempty"`
+	Call          *DecodedCall     `json:"call,omitempty"`
+}
+
+// DecodedCall 识别出的代币合约调用
+type DecodedCall struct {
+	Standard  string    `json:"standard"`
+	Method    string    `json:"method"`
+	Signature string    `json:"signature"`
+	Args      []CallArg `json:"args"`
+}
+
+// CallArg 调用参数，Value 为可读格式
+type CallArg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// knownContracts 按顺序匹配方法选择器。ERC-20 与 ERC-721 的 approve/transferFrom 编码一致，
+// 仅凭调用数据无法区分，解码结果标记为 erc20/erc721，参数名同时给出两种含义
+var knownContracts = []struct {
+	standard string
+	contract abi.ABI
+}{
+	{standard: "erc20", contract: Erc20ABI},
+	{standard: "erc721", contract: Erc721ABI},
+	{standard: "erc1155", contract: Erc1155ABI},
+}
+
+// DecodeRawTx 解析十六进制编码的已签名交易（legacy、EIP-2930、EIP-1559、EIP-4844），恢复发送方并解码已知的代币调用
+func DecodeRawTx(raw string) (*DecodedTx, error) {
+	b, err := hexutil.Decode(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw tx hex: %w", err)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("unable to decode raw tx: %w", err)
+	}
+	return DecodeTx(&tx)
+}
+
+// DecodeTx 解析已签名交易
+func DecodeTx(tx *types.Transaction) (*DecodedTx, error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recover sender: %w", err)
+	}
+
+	out := &DecodedTx{
+		Type:       txTypeName(tx.Type()),
+		Hash:       tx.Hash().String(),
+		Protected:  tx.Protected(),
+		From:       from.String(),
+		Nonce:      tx.Nonce(),
+		Value:      tx.Value().String(),
+		Gas:        tx.Gas(),
+		AccessList: tx.AccessList(),
+	}
+	if tx.Protected() {
+		out.ChainId = tx.ChainId().String()
+	}
+	if tx.To() != nil {
+		out.To = tx.To().String()
+	}
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		out.GasPrice = tx.GasPrice().String()
+	default:
+		out.GasTipCap = tx.GasTipCap().String()
+		out.GasFeeCap = tx.GasFeeCap().String()
+	}
+	if tx.Type() == types.BlobTxType {
+		out.BlobGasFeeCap = tx.BlobGasFeeCap().String()
+		for _, hash := range tx.BlobHashes() {
+			out.BlobHashes = append(out.BlobHashes, hash.String())
+		}
+	}
+	if len(tx.Data()) > 0 {
+		out.Data = hexutil.Encode(tx.Data())
+		out.Call = DecodeCalldata(tx.Data())
+	}
+	return out, nil
+}
+
+// DecodeCalldata 按已知的 ERC-20/721/1155 方法解码调用数据，无法识别时返回 nil，
+// 多个标准的同名方法都能解码时合并为一个结果，Standard 形如 erc20/erc721
+func DecodeCalldata(data []byte) *DecodedCall {
+	if len(data) < 4 {
+		return nil
+	}
+	var call *DecodedCall
+	for _, known := range knownContracts {
+		method, err := known.contract.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		values, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		// 解码后重新编码，拒绝带多余字节或非规范编码的数据
+		packed, err := method.Inputs.Pack(values...)
+		if err != nil || !bytes.Equal(packed, data[4:]) {
+			continue
+		}
+		if call != nil {
+			call.Standard += "/" + known.standard
+			for i, input := range method.Inputs {
+				if call.Args[i].Name != input.Name {
+					call.Args[i].Name += "/" + input.Name
+				}
+			}
+			continue
+		}
+		call = &DecodedCall{Standard: known.standard, Method: method.RawName, Signature: method.Sig}
+		for i, input := range method.Inputs {
+			call.Args = append(call.Args, CallArg{Name: input.Name, Type: input.Type.String(), Value: formatArg(values[i])})
+		}
+	}
+	return call
+}
+
+func formatArg(value interface{}) string {
+	switch v := value.(type) {
+	case common.Address:
+		return v.String()
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case []*big.Int:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, item.String())
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func txTypeName(txType uint8) string {
+	switch txType {
+	case types.LegacyTxType:
+		return "legacy"
+	case types.AccessListTxType:
+		return "access_list"
+	case types.DynamicFeeTxType:
+		return "dynamic_fee"
+	case types.BlobTxType:
+		return "blob"
+	default:
+		return fmt.Sprintf("unknown(%d)", txType)
+	}
+}
@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+func TestDecodeRawTx(t *testing.T) {
+	key := testKey(t)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	token := common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+	to := common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	transfer, err := Erc20TransferData(to, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainId := big.NewInt(11155111)
+	blobHash := common.HexToHash("0x01a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+	tests := []struct {
+		name     string
+		signer   types.Signer
+		txData   types.TxData
+		wantType string
+		wantCall bool
+	}{
+		{
+			name:     "legacy unprotected",
+			signer:   types.HomesteadSigner{},
+			txData:   &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)},
+			wantType: "legacy",
+		},
+		{
+			name:     "legacy eip155",
+			signer:   types.NewEIP155Signer(chainId),
+			txData:   &types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 100000, To: &token, Value: big.NewInt(0), Data: transfer},
+			wantType: "legacy",
+			wantCall: true,
+		},
+		{
+			name:   "access list",
+			signer: types.LatestSignerForChainID(chainId),
+			txData: &types.AccessListTx{ChainID: chainId, Nonce: 3, GasPrice: big.NewInt(1e9), Gas: 100000, To: &token, Data: transfer,
+				AccessList: types.AccessList{{Address: token, StorageKeys: []common.Hash{{}}}}},
+			wantType: "access_list",
+			wantCall: true,
+		},
+		{
+			name:     "dynamic fee",
+			signer:   types.LatestSignerForChainID(chainId),
+			txData:   &types.DynamicFeeTx{ChainID: chainId, Nonce: 4, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e10), Gas: 100000, To: &token, Data: transfer},
+			wantType: "dynamic_fee",
+			wantCall: true,
+		},
+		{
+			name:   "blob",
+			signer: types.LatestSignerForChainID(chainId),
+			txData: &types.BlobTx{ChainID: uint256.MustFromBig(chainId), Nonce: 5, GasTipCap: uint256.NewInt(1e9), GasFeeCap: uint256.NewInt(3e10),
+				Gas: 21000, To: to, Value: uint256.NewInt(0), BlobFeeCap: uint256.NewInt(1), BlobHashes: []common.Hash{blobHash}},
+			wantType: "blob",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := types.SignNewTx(key, tt.signer, tt.txData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := signed.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeRawTx(hexutil.Encode(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Type != tt.wantType || decoded.From != from.String() || decoded.Hash != signed.Hash().String() || decoded.Nonce != signed.Nonce() {
+				t.Fatalf("unexpected decoded tx %+v", decoded)
+			}
+			if (decoded.Call != nil) != tt.wantCall {
+				t.Fatalf("unexpected call %+v", decoded.Call)
+			}
+			if tt.wantType == "blob" && (len(decoded.BlobHashes) != 1 || decoded.BlobHashes[0] != blobHash.String() || decoded.BlobGasFeeCap != "1") {
+				t.Fatalf("unexpected blob fields %+v", decoded)
+			}
+		})
+	}
+	if _, err := DecodeRawTx("0xzz"); err == nil {
+		t.Fatal("expected invalid hex to fail")
+	}
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	from := common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	to := common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238")
+	batch, err := Erc1155SafeBatchTransferFromData(from, to, []*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)}, []byte{0xab})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := DecodeCalldata(batch)
+	if call == nil || call.Standard != "erc1155" || call.Method != "safeBatchTransferFrom" {
+		t.Fatalf("unexpected call %+v", call)
+	}
+	want := []CallArg{
+		{Name: "from", Type: "address", Value: from.String()},
+		{Name: "to", Type: "address", Value: to.String()},
+		{Name: "ids", Type: "uint256[]", Value: "[1,2]"},
+		{Name: "values", Type: "uint256[]", Value: "[10,20]"},
+		{Name: "data", Type: "bytes", Value: "0xab"},
+	}
+	for i := range want {
+		if call.Args[i] != want[i] {
+			t.Fatalf("arg %d: got %+v, want %+v", i, call.Args[i], want[i])
+		}
+	}
+
+	withData, err := Erc721SafeTransferFromData(from, to, big.NewInt(7), []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call := DecodeCalldata(withData); call == nil || call.Standard != "erc721" || call.Signature != "safeTransferFrom(address,address,uint256,bytes)" {
+		t.Fatalf("unexpected call %+v", call)
+	}
+
+	// approve 的编码在 ERC-20 和 ERC-721 中一致，结果同时给出两种含义
+	approve, err := Erc20ApproveData(to, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call = DecodeCalldata(approve)
+	if call == nil || call.Standard != "erc20/erc721" || call.Method != "approve" || call.Args[0].Name != "spender/to" || call.Args[1].Name != "value/tokenId" || call.Args[1].Value != "7" {
+		t.Fatalf("unexpected ambiguous call %+v", call)
+	}
+
+	transfer, err := Erc20TransferData(to, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call := DecodeCalldata(append(transfer, 0x00)); call != nil {
+		t.Fatalf("trailing bytes should not decode, got %+v", call)
+	}
+	if call := DecodeCalldata([]byte{0xde, 0xad, 0xbe, 0xef}); call != nil {
+		t.Fatalf("unknown selector should not decode, got %+v", call)
+	}
+}
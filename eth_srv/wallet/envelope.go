@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const dataKeyLength = 32
+
+// MasterKey 包裹数据密钥的主密钥，可以是本地文件，也可以接入 KMS，主密钥本身不离开实现
+type MasterKey interface {
+	Id() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// Envelope 信封加密的结果，Ciphertext 由数据密钥加密，数据密钥由 MasterKeyId 对应的主密钥包裹
+type Envelope struct {
+	MasterKeyId    string
+	WrappedDataKey []byte
+	Ciphertext     []byte // nonce || AES-GCM 密文
+}
+
+type fileMasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewFileMasterKey 从文件读取 hex 编码的 32 字节主密钥，作为 KMS 的本地替代
+func NewFileMasterKey(id, path string) (MasterKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read master key %s: %w", id, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("master key %s must be 32 bytes in hex", id)
+	}
+	return NewStaticMasterKey(id, key)
+}
+
+// NewStaticMasterKey 使用给定的 32 字节主密钥
+func NewStaticMasterKey(id string, key []byte) (MasterKey, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &fileMasterKey{id: id, aead: aead}, nil
+}
+
+func (k *fileMasterKey) Id() string {
+	return k.id
+}
+
+func (k *fileMasterKey) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey, []byte(k.id))
+}
+
+func (k *fileMasterKey) Unwrap(wrapped []byte) ([]byte, error) {
+	return open(k.aead, wrapped, []byte(k.id))
+}
+
+// Sealer 只能加密的信封加密接口，保存私钥的模块不需要解密能力
+type Sealer interface {
+	Seal(plaintext, aad []byte) (*Envelope, error)
+}
+
+// Keyring 管理多个主密钥，新记录使用 current 加密，旧记录按记录上的主密钥 id 解密
+type Keyring struct {
+	current MasterKey
+	keys    map[string]MasterKey
+}
+
+func NewKeyring(current string, keys ...MasterKey) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string]MasterKey, len(keys))}
+	for _, key := range keys {
+		if _, ok := keyring.keys[key.Id()]; ok {
+			return nil, fmt.Errorf("duplicate master key %s", key.Id())
+		}
+		keyring.keys[key.Id()] = key
+	}
+	key, ok := keyring.keys[current]
+	if !ok {
+		return nil, fmt.Errorf("current master key %q not found", current)
+	}
+	keyring.current = key
+	return keyring, nil
+}
+
+// Current 当前用于加密的主密钥 id
+func (k *Keyring) Current() string {
+	return k.current.Id()
+}
+
+// Seal 为每条记录生成随机数据密钥加密 plaintext，aad 与记录绑定（如地址），防止密文被挪到其他记录上
+func (k *Keyring) Seal(plaintext, aad []byte) (*Envelope, error) {
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(aead, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := k.current.Wrap(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap data key: %w", err)
+	}
+	return &Envelope{MasterKeyId: k.current.Id(), WrappedDataKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Open 解密信封，调用方用完明文后应尽快清零
+func (k *Keyring) Open(envelope *Envelope, aad []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(envelope)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, envelope.Ciphertext, aad)
+}
+
+// Rewrap 用当前主密钥重新包裹数据密钥，密文不变，用于主密钥轮换
+func (k *Keyring) Rewrap(envelope *Envelope) (*Envelope, error) {
+	if envelope.MasterKeyId == k.current.Id() {
+		return envelope, nil
+	}
+	dataKey, err := k.unwrap(envelope)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+	wrapped, err := k.current.Wrap(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap data key: %w", err)
+	}
+	return &Envelope{MasterKeyId: k.current.Id(), WrappedDataKey: wrapped, Ciphertext: envelope.Ciphertext}, nil
+}
+
+func (k *Keyring) unwrap(envelope *Envelope) ([]byte, error) {
+	key, ok := k.keys[envelope.MasterKeyId]
+	if !ok {
+		return nil, fmt.Errorf("master key %q not found", envelope.MasterKeyId)
+	}
+	dataKey, err := key.Unwrap(envelope.WrappedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key with %s: %w", envelope.MasterKeyId, err)
+	}
+	return dataKey, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, aad)
+}
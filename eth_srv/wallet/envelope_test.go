@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testMasterKey(t *testing.T, id string) MasterKey {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), id)
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(secret)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewFileMasterKey(id, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEnvelopeSealOpen(t *testing.T) {
+	keyring, err := NewKeyring("mk-1", testMasterKey(t, "mk-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("0cbb2ff952da876c4779200c83f6b90d")
+	aad := []byte("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+
+	first, err := keyring.Seal(plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := keyring.Seal(plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每条记录使用独立的数据密钥
+	if bytes.Equal(first.WrappedDataKey, second.WrappedDataKey) || bytes.Equal(first.Ciphertext, second.Ciphertext) {
+		t.Fatal("expected distinct data keys per record")
+	}
+	opened, err := keyring.Open(first, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Fatalf("opened %x, want %x", opened, plaintext)
+	}
+	// 密文挪到其他地址上无法解密
+	if _, err := keyring.Open(first, []byte("0x1111111111111111111111111111111111111111")); err == nil {
+		t.Fatal("expected mismatched aad to fail")
+	}
+}
+
+func TestEnvelopeRewrap(t *testing.T) {
+	oldKey, newKey := testMasterKey(t, "mk-1"), testMasterKey(t, "mk-2")
+	oldRing, err := NewKeyring("mk-1", oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := []byte("address")
+	envelope, err := oldRing.Seal([]byte("secret"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotating, err := NewKeyring("mk-2", oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, err := rotating.Rewrap(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped.MasterKeyId != "mk-2" || !bytes.Equal(rewrapped.Ciphertext, envelope.Ciphertext) {
+		t.Fatalf("rewrap should only replace the wrapped data key: %+v", rewrapped)
+	}
+
+	// 重新包裹完成后旧主密钥可以下线
+	newRing, err := NewKeyring("mk-2", newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := newRing.Open(rewrapped, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(opened) != "secret" {
+		t.Fatalf("opened %q", opened)
+	}
+	if _, err := newRing.Open(envelope, aad); err == nil {
+		t.Fatal("expected retired master key to be unavailable")
+	}
+}
+
+func TestKeyringConfig(t *testing.T) {
+	key := testMasterKey(t, "mk-1")
+	if _, err := NewKeyring("mk-2", key); err == nil {
+		t.Fatal("expected missing current key to be rejected")
+	}
+	if _, err := NewKeyring("mk-1", key, key); err == nil {
+		t.Fatal("expected duplicate key to be rejected")
+	}
+	path := filepath.Join(t.TempDir(), "short")
+	if err := os.WriteFile(path, []byte("abcd"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileMasterKey("short", path); err == nil {
+		t.Fatal("expected short master key to be rejected")
+	}
+}
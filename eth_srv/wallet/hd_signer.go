@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrHDIndexMismatch 派生序号与待签名的地址不对应
+var ErrHDIndexMismatch = errors.New("hd index does not match address")
+
+// HDSigner 持有账户扩展私钥，为其派生出的充值地址签名，部署在签名机上，在线服务只保存扩展公钥。
+// 地址到序号的映射按需从 0 开始派生并缓存，最多派生到 maxIndex
+type HDSigner struct {
+	account   *ExtendedKey
+	maxIndex  uint32
+	lock      sync.Mutex
+	addresses map[common.Address]uint32
+	next      uint32
+}
+
+func NewHDSigner(account *ExtendedKey, maxIndex uint32) (*HDSigner, error) {
+	if !account.IsPrivate() {
+		return nil, errors.New("hd signer requires an extended private key")
+	}
+	return &HDSigner{
+		account:   account,
+		maxIndex:  maxIndex,
+		addresses: make(map[common.Address]uint32),
+	}, nil
+}
+
+// index 查找地址对应的序号，缓存中没有时继续向后派生
+func (s *HDSigner) index(address common.Address) (uint32, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if i, ok := s.addresses[address]; ok {
+		return i, true, nil
+	}
+	for ; s.next <= s.maxIndex; s.next++ {
+		derived, err := DeriveAddress(s.account, s.next)
+		if errors.Is(err, ErrInvalidChild) {
+			continue
+		} else if err != nil {
+			return 0, false, err
+		}
+		s.addresses[derived] = s.next
+		if derived == address {
+			s.next++
+			return s.addresses[derived], true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (s *HDSigner) SignTx(_ context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	i, ok, err := s.index(address)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, address)
+	}
+	return s.signAt(i, tx, chainId)
+}
+
+// SignIndexTx 使用序号 index 派生的密钥签名，调用方提供的序号超出 maxIndex 或派生地址不是 address 时返回 ErrHDIndexMismatch
+func (s *HDSigner) SignIndexTx(index uint32, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	if index > s.maxIndex {
+		return nil, fmt.Errorf("%w: index %d exceeds %d", ErrHDIndexMismatch, index, s.maxIndex)
+	}
+	derived, err := DeriveAddress(s.account, index)
+	if err != nil {
+		return nil, err
+	}
+	if derived != address {
+		return nil, fmt.Errorf("%w: index %d derives %s, not %s", ErrHDIndexMismatch, index, derived, address)
+	}
+	return s.signAt(index, tx, chainId)
+}
+
+func (s *HDSigner) signAt(index uint32, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	child, err := s.account.Derive(fmt.Sprintf("0/%d", index))
+	if err != nil {
+		return nil, err
+	}
+	key, err := child.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), key)
+}
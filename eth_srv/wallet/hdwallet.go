@@ -0,0 +1,352 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	// HardenedOffset BIP-32 强化派生的起始序号
+	HardenedOffset uint32 = 0x80000000
+	// EthAccountPath BIP-44 以太坊第一个账户，用户充值地址为 EthAccountPath/0/i
+	EthAccountPath = "m/44'/60'/0'"
+
+	extendedKeyLength = 78
+)
+
+var (
+	xprvVersion = []byte{0x04, 0x88, 0xad, 0xe4}
+	xpubVersion = []byte{0x04, 0x88, 0xb2, 0x1e}
+	masterKey   = []byte("Bitcoin seed")
+
+	// ErrHardenedFromPublic 扩展公钥不能做强化派生
+	ErrHardenedFromPublic = errors.New("cannot derive hardened child from public key")
+	// ErrInvalidChild 派生结果无效（概率低于 2^-127），按 BIP-32 应跳过该序号
+	ErrInvalidChild = errors.New("invalid child key")
+)
+
+// ExtendedKey BIP-32 扩展密钥，只含公钥时只能做普通派生，在线服务只持有扩展公钥
+type ExtendedKey struct {
+	key         []byte // 私钥为 32 字节，公钥为 33 字节压缩格式
+	chainCode   []byte
+	depth       uint8
+	parentFP    []byte
+	childNumber uint32
+	private     bool
+}
+
+// NewMnemonic 生成 BIP-39 助记词，bitSize 为 128 到 256 之间 32 的倍数
+func NewMnemonic(bitSize int) (string, error) {
+	entropy, err := bip39.NewEntropy(bitSize)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewSeed 校验助记词后生成 BIP-39 种子
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	return bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+}
+
+// NewMasterKey 由种子生成主扩展私钥
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d", len(seed))
+	}
+	mac := hmac.New(sha512.New, masterKey)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(secp256k1N) >= 0 {
+		return nil, ErrInvalidChild
+	}
+	return &ExtendedKey{
+		key:       sum[:32],
+		chainCode: sum[32:],
+		parentFP:  []byte{0, 0, 0, 0},
+		private:   true,
+	}, nil
+}
+
+// IsPrivate 是否为扩展私钥
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.private
+}
+
+// publicKeyBytes 33 字节压缩公钥
+func (k *ExtendedKey) publicKeyBytes() []byte {
+	if !k.private {
+		return k.key
+	}
+	return crypto.CompressPubkey(&k.privateKey().PublicKey)
+}
+
+func (k *ExtendedKey) privateKey() *ecdsa.PrivateKey {
+	key, _ := crypto.ToECDSA(k.key)
+	return key
+}
+
+// Child 派生序号为 i 的子密钥，i >= HardenedOffset 时为强化派生
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	hardened := i >= HardenedOffset
+	if hardened && !k.private {
+		return nil, ErrHardenedFromPublic
+	}
+	data := make([]byte, 0, 37)
+	if hardened {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		data = append(data, k.publicKeyBytes()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, i)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(secp256k1N) >= 0 {
+		return nil, ErrInvalidChild
+	}
+
+	child := &ExtendedKey{
+		chainCode:   sum[32:],
+		depth:       k.depth + 1,
+		parentFP:    hash160(k.publicKeyBytes())[:4],
+		childNumber: i,
+		private:     k.private,
+	}
+	if k.private {
+		key := il.Add(il, new(big.Int).SetBytes(k.key))
+		key.Mod(key, secp256k1N)
+		if key.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+		child.key = key.FillBytes(make([]byte, 32))
+		return child, nil
+	}
+
+	parent, err := crypto.DecompressPubkey(k.key)
+	if err != nil {
+		return nil, err
+	}
+	curve := crypto.S256()
+	x, y := curve.ScalarBaseMult(sum[:32])
+	x, y = curve.Add(x, y, parent.X, parent.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrInvalidChild
+	}
+	child.key = crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	return child, nil
+}
+
+// Derive 按路径依次派生，路径为 "m/44'/60'/0'" 形式的绝对路径或 "0/5" 形式的相对路径
+func (k *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, i := range indexes {
+		if key, err = key.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Neuter 返回对应的扩展公钥
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	if !k.private {
+		return k
+	}
+	return &ExtendedKey{
+		key:         k.publicKeyBytes(),
+		chainCode:   k.chainCode,
+		depth:       k.depth,
+		parentFP:    k.parentFP,
+		childNumber: k.childNumber,
+	}
+}
+
+// PrivateKey 返回私钥，扩展公钥返回错误
+func (k *ExtendedKey) PrivateKey() (*ecdsa.PrivateKey, error) {
+	if !k.private {
+		return nil, errors.New("extended key is public")
+	}
+	return crypto.ToECDSA(k.key)
+}
+
+// Address 扩展密钥对应的以太坊地址
+func (k *ExtendedKey) Address() (common.Address, error) {
+	publicKey, err := crypto.DecompressPubkey(k.publicKeyBytes())
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// String 序列化为 xprv/xpub
+func (k *ExtendedKey) String() string {
+	data := make([]byte, 0, extendedKeyLength+4)
+	if k.private {
+		data = append(data, xprvVersion...)
+	} else {
+		data = append(data, xpubVersion...)
+	}
+	data = append(data, k.depth)
+	data = append(data, k.parentFP...)
+	data = binary.BigEndian.AppendUint32(data, k.childNumber)
+	data = append(data, k.chainCode...)
+	if k.private {
+		data = append(data, 0)
+	}
+	data = append(data, k.key...)
+	checksum := doubleSha256(data)
+	return base58Encode(append(data, checksum[:4]...))
+}
+
+// ParseExtendedKey 解析 xprv/xpub
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	data, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != extendedKeyLength+4 {
+		return nil, fmt.Errorf("invalid extended key length %d", len(data))
+	}
+	payload, checksum := data[:extendedKeyLength], data[extendedKeyLength:]
+	if sum := doubleSha256(payload); !bytes.Equal(sum[:4], checksum) {
+		return nil, errors.New("invalid extended key checksum")
+	}
+	k := &ExtendedKey{
+		depth:       payload[4],
+		parentFP:    payload[5:9],
+		childNumber: binary.BigEndian.Uint32(payload[9:13]),
+		chainCode:   payload[13:45],
+	}
+	switch {
+	case bytes.Equal(payload[:4], xprvVersion):
+		if payload[45] != 0 {
+			return nil, errors.New("invalid extended private key")
+		}
+		key := new(big.Int).SetBytes(payload[46:])
+		if key.Sign() == 0 || key.Cmp(secp256k1N) >= 0 {
+			return nil, errors.New("invalid extended private key")
+		}
+		k.key = payload[46:]
+		k.private = true
+	case bytes.Equal(payload[:4], xpubVersion):
+		if _, err := crypto.DecompressPubkey(payload[45:]); err != nil {
+			return nil, fmt.Errorf("invalid extended public key: %w", err)
+		}
+		k.key = payload[45:]
+	default:
+		return nil, fmt.Errorf("unsupported extended key version %x", payload[:4])
+	}
+	return k, nil
+}
+
+// ParsePath 解析派生路径，' 或 h 表示强化派生
+func ParsePath(path string) ([]uint32, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "m")
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil, nil
+	}
+	parts := strings.Split(path, "/")
+	indexes := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(i) >= HardenedOffset {
+			return nil, fmt.Errorf("invalid derivation path %q", path)
+		}
+		if hardened {
+			i += uint64(HardenedOffset)
+		}
+		indexes = append(indexes, uint32(i))
+	}
+	return indexes, nil
+}
+
+// DeriveAddress 由账户扩展密钥（EthAccountPath 处的 xpub 或 xprv）派生第 index 个充值地址，路径为 0/index
+func DeriveAddress(account *ExtendedKey, index uint32) (common.Address, error) {
+	if index >= HardenedOffset {
+		return common.Address{}, fmt.Errorf("address index %d out of range", index)
+	}
+	key, err := account.Derive("0/" + strconv.FormatUint(uint64(index), 10))
+	if err != nil {
+		return common.Address{}, err
+	}
+	return key.Address()
+}
+
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+func doubleSha256(data []byte) [32]byte {
+	first := sha256.Sum256(data)
+	return sha256.Sum256(first[:])
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(data []byte) string {
+	x := new(big.Int).SetBytes(data)
+	base, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	x, base := new(big.Int), big.NewInt(58)
+	for _, c := range []byte(s) {
+		i := strings.IndexByte(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		x.Mul(x, base).Add(x, big.NewInt(int64(i)))
+	}
+	out := x.Bytes()
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), out...), nil
+}
@@ -0,0 +1,175 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BIP-32 测试向量 1
+func TestBIP32Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		xpub string
+		xprv string
+	}{
+		{
+			path: "m",
+			xpub: "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+			xprv: "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+		},
+		{
+			path: "m/0'",
+			xpub: "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			xprv: "xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+		},
+		{
+			path: "m/0'/1",
+			xpub: "xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+			xprv: "xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs",
+		},
+	}
+	for _, tt := range tests {
+		key, err := master.Derive(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := key.String(); got != tt.xprv {
+			t.Errorf("%s xprv: got %s", tt.path, got)
+		}
+		if got := key.Neuter().String(); got != tt.xpub {
+			t.Errorf("%s xpub: got %s", tt.path, got)
+		}
+		parsed, err := ParseExtendedKey(tt.xprv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != tt.xprv {
+			t.Errorf("%s: round trip mismatch", tt.path)
+		}
+	}
+}
+
+// 通用测试助记词在 m/44'/60'/0'/0/0 的地址
+func TestBIP44EthAddress(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed, err := NewSeed(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive(EthAccountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := DeriveAddress(account, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"); address != want {
+		t.Fatalf("address %s, want %s", address, want)
+	}
+
+	// 在线服务只持有 xpub，派生结果与私钥派生一致
+	xpub, err := ParseExtendedKey(account.Neuter().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 5; i++ {
+		fromPrivate, err := DeriveAddress(account, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromPublic, err := DeriveAddress(xpub, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fromPrivate != fromPublic {
+			t.Fatalf("index %d: xpub derived %s, want %s", i, fromPublic, fromPrivate)
+		}
+	}
+	if _, err := xpub.Child(HardenedOffset); err != ErrHardenedFromPublic {
+		t.Fatalf("expected hardened derivation from xpub to fail, got %v", err)
+	}
+	if _, err := NewSeed("abandon abandon abandon", ""); err == nil {
+		t.Fatal("expected invalid mnemonic to be rejected")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indexes, err := ParsePath("m/44'/60h/0'/0/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{HardenedOffset + 44, HardenedOffset + 60, HardenedOffset, 0, 7}
+	if len(indexes) != len(want) {
+		t.Fatalf("unexpected indexes %v", indexes)
+	}
+	for i := range want {
+		if indexes[i] != want[i] {
+			t.Fatalf("unexpected indexes %v", indexes)
+		}
+	}
+	for _, path := range []string{"m/x", "m/2147483648", "m//1"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Fatalf("expected %q to be rejected", path)
+		}
+	}
+}
+
+func TestHDSigner(t *testing.T) {
+	mnemonic, err := NewMnemonic(128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := NewSeed(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive(EthAccountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewHDSigner(account, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := DeriveAddress(account.Neuter(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.SignTx(context.Background(), address, testTx(), testTx().ChainId())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, _ := types.Sender(types.LatestSignerForChainID(testTx().ChainId()), signed); sender != address {
+		t.Fatalf("signed by %s, want %s", sender, address)
+	}
+
+	beyond, err := DeriveAddress(account.Neuter(), 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.SignTx(context.Background(), beyond, testTx(), testTx().ChainId()); !errors.Is(err, ErrUnknownAccount) {
+		t.Fatalf("expected unknown account beyond max index, got %v", err)
+	}
+	if _, err := NewHDSigner(account.Neuter(), 10); err == nil {
+		t.Fatal("expected xpub to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// HSMModule PKCS#11 风格的签名设备，私钥不出设备，按 key id 引用。
+// Sign 对应 CKM_ECDSA 机制，对 32 字节摘要做 secp256k1 签名，返回 r||s 共 64 字节，不含恢复位
+type HSMModule interface {
+	PublicKey(keyId string) (*ecdsa.PublicKey, error)
+	Sign(keyId string, digest []byte) ([]byte, error)
+}
+
+// HSMFactory 按配置打开签名设备，options 的含义由具体实现决定（如动态库路径、slot、PIN 文件）
+type HSMFactory func(options map[string]string) (HSMModule, error)
+
+var (
+	hsmLock      sync.RWMutex
+	hsmFactories = make(map[string]HSMFactory)
+)
+
+// RegisterHSMModule 登记签名设备实现，一般在实现包的 init 中调用
+func RegisterHSMModule(name string, factory HSMFactory) {
+	hsmLock.Lock()
+	defer hsmLock.Unlock()
+	hsmFactories[name] = factory
+}
+
+// OpenHSMModule 打开已登记的签名设备
+func OpenHSMModule(name string, options map[string]string) (HSMModule, error) {
+	hsmLock.RLock()
+	factory, ok := hsmFactories[name]
+	hsmLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("hsm module %q is not registered", name)
+	}
+	return factory(options)
+}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// HSMSigner 通过签名设备签名交易，地址与设备中 key id 的对应关系由配置给出
+type HSMSigner struct {
+	module HSMModule
+	keys   map[common.Address]string
+}
+
+// NewHSMSigner 校验每个 key id 的公钥与配置的地址一致
+func NewHSMSigner(module HSMModule, keys map[common.Address]string) (*HSMSigner, error) {
+	for address, keyId := range keys {
+		publicKey, err := module.PublicKey(keyId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load hsm key %s: %w", keyId, err)
+		}
+		if crypto.PubkeyToAddress(*publicKey) != address {
+			return nil, fmt.Errorf("hsm key %s does not match address %s", keyId, address)
+		}
+	}
+	return &HSMSigner{module: module, keys: keys}, nil
+}
+
+func (s *HSMSigner) SignTx(_ context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	keyId, ok := s.keys[address]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, address)
+	}
+	signer := types.LatestSignerForChainID(chainId)
+	digest := signer.Hash(tx)
+	rs, err := s.module.Sign(keyId, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("hsm sign with key %s: %w", keyId, err)
+	}
+	sig, err := recoverableSignature(address, digest[:], rs)
+	if err != nil {
+		return nil, fmt.Errorf("hsm key %s: %w", keyId, err)
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// recoverableSignature 把 r||s 转为以太坊使用的 r||s||v。
+// 链上只接受 low-s 签名，设备返回 high-s 时取 N-s，再逐个尝试恢复位找出与地址匹配的 v
+func recoverableSignature(address common.Address, digest, rs []byte) ([]byte, error) {
+	if len(rs) != 64 {
+		return nil, fmt.Errorf("unexpected signature length %d", len(rs))
+	}
+	r := new(big.Int).SetBytes(rs[:32])
+	sv := new(big.Int).SetBytes(rs[32:])
+	if sv.Cmp(secp256k1HalfN) > 0 {
+		sv.Sub(secp256k1N, sv)
+	}
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	sv.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		publicKey, err := crypto.SigToPub(digest, sig)
+		if err == nil && crypto.PubkeyToAddress(*publicKey) == address {
+			return sig, nil
+		}
+	}
+	return nil, fmt.Errorf("signature does not recover to %s", address)
+}
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// KeystoreSigner 使用 go-ethereum keystore v3 加密文件保存私钥，启动时用密码文件解锁
+type KeystoreSigner struct {
+	ks *keystore.KeyStore
+}
+
+// NewKeystoreSigner 加载 dir 中的 keystore 文件并全部解锁，密码从 passwordFile 读取，文件中不需要明文私钥
+func NewKeystoreSigner(dir, passwordFile string) (*KeystoreSigner, error) {
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keystore password: %w", err)
+	}
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	passphrase := strings.TrimRight(string(password), "\r\n")
+	for _, account := range ks.Accounts() {
+		if err := ks.Unlock(account, passphrase); err != nil {
+			return nil, fmt.Errorf("unable to unlock %s: %w", account.Address, err)
+		}
+	}
+	return &KeystoreSigner{ks: ks}, nil
+}
+
+// Addresses 返回 keystore 中的全部地址
+func (s *KeystoreSigner) Addresses() []common.Address {
+	accounts := s.ks.Accounts()
+	addresses := make([]common.Address, 0, len(accounts))
+	for _, account := range accounts {
+		addresses = append(addresses, account.Address)
+	}
+	return addresses
+}
+
+func (s *KeystoreSigner) SignTx(_ context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	if !s.ks.HasAddress(address) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, address)
+	}
+	signed, err := s.ks.SignTx(accounts.Account{Address: address}, tx, chainId)
+	if errors.Is(err, keystore.ErrLocked) {
+		return nil, fmt.Errorf("keystore account %s is locked: %w", address, err)
+	}
+	return signed, err
+}
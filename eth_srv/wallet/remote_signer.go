@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/proto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// signerTokenKey 签名请求携带共享令牌的 metadata 键
+const signerTokenKey = "authorization"
+
+// NewSignerTLSConfig 加载双向 TLS 配置，两端都用 caFile 校验对端证书。
+// 签名机要求业务服务出示证书，业务服务按 serverName 校验签名机证书
+func NewSignerTLSConfig(certFile, keyFile, caFile, serverName string, server bool) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("signer tls requires cert_file, key_file and ca_file")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load signer tls certificate: %w", err)
+	}
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signer tls ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if server {
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		config.RootCAs = pool
+		config.ServerName = serverName
+	}
+	return config, nil
+}
+
+// ReadSignerToken 读取共享令牌文件，文件名为空时不使用令牌
+func ReadSignerToken(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read signer token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("signer token file %s is empty", file)
+	}
+	return token, nil
+}
+
+// tokenCredentials 随每次签名请求发送共享令牌，只允许在 TLS 连接上发送
+type tokenCredentials string
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{signerTokenKey: "Bearer " + string(c)}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// RemoteSigner 通过 gRPC 调用远程签名服务，本进程不接触私钥
+type RemoteSigner struct {
+	client  proto.SignerClient
+	conn    *grpc.ClientConn
+	timeout time.Duration
+}
+
+// DialRemoteSigner 通过双向 TLS 连接 target 上的签名服务，token 不为空时随每次请求发送
+func DialRemoteSigner(target string, tlsConfig *tls.Config, token string, timeout time.Duration) (*RemoteSigner, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("remote signer requires tls")
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to dial remote signer %s: %w", target, err)
+	}
+	signer := NewRemoteSigner(proto.NewSignerClient(conn), timeout)
+	signer.conn = conn
+	return signer, nil
+}
+
+func NewRemoteSigner(client proto.SignerClient, timeout time.Duration) *RemoteSigner {
+	if timeout <= 0 {
+		timeout = time.Second * 5
+	}
+	return &RemoteSigner{client: client, timeout: timeout}
+}
+
+func (s *RemoteSigner) SignTx(ctx context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return s.sign(ctx, address, nil, tx, chainId)
+}
+
+// SignHDTx 由签名机用扩展私钥派生序号 index 的密钥签名，在线服务只保存扩展公钥和地址的派生序号
+func (s *RemoteSigner) SignHDTx(ctx context.Context, index uint32, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return s.sign(ctx, address, &index, tx, chainId)
+}
+
+func (s *RemoteSigner) sign(ctx context.Context, address common.Address, hdIndex *uint32, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	resp, err := s.client.SignTx(ctx, &proto.SignTxReq{
+		Address: address.String(),
+		ChainId: chainId.Uint64(),
+		Tx:      data,
+		HdIndex: hdIndex,
+	})
+	if code := status.Code(err); code == codes.NotFound || code == codes.PermissionDenied {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnknownAccount, address, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(resp.GetSignedTx()); err != nil {
+		return nil, fmt.Errorf("invalid signed tx from remote signer: %w", err)
+	}
+	if err := checkSignature(address, tx, signed, chainId); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+	return signed, nil
+}
+
+func (s *RemoteSigner) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// SignerServer 把本地签名后端发布为 gRPC 签名服务，部署在隔离的签名机上。
+// 只为允许列表中的地址签名，携带派生序号的请求由 hd 签名，hd 为 nil 时拒绝
+type SignerServer struct {
+	proto.UnimplementedSignerServer
+	signer  Signer
+	hd      *HDSigner
+	allowed map[common.Address]struct{}
+	token   string
+}
+
+// NewSignerServer token 不为空时校验每次请求携带的共享令牌
+func NewSignerServer(signer Signer, hd *HDSigner, allowed []common.Address, token string) *SignerServer {
+	s := &SignerServer{signer: signer, hd: hd, allowed: make(map[common.Address]struct{}, len(allowed)), token: token}
+	for _, address := range allowed {
+		s.allowed[address] = struct{}{}
+	}
+	return s
+}
+
+func (s *SignerServer) SignTx(ctx context.Context, req *proto.SignTxReq) (*proto.SignTxResp, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(req.GetAddress()) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address %q", req.GetAddress())
+	}
+	address := common.HexToAddress(req.GetAddress())
+	if _, ok := s.allowed[address]; !ok && req.HdIndex == nil {
+		return nil, status.Errorf(codes.PermissionDenied, "address %s is not allowed", req.GetAddress())
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(req.GetTx()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tx: %v", err)
+	}
+	chainId := new(big.Int).SetUint64(req.GetChainId())
+	var signed *types.Transaction
+	var err error
+	switch {
+	case req.HdIndex == nil:
+		signed, err = s.signer.SignTx(ctx, address, tx, chainId)
+	case s.hd == nil:
+		return nil, status.Error(codes.NotFound, "signer holds no hd keys")
+	default:
+		signed, err = s.hd.SignIndexTx(req.GetHdIndex(), address, tx, chainId)
+	}
+	if errors.Is(err, ErrUnknownAccount) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if errors.Is(err, ErrHDIndexMismatch) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	data, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &proto.SignTxResp{SignedTx: data}, nil
+}
+
+// authenticate 校验请求携带的共享令牌，未配置令牌时只依赖双向 TLS
+func (s *SignerServer) authenticate(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(signerTokenKey) {
+		if subtle.ConstantTimeCompare([]byte(value), []byte("Bearer "+s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid signer token")
+}
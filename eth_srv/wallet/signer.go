@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrUnknownAccount 签名后端没有该地址的密钥
+var ErrUnknownAccount = errors.New("unknown signer account")
+
+// Signer 按地址签名交易，调用方只持有地址，私钥保存在签名后端
+type Signer interface {
+	SignTx(ctx context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+}
+
+type multiSigner []Signer
+
+// NewMultiSigner 依次在多个签名后端中查找地址，由第一个持有该地址密钥的后端签名
+func NewMultiSigner(signers ...Signer) Signer {
+	return multiSigner(signers)
+}
+
+func (s multiSigner) SignTx(ctx context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	for _, signer := range s {
+		signed, err := signer.SignTx(ctx, address, tx, chainId)
+		if errors.Is(err, ErrUnknownAccount) {
+			continue
+		}
+		return signed, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, address)
+}
+
+type keySigner map[common.Address]*ecdsa.PrivateKey
+
+// NewKeySigner 使用内存中的私钥签名，用于测试和尚未迁移到签名后端的地址
+func NewKeySigner(keys ...*ecdsa.PrivateKey) Signer {
+	signer := make(keySigner, len(keys))
+	for _, key := range keys {
+		signer[crypto.PubkeyToAddress(key.PublicKey)] = key
+	}
+	return signer
+}
+
+func (s keySigner) SignTx(_ context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	key, ok := s[address]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, address)
+	}
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), key)
+}
+
+// EncodeSignedTx 编码已签名交易，返回广播用的 0x 前缀 hex 和交易哈希
+func EncodeSignedTx(tx *types.Transaction) (string, string, error) {
+	// 类型化交易需要使用 MarshalBinary 编码，rlp.EncodeToBytes 会额外包一层 RLP 字符串头
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return "", "", err
+	}
+	return "0x" + common.Bytes2Hex(data), tx.Hash().String(), nil
+}
+
+// checkSignature 校验签名后端返回的交易与待签名交易一致且签名地址正确，避免远端或设备替换交易内容
+func checkSignature(address common.Address, unsigned, signed *types.Transaction, chainId *big.Int) error {
+	signer := types.LatestSignerForChainID(chainId)
+	if signer.Hash(unsigned) != signer.Hash(signed) {
+		return fmt.Errorf("signed tx %s does not match the unsigned tx", signed.Hash())
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return fmt.Errorf("unable to recover signer: %w", err)
+	}
+	if sender != address {
+		return fmt.Errorf("tx signed by %s, want %s", sender, address)
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xweb-3/CoinNest/proto"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testPrivateKey = "0cbb2ff952da876c4779200c83f6b90d73ea85a8da82e06c2276a11499922720"
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     58,
+		GasTipCap: big.NewInt(2600000000),
+		GasFeeCap: big.NewInt(2900000000),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1000000000000),
+	})
+}
+
+// assertSigned 校验交易由 address 签名且与本地私钥签名的结果一致，secp256k1 签名使用 RFC6979 确定性随机数
+func assertSigned(t *testing.T, signer Signer, address common.Address) {
+	t.Helper()
+	chainId := big.NewInt(1)
+	signed, err := signer.SignTx(context.Background(), address, testTx(), chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != address {
+		t.Fatalf("signed by %s, want %s", sender, address)
+	}
+	want, err := NewKeySigner(testKey(t)).SignTx(context.Background(), address, testTx(), chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Hash() != want.Hash() {
+		t.Fatalf("tx hash %s, want %s", signed.Hash(), want.Hash())
+	}
+
+	other := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	if _, err := signer.SignTx(context.Background(), other, testTx(), chainId); !errors.Is(err, ErrUnknownAccount) {
+		t.Fatalf("expected unknown account, got %v", err)
+	}
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	dir := t.TempDir()
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(testKey(t), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("passphrase\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewKeystoreSigner(dir, passwordFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSigned(t, signer, account.Address)
+
+	if err := os.WriteFile(passwordFile, []byte("wrong"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeystoreSigner(dir, passwordFile); err == nil {
+		t.Fatal("expected wrong password to be rejected")
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key := testKey(t)
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	proto.RegisterSignerServer(server, NewSignerServer(NewKeySigner(key), nil, []common.Address{crypto.PubkeyToAddress(key.PublicKey)}, ""))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	signer := NewRemoteSigner(proto.NewSignerClient(conn), 0)
+	assertSigned(t, signer, crypto.PubkeyToAddress(key.PublicKey))
+
+	// 调用方取消后不再等待签名服务
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := signer.SignTx(ctx, crypto.PubkeyToAddress(key.PublicKey), testTx(), big.NewInt(1)); status.Code(errors.Unwrap(err)) != codes.Canceled {
+		t.Fatalf("expected cancelled signing call, got %v", err)
+	}
+}
+
+func TestRemoteHDSigner(t *testing.T) {
+	master, err := NewMasterKey([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive(EthAccountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hd, err := NewHDSigner(account, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	proto.RegisterSignerServer(server, NewSignerServer(NewKeySigner(testKey(t)), hd, nil, ""))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	signer := NewRemoteSigner(proto.NewSignerClient(conn), 0)
+
+	address, err := DeriveAddress(account.Neuter(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.SignHDTx(context.Background(), 7, address, testTx(), testTx().ChainId())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, _ := types.Sender(types.LatestSignerForChainID(testTx().ChainId()), signed); sender != address {
+		t.Fatalf("signed by %s, want %s", sender, address)
+	}
+	// 派生地址只能按序号签名，序号不对应或超出范围时拒绝
+	if _, err := signer.SignTx(context.Background(), address, testTx(), testTx().ChainId()); !errors.Is(err, ErrUnknownAccount) {
+		t.Fatalf("address outside the allow list should be rejected, got %v", err)
+	}
+	if _, err := signer.SignHDTx(context.Background(), 6, address, testTx(), testTx().ChainId()); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected permission denied for a mismatched index, got %v", err)
+	}
+	if _, err := signer.SignHDTx(context.Background(), 11, address, testTx(), testTx().ChainId()); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected permission denied beyond max index, got %v", err)
+	}
+}
+
+// writeTestCerts 生成测试 CA 及其签发的签名机和业务服务证书，返回证书文件所在目录
+func writeTestCerts(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePem(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", caDer)
+	for i, name := range []string{"signer", "client"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 2)),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{"signer.test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePem(t, filepath.Join(dir, name+".pem"), "CERTIFICATE", der)
+		writePem(t, filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDer)
+	}
+	return dir
+}
+
+func writePem(t *testing.T, file, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteSignerAuth(t *testing.T) {
+	key := testKey(t)
+	dir := writeTestCerts(t)
+	serverTLS, err := NewSignerTLSConfig(filepath.Join(dir, "signer.pem"), filepath.Join(dir, "signer-key.pem"), filepath.Join(dir, "ca.pem"), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+	proto.RegisterSignerServer(server, NewSignerServer(NewKeySigner(key), nil, []common.Address{crypto.PubkeyToAddress(key.PublicKey)}, "secret"))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	clientTLS, err := NewSignerTLSConfig(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem"), filepath.Join(dir, "ca.pem"), "signer.test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := DialRemoteSigner(listener.Addr().String(), clientTLS, "secret", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+	assertSigned(t, signer, crypto.PubkeyToAddress(key.PublicKey))
+
+	// 令牌不一致时拒绝签名
+	wrongToken, err := DialRemoteSigner(listener.Addr().String(), clientTLS, "other", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wrongToken.Close()
+	if _, err := wrongToken.SignTx(context.Background(), crypto.PubkeyToAddress(key.PublicKey), testTx(), big.NewInt(1)); status.Code(errors.Unwrap(err)) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated, got %v", err)
+	}
+
+	// 没有客户端证书时无法完成握手
+	noCert := clientTLS.Clone()
+	noCert.Certificates = nil
+	anonymous, err := DialRemoteSigner(listener.Addr().String(), noCert, "secret", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer anonymous.Close()
+	if _, err := anonymous.SignTx(context.Background(), crypto.PubkeyToAddress(key.PublicKey), testTx(), big.NewInt(1)); err == nil {
+		t.Fatal("signer should reject clients without a certificate")
+	}
+}
+
+// tamperingSigner 模拟被篡改的远程签名服务，返回另一笔交易的签名
+type tamperingSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s tamperingSigner) SignTx(_ context.Context, address common.Address, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	other := types.NewTx(&types.DynamicFeeTx{ChainID: chainId, Nonce: tx.Nonce(), Gas: tx.Gas(), To: &address, Value: big.NewInt(1)})
+	return types.SignTx(other, types.LatestSignerForChainID(chainId), s.key)
+}
+
+func TestCheckSignature(t *testing.T) {
+	key := testKey(t)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	chainId := big.NewInt(1)
+	signed, err := tamperingSigner{key: key}.SignTx(context.Background(), address, testTx(), chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSignature(address, testTx(), signed, chainId); err == nil {
+		t.Fatal("expected tampered tx to be rejected")
+	}
+}
+
+// softHSM 用内存私钥模拟签名设备，按 CKM_ECDSA 只返回 r||s，highS 为 true 时返回 N-s
+type softHSM struct {
+	keys  map[string]*ecdsa.PrivateKey
+	highS bool
+}
+
+func (h *softHSM) PublicKey(keyId string) (*ecdsa.PublicKey, error) {
+	key, ok := h.keys[keyId]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	return &key.PublicKey, nil
+}
+
+func (h *softHSM) Sign(keyId string, digest []byte) ([]byte, error) {
+	sig, err := crypto.Sign(digest, h.keys[keyId])
+	if err != nil {
+		return nil, err
+	}
+	rs := sig[:64]
+	if h.highS {
+		s := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(rs[32:]))
+		s.FillBytes(rs[32:])
+	}
+	return rs, nil
+}
+
+func TestHSMSigner(t *testing.T) {
+	key := testKey(t)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	for _, highS := range []bool{false, true} {
+		RegisterHSMModule("soft", func(map[string]string) (HSMModule, error) {
+			return &softHSM{keys: map[string]*ecdsa.PrivateKey{"hot-1": key}, highS: highS}, nil
+		})
+		module, err := OpenHSMModule("soft", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := NewHSMSigner(module, map[common.Address]string{address: "hot-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSigned(t, signer, address)
+	}
+
+	module := &softHSM{keys: map[string]*ecdsa.PrivateKey{"hot-1": key}}
+	other := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	if _, err := NewHSMSigner(module, map[common.Address]string{other: "hot-1"}); err == nil {
+		t.Fatal("expected mismatched key id to be rejected")
+	}
+	if _, err := OpenHSMModule("missing", nil); err == nil {
+		t.Fatal("expected unregistered module to be rejected")
+	}
+}
+
+func TestMultiSigner(t *testing.T) {
+	key := testKey(t)
+	signer := NewMultiSigner(NewKeySigner(), NewKeySigner(key))
+	assertSigned(t, signer, crypto.PubkeyToAddress(key.PublicKey))
+}
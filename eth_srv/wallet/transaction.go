@@ -1,72 +1,98 @@
 package wallet
 
 import (
-	"encoding/hex"
+	"context"
+	"errors"
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
 )
 
-// BuildErc20Data 构建ERC-20转账
-func BuildErc20Data(toAddress common.Address, amount *big.Int) []byte {
-	var data []byte
-
-	tranferFnSignature := []byte("transfer(address,uint256)")
-	hash := crypto.Keccak256Hash(tranferFnSignature)
-	methodId := hash[:5]
-	dataAddress := common.LeftPadBytes(toAddress.Bytes(), 32)
-	dataAmount := common.LeftPadBytes(amount.Bytes(), 32)
-
-	data = append(data, methodId...)
-	data = append(data, dataAddress...)
-	data = append(data, dataAmount...)
-
-	return data
+// TxRequest 与交易类型无关的签名请求，实际交易类型由链支持的交易类型决定
+type TxRequest struct {
+	ChainId    *big.Int
+	Nonce      uint64
+	To         *common.Address // 为 nil 时创建合约
+	Value      *big.Int
+	Data       []byte
+	Gas        uint64
+	GasTipCap  *big.Int // EIP-1559 小费上限，legacy 与 EIP-2930 交易忽略
+	GasFeeCap  *big.Int // EIP-1559 费用上限，legacy 与 EIP-2930 交易以此作为 gasPrice
+	AccessList types.AccessList
 }
 
-// BuildErc721Data 构建ERC-20转账
-func BuildErc721Data(fromAddress, toAddress common.Address, tokenId *big.Int) []byte {
-	var data []byte
-
-	tranferFnSignature := []byte("safeTransferFrom(address,address,uint256)")
-	hash := crypto.Keccak256Hash(tranferFnSignature)
-	methodId := hash[:5]
-
-	dataFromAddress := common.LeftPadBytes(fromAddress.Bytes(), 32)
-	dataToAddress := common.LeftPadBytes(toAddress.Bytes(), 32)
-	dataTokenId := common.LeftPadBytes(tokenId.Bytes(), 32)
-
-	data = append(data, methodId...)
-	data = append(data, dataFromAddress...)
-	data = append(data, dataToAddress...)
-	data = append(data, dataTokenId...)
-
-	return data
+// NewTx 按链支持的交易类型构建未签名交易：支持 EIP-1559 时使用动态费用交易，
+// 带访问列表且支持 EIP-2930 时使用访问列表交易，其余情况使用 legacy 交易
+func NewTx(req *TxRequest, capability global_const.TxCapability) (*types.Transaction, error) {
+	if req.ChainId == nil || req.GasFeeCap == nil {
+		return nil, errors.New("chain id and gas fee cap are required")
+	}
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	switch {
+	case capability.DynamicFee:
+		if req.GasTipCap == nil {
+			return nil, errors.New("gas tip cap is required for dynamic fee tx")
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    req.ChainId,
+			Nonce:      req.Nonce,
+			GasTipCap:  req.GasTipCap,
+			GasFeeCap:  req.GasFeeCap,
+			Gas:        req.Gas,
+			To:         req.To,
+			Value:      value,
+			Data:       req.Data,
+			AccessList: req.AccessList,
+		}), nil
+	case len(req.AccessList) > 0 && capability.AccessList:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    req.ChainId,
+			Nonce:      req.Nonce,
+			GasPrice:   req.GasFeeCap,
+			Gas:        req.Gas,
+			To:         req.To,
+			Value:      value,
+			Data:       req.Data,
+			AccessList: req.AccessList,
+		}), nil
+	case len(req.AccessList) > 0:
+		// 丢弃访问列表会改变交易的 gas 消耗，由调用方决定如何处理
+		return nil, errors.New("access list is not supported by the chain")
+	default:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    req.Nonce,
+			GasPrice: req.GasFeeCap,
+			Gas:      req.Gas,
+			To:       req.To,
+			Value:    value,
+			Data:     req.Data,
+		}), nil
+	}
 }
 
-// OfflinesignTx 离线签名
-func OfflineSignTx(txData *types.DynamicFeeTx, privateKey string, chainId *big.Int) (string, string, error) {
-	privateKeyEcdsa, err := crypto.HexToECDSA(privateKey)
-
+// SignTxRequest 构建并签名交易，返回广播用的原始交易和交易哈希
+func SignTxRequest(ctx context.Context, signer Signer, from common.Address, req *TxRequest, capability global_const.TxCapability) (string, string, error) {
+	tx, err := NewTx(req, capability)
 	if err != nil {
 		return "", "", err
 	}
-	tx := types.NewTx(txData)
-	signer := types.LatestSignerForChainID(chainId)
-
-	signedTx, err := types.SignTx(tx, signer, privateKeyEcdsa)
-
+	signed, err := signer.SignTx(ctx, from, tx, req.ChainId)
 	if err != nil {
 		return "", "", err
 	}
+	return EncodeSignedTx(signed)
+}
 
-	signedTxData, err := rlp.EncodeToBytes(signedTx)
-
+// OfflineSignTx 离线签名
+func OfflineSignTx(req *TxRequest, capability global_const.TxCapability, privateKey string) (string, string, error) {
+	privateKeyEcdsa, err := crypto.HexToECDSA(privateKey)
 	if err != nil {
 		return "", "", err
 	}
-	//return "0x" + hex.EncodeToString(signedTxData)[6:], err
-	return "0x" + hex.EncodeToString(signedTxData), signedTx.Hash().String(), nil
+	return SignTxRequest(context.Background(), NewKeySigner(privateKeyEcdsa), crypto.PubkeyToAddress(privateKeyEcdsa.PublicKey), req, capability)
 }
@@ -1,7 +1,7 @@
 package wallet
 
 import (
-	"fmt"
+	"github.com/0xweb-3/CoinNest/eth_srv/common/global_const"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"math/big"
@@ -10,24 +10,58 @@ import (
 
 func TestOfflineSignTx(t *testing.T) {
 	privateKeyHex := "0cbb2ff952da876c4779200c83f6b90d73ea85a8da82e06c2276a11499922720"
-	nonce := uint64(58)
 	toAddress := common.HexToAddress("0x35096AD62E57e86032a3Bb35aDaCF2240d55421D")
-	amount := big.NewInt(1000000000000)
-	gasLimit := uint64(21000)                      // 燃料上限，指定交易可以消耗的最大 Gas 量。
-	maxPriorityFeePerGas := big.NewInt(2600000000) //最大优先费， 付给矿工的额外小费，以提高交易优先级。
-	maxFeePerGas := big.NewInt(2900000000)         // 最大 Gas 费上限，交易可接受的最高 Gas 费用上限
-	chainID := big.NewInt(1)
-	dFeeTx := &types.DynamicFeeTx{
-		ChainID: chainID,
-		Nonce:   nonce,
-		// GasTipCap 和 GasFeeCap 分别等于 maxPriorityFeePerGas 和 maxFeePerGas，控制交易的费用策略。
-		GasTipCap: maxPriorityFeePerGas, // 交易中的小费上限，确定矿工可获得的最大小费金额，直接影响交易被打包的速度
-		GasFeeCap: maxFeePerGas,         // 交易中的 Gas 费用上限，交易可承受的最大 Gas 费用，避免 Gas 价格波动导致交易花费超出预期。
-		Gas:       gasLimit,             // 交易的 Gas 量，指定交易最多能使用的 Gas 量，防止恶意消耗资源。
+	req := &TxRequest{
+		ChainId:   big.NewInt(1),
+		Nonce:     58,
+		GasTipCap: big.NewInt(2600000000), // 最大优先费，付给矿工的额外小费，以提高交易优先级
+		GasFeeCap: big.NewInt(2900000000), // 最大 Gas 费上限，legacy 交易以此作为 gasPrice
+		Gas:       21000,                  // 燃料上限，指定交易可以消耗的最大 Gas 量
 		To:        &toAddress,
-		Value:     amount,
-		Data:      nil,
+		Value:     big.NewInt(1000000000000),
+	}
+	accessList := types.AccessList{{Address: toAddress, StorageKeys: []common.Hash{{}}}}
+
+	tests := []struct {
+		name       string
+		capability global_const.TxCapability
+		accessList types.AccessList
+		wantType   string
+		wantErr    bool
+	}{
+		{name: "dynamic fee", capability: global_const.TxCapabilityOf(global_const.EthereumChainId), wantType: "dynamic_fee"},
+		{name: "dynamic fee with access list", capability: global_const.TxCapabilityOf(global_const.EthereumChainId), accessList: accessList, wantType: "dynamic_fee"},
+		{name: "legacy", capability: global_const.TxCapabilityOf(global_const.OkxChainId), wantType: "legacy"},
+		{name: "access list", capability: global_const.TxCapability{AccessList: true}, accessList: accessList, wantType: "access_list"},
+		{name: "access list unsupported", capability: global_const.TxCapabilityOf(global_const.ZkFairChainId), accessList: accessList, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := *req
+			req.AccessList = tt.accessList
+			txHex, txHash, err := OfflineSignTx(&req, tt.capability, privateKeyHex)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeRawTx(txHex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Type != tt.wantType || decoded.Hash != txHash || decoded.From != "0x72fFaA289993bcaDa2E01612995E5c75dD81cdBC" || decoded.ChainId != "1" {
+				t.Fatalf("unexpected tx %+v", decoded)
+			}
+			if len(decoded.AccessList) != len(tt.accessList) {
+				t.Fatalf("unexpected access list %+v", decoded.AccessList)
+			}
+			if tt.wantType != "dynamic_fee" && decoded.GasPrice != req.GasFeeCap.String() {
+				t.Fatalf("gas price should come from gas fee cap, got %s", decoded.GasPrice)
+			}
+		})
 	}
-	txHex, txHash, _ := OfflineSignTx(dFeeTx, privateKeyHex, chainID)
-	fmt.Println("txHex===", txHex, "txHash==", txHash)
 }
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.3.0
-// - protoc             v3.15.7
+// - protoc             (unknown)
 // source: eth.proto
 
 package proto
@@ -19,91 +19,591 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	User_GetUserById_FullMethodName = "/User/GetUserById"
+	Eth_CreateAddress_FullMethodName         = "/Eth/CreateAddress"
+	Eth_GetAddress_FullMethodName            = "/Eth/GetAddress"
+	Eth_GetBalance_FullMethodName            = "/Eth/GetBalance"
+	Eth_SubmitWithdraw_FullMethodName        = "/Eth/SubmitWithdraw"
+	Eth_GetWithdraw_FullMethodName           = "/Eth/GetWithdraw"
+	Eth_CancelWithdraw_FullMethodName        = "/Eth/CancelWithdraw"
+	Eth_ListDeposits_FullMethodName          = "/Eth/ListDeposits"
+	Eth_ListTransactions_FullMethodName      = "/Eth/ListTransactions"
+	Eth_StreamEvents_FullMethodName          = "/Eth/StreamEvents"
+	Eth_ListWebhookDeliveries_FullMethodName = "/Eth/ListWebhookDeliveries"
+	Eth_ListRetryTasks_FullMethodName        = "/Eth/ListRetryTasks"
+	Eth_RequeueRetryTask_FullMethodName      = "/Eth/RequeueRetryTask"
+	Eth_AbandonRetryTask_FullMethodName      = "/Eth/AbandonRetryTask"
 )
 
-// UserClient is the client API for User service.
+// EthClient is the client API for Eth service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
-type UserClient interface {
-	GetUserById(ctx context.Context, in *GetUserByIdReq, opts ...grpc.CallOption) (*UserInfo, error)
+type EthClient interface {
+	// CreateAddress 为用户生成新的充值地址
+	CreateAddress(ctx context.Context, in *CreateAddressReq, opts ...grpc.CallOption) (*AddressInfo, error)
+	// GetAddress 按地址查询，未指定地址时返回用户最早创建的充值地址
+	GetAddress(ctx context.Context, in *GetAddressReq, opts ...grpc.CallOption) (*AddressInfo, error)
+	// GetBalance 查询地址已入账的余额，未指定代币时返回全部代币
+	GetBalance(ctx context.Context, in *GetBalanceReq, opts ...grpc.CallOption) (*GetBalanceResp, error)
+	// SubmitWithdraw 提交提现，request_id 重复时返回已有的提现
+	SubmitWithdraw(ctx context.Context, in *SubmitWithdrawReq, opts ...grpc.CallOption) (*WithdrawInfo, error)
+	// GetWithdraw 按 id 或 request_id 查询提现
+	GetWithdraw(ctx context.Context, in *GetWithdrawReq, opts ...grpc.CallOption) (*WithdrawInfo, error)
+	// CancelWithdraw 运营人员按 id 或 request_id 取消提现。排队中的提现直接取消，
+	// 已签名或已广播的提现发送同 nonce 的取消交易，取消交易上链后状态变为已取消
+	CancelWithdraw(ctx context.Context, in *CancelWithdrawReq, opts ...grpc.CallOption) (*WithdrawInfo, error)
+	// ListDeposits 按时间倒序分页查询地址的充值
+	ListDeposits(ctx context.Context, in *ListDepositsReq, opts ...grpc.CallOption) (*ListDepositsResp, error)
+	// ListTransactions 按时间倒序分页查询地址相关的充值和提现
+	ListTransactions(ctx context.Context, in *ListTransactionsReq, opts ...grpc.CallOption) (*ListTransactionsResp, error)
+	// StreamEvents 推送 cursor 之后的充值和提现状态变更，追上最新事件后持续等待新事件
+	StreamEvents(ctx context.Context, in *StreamEventsReq, opts ...grpc.CallOption) (Eth_StreamEventsClient, error)
+	// ListWebhookDeliveries 按时间倒序分页查询订阅方的回调投递记录
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesReq, opts ...grpc.CallOption) (*ListWebhookDeliveriesResp, error)
+	// ListRetryTasks 运营人员按状态查看重试任务，默认查看死信
+	ListRetryTasks(ctx context.Context, in *ListRetryTasksReq, opts ...grpc.CallOption) (*ListRetryTasksResp, error)
+	// RequeueRetryTask 把死信或已放弃的任务放回队列并立即重试。死信任务不会自动恢复，这是重新执行的唯一途径
+	RequeueRetryTask(ctx context.Context, in *RetryTaskReq, opts ...grpc.CallOption) (*RetryTask, error)
+	// AbandonRetryTask 放弃等待重试或死信中的任务，对应记录不再广播、入账或归集，需要时可再 Requeue
+	AbandonRetryTask(ctx context.Context, in *RetryTaskReq, opts ...grpc.CallOption) (*RetryTask, error)
 }
 
-type userClient struct {
+type ethClient struct {
 	cc grpc.ClientConnInterface
 }
 
-func NewUserClient(cc grpc.ClientConnInterface) UserClient {
-	return &userClient{cc}
+func NewEthClient(cc grpc.ClientConnInterface) EthClient {
+	return &ethClient{cc}
 }
 
-func (c *userClient) GetUserById(ctx context.Context, in *GetUserByIdReq, opts ...grpc.CallOption) (*UserInfo, error) {
-	out := new(UserInfo)
-	err := c.cc.Invoke(ctx, User_GetUserById_FullMethodName, in, out, opts...)
+func (c *ethClient) CreateAddress(ctx context.Context, in *CreateAddressReq, opts ...grpc.CallOption) (*AddressInfo, error) {
+	out := new(AddressInfo)
+	err := c.cc.Invoke(ctx, Eth_CreateAddress_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// UserServer is the server API for User service.
-// All implementations must embed UnimplementedUserServer
+func (c *ethClient) GetAddress(ctx context.Context, in *GetAddressReq, opts ...grpc.CallOption) (*AddressInfo, error) {
+	out := new(AddressInfo)
+	err := c.cc.Invoke(ctx, Eth_GetAddress_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetBalance(ctx context.Context, in *GetBalanceReq, opts ...grpc.CallOption) (*GetBalanceResp, error) {
+	out := new(GetBalanceResp)
+	err := c.cc.Invoke(ctx, Eth_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) SubmitWithdraw(ctx context.Context, in *SubmitWithdrawReq, opts ...grpc.CallOption) (*WithdrawInfo, error) {
+	out := new(WithdrawInfo)
+	err := c.cc.Invoke(ctx, Eth_SubmitWithdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetWithdraw(ctx context.Context, in *GetWithdrawReq, opts ...grpc.CallOption) (*WithdrawInfo, error) {
+	out := new(WithdrawInfo)
+	err := c.cc.Invoke(ctx, Eth_GetWithdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) CancelWithdraw(ctx context.Context, in *CancelWithdrawReq, opts ...grpc.CallOption) (*WithdrawInfo, error) {
+	out := new(WithdrawInfo)
+	err := c.cc.Invoke(ctx, Eth_CancelWithdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) ListDeposits(ctx context.Context, in *ListDepositsReq, opts ...grpc.CallOption) (*ListDepositsResp, error) {
+	out := new(ListDepositsResp)
+	err := c.cc.Invoke(ctx, Eth_ListDeposits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) ListTransactions(ctx context.Context, in *ListTransactionsReq, opts ...grpc.CallOption) (*ListTransactionsResp, error) {
+	out := new(ListTransactionsResp)
+	err := c.cc.Invoke(ctx, Eth_ListTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) StreamEvents(ctx context.Context, in *StreamEventsReq, opts ...grpc.CallOption) (Eth_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Eth_ServiceDesc.Streams[0], Eth_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Eth_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type ethStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ethClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesReq, opts ...grpc.CallOption) (*ListWebhookDeliveriesResp, error) {
+	out := new(ListWebhookDeliveriesResp)
+	err := c.cc.Invoke(ctx, Eth_ListWebhookDeliveries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) ListRetryTasks(ctx context.Context, in *ListRetryTasksReq, opts ...grpc.CallOption) (*ListRetryTasksResp, error) {
+	out := new(ListRetryTasksResp)
+	err := c.cc.Invoke(ctx, Eth_ListRetryTasks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) RequeueRetryTask(ctx context.Context, in *RetryTaskReq, opts ...grpc.CallOption) (*RetryTask, error) {
+	out := new(RetryTask)
+	err := c.cc.Invoke(ctx, Eth_RequeueRetryTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) AbandonRetryTask(ctx context.Context, in *RetryTaskReq, opts ...grpc.CallOption) (*RetryTask, error) {
+	out := new(RetryTask)
+	err := c.cc.Invoke(ctx, Eth_AbandonRetryTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EthServer is the server API for Eth service.
+// All implementations must embed UnimplementedEthServer
 // for forward compatibility
-type UserServer interface {
-	GetUserById(context.Context, *GetUserByIdReq) (*UserInfo, error)
-	mustEmbedUnimplementedUserServer()
+type EthServer interface {
+	// CreateAddress 为用户生成新的充值地址
+	CreateAddress(context.Context, *CreateAddressReq) (*AddressInfo, error)
+	// GetAddress 按地址查询，未指定地址时返回用户最早创建的充值地址
+	GetAddress(context.Context, *GetAddressReq) (*AddressInfo, error)
+	// GetBalance 查询地址已入账的余额，未指定代币时返回全部代币
+	GetBalance(context.Context, *GetBalanceReq) (*GetBalanceResp, error)
+	// SubmitWithdraw 提交提现，request_id 重复时返回已有的提现
+	SubmitWithdraw(context.Context, *SubmitWithdrawReq) (*WithdrawInfo, error)
+	// GetWithdraw 按 id 或 request_id 查询提现
+	GetWithdraw(context.Context, *GetWithdrawReq) (*WithdrawInfo, error)
+	// CancelWithdraw 运营人员按 id 或 request_id 取消提现。排队中的提现直接取消，
+	// 已签名或已广播的提现发送同 nonce 的取消交易，取消交易上链后状态变为已取消
+	CancelWithdraw(context.Context, *CancelWithdrawReq) (*WithdrawInfo, error)
+	// ListDeposits 按时间倒序分页查询地址的充值
+	ListDeposits(context.Context, *ListDepositsReq) (*ListDepositsResp, error)
+	// ListTransactions 按时间倒序分页查询地址相关的充值和提现
+	ListTransactions(context.Context, *ListTransactionsReq) (*ListTransactionsResp, error)
+	// StreamEvents 推送 cursor 之后的充值和提现状态变更，追上最新事件后持续等待新事件
+	StreamEvents(*StreamEventsReq, Eth_StreamEventsServer) error
+	// ListWebhookDeliveries 按时间倒序分页查询订阅方的回调投递记录
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesReq) (*ListWebhookDeliveriesResp, error)
+	// ListRetryTasks 运营人员按状态查看重试任务，默认查看死信
+	ListRetryTasks(context.Context, *ListRetryTasksReq) (*ListRetryTasksResp, error)
+	// RequeueRetryTask 把死信或已放弃的任务放回队列并立即重试。死信任务不会自动恢复，这是重新执行的唯一途径
+	RequeueRetryTask(context.Context, *RetryTaskReq) (*RetryTask, error)
+	// AbandonRetryTask 放弃等待重试或死信中的任务，对应记录不再广播、入账或归集，需要时可再 Requeue
+	AbandonRetryTask(context.Context, *RetryTaskReq) (*RetryTask, error)
+	mustEmbedUnimplementedEthServer()
 }
 
-// UnimplementedUserServer must be embedded to have forward compatible implementations.
-type UnimplementedUserServer struct {
+// UnimplementedEthServer must be embedded to have forward compatible implementations.
+type UnimplementedEthServer struct {
 }
 
-func (UnimplementedUserServer) GetUserById(context.Context, *GetUserByIdReq) (*UserInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetUserById not implemented")
+func (UnimplementedEthServer) CreateAddress(context.Context, *CreateAddressReq) (*AddressInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAddress not implemented")
+}
+func (UnimplementedEthServer) GetAddress(context.Context, *GetAddressReq) (*AddressInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAddress not implemented")
+}
+func (UnimplementedEthServer) GetBalance(context.Context, *GetBalanceReq) (*GetBalanceResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
 }
-func (UnimplementedUserServer) mustEmbedUnimplementedUserServer() {}
+func (UnimplementedEthServer) SubmitWithdraw(context.Context, *SubmitWithdrawReq) (*WithdrawInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitWithdraw not implemented")
+}
+func (UnimplementedEthServer) GetWithdraw(context.Context, *GetWithdrawReq) (*WithdrawInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWithdraw not implemented")
+}
+func (UnimplementedEthServer) CancelWithdraw(context.Context, *CancelWithdrawReq) (*WithdrawInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelWithdraw not implemented")
+}
+func (UnimplementedEthServer) ListDeposits(context.Context, *ListDepositsReq) (*ListDepositsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeposits not implemented")
+}
+func (UnimplementedEthServer) ListTransactions(context.Context, *ListTransactionsReq) (*ListTransactionsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedEthServer) StreamEvents(*StreamEventsReq, Eth_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedEthServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesReq) (*ListWebhookDeliveriesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
+func (UnimplementedEthServer) ListRetryTasks(context.Context, *ListRetryTasksReq) (*ListRetryTasksResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRetryTasks not implemented")
+}
+func (UnimplementedEthServer) RequeueRetryTask(context.Context, *RetryTaskReq) (*RetryTask, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequeueRetryTask not implemented")
+}
+func (UnimplementedEthServer) AbandonRetryTask(context.Context, *RetryTaskReq) (*RetryTask, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbandonRetryTask not implemented")
+}
+func (UnimplementedEthServer) mustEmbedUnimplementedEthServer() {}
 
-// UnsafeUserServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to UserServer will
+// UnsafeEthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EthServer will
 // result in compilation errors.
-type UnsafeUserServer interface {
-	mustEmbedUnimplementedUserServer()
+type UnsafeEthServer interface {
+	mustEmbedUnimplementedEthServer()
+}
+
+func RegisterEthServer(s grpc.ServiceRegistrar, srv EthServer) {
+	s.RegisterService(&Eth_ServiceDesc, srv)
+}
+
+func _Eth_CreateAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAddressReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).CreateAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_CreateAddress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).CreateAddress(ctx, req.(*CreateAddressReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAddressReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetAddress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetAddress(ctx, req.(*GetAddressReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetBalance(ctx, req.(*GetBalanceReq))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterUserServer(s grpc.ServiceRegistrar, srv UserServer) {
-	s.RegisterService(&User_ServiceDesc, srv)
+func _Eth_SubmitWithdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitWithdrawReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).SubmitWithdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_SubmitWithdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).SubmitWithdraw(ctx, req.(*SubmitWithdrawReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetWithdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWithdrawReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetWithdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetWithdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetWithdraw(ctx, req.(*GetWithdrawReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_CancelWithdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelWithdrawReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).CancelWithdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_CancelWithdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).CancelWithdraw(ctx, req.(*CancelWithdrawReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_ListDeposits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDepositsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).ListDeposits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_ListDeposits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).ListDeposits(ctx, req.(*ListDepositsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).ListTransactions(ctx, req.(*ListTransactionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthServer).StreamEvents(m, &ethStreamEventsServer{stream})
+}
+
+type Eth_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
 }
 
-func _User_GetUserById_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetUserByIdReq)
+type ethStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Eth_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_ListWebhookDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_ListRetryTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRetryTasksReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).ListRetryTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_ListRetryTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).ListRetryTasks(ctx, req.(*ListRetryTasksReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_RequeueRetryTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryTaskReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).RequeueRetryTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_RequeueRetryTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).RequeueRetryTask(ctx, req.(*RetryTaskReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_AbandonRetryTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryTaskReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UserServer).GetUserById(ctx, in)
+		return srv.(EthServer).AbandonRetryTask(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: User_GetUserById_FullMethodName,
+		FullMethod: Eth_AbandonRetryTask_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UserServer).GetUserById(ctx, req.(*GetUserByIdReq))
+		return srv.(EthServer).AbandonRetryTask(ctx, req.(*RetryTaskReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// User_ServiceDesc is the grpc.ServiceDesc for User service.
+// Eth_ServiceDesc is the grpc.ServiceDesc for Eth service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
-var User_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "User",
-	HandlerType: (*UserServer)(nil),
+var Eth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Eth",
+	HandlerType: (*EthServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetUserById",
-			Handler:    _User_GetUserById_Handler,
+			MethodName: "CreateAddress",
+			Handler:    _Eth_CreateAddress_Handler,
+		},
+		{
+			MethodName: "GetAddress",
+			Handler:    _Eth_GetAddress_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _Eth_GetBalance_Handler,
+		},
+		{
+			MethodName: "SubmitWithdraw",
+			Handler:    _Eth_SubmitWithdraw_Handler,
+		},
+		{
+			MethodName: "GetWithdraw",
+			Handler:    _Eth_GetWithdraw_Handler,
+		},
+		{
+			MethodName: "CancelWithdraw",
+			Handler:    _Eth_CancelWithdraw_Handler,
+		},
+		{
+			MethodName: "ListDeposits",
+			Handler:    _Eth_ListDeposits_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _Eth_ListTransactions_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _Eth_ListWebhookDeliveries_Handler,
+		},
+		{
+			MethodName: "ListRetryTasks",
+			Handler:    _Eth_ListRetryTasks_Handler,
+		},
+		{
+			MethodName: "RequeueRetryTask",
+			Handler:    _Eth_RequeueRetryTask_Handler,
+		},
+		{
+			MethodName: "AbandonRetryTask",
+			Handler:    _Eth_AbandonRetryTask_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Eth_StreamEvents_Handler,
+			ServerStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "eth.proto",
 }